@@ -1,10 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -12,15 +16,42 @@ import (
 type Config struct {
 	Port    int             `json:"port"`
 	Timeout int             `json:"timeout_seconds"`
+	DataDir string          `json:"data_dir"`
 	Sites   map[string]Site `json:"sites"`
 }
 
 // Site API站点配置
 type Site struct {
-	Api    string `json:"api"`
-	Name   string `json:"name"`
-	Detail string `json:"detail"`
-	Adult  bool   `json:"adult"`
+	Api      string `json:"api"`
+	Name     string `json:"name"`
+	Type     string `json:"type,omitempty"`
+	Epg      string `json:"epg,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Adult    bool   `json:"adult,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	NoCrawl  bool   `json:"no_crawl,omitempty"` // 不在后台采集到本地目录，始终实时请求
+}
+
+// 站点类型
+const (
+	SiteTypeVod  = "vod"  // MacCMS 点播接口(默认)
+	SiteTypeLive = "live" // 直播频道列表，Api 为 M3U/TXT 文件路径或地址，Epg 为 XMLTV 节目单地址
+	// SiteTypeWebDAV WebDAV/Alist 网盘目录，Api 为根目录地址，Username/Password 为访问凭据(只在服务端使用)
+	SiteTypeWebDAV = "webdav"
+	// SiteTypeLocal 本地片库，Api 为一个或多个本地目录，多个目录按系统路径列表分隔符(Linux 为 ":"，Windows 为 ";")分隔
+	SiteTypeLocal = "local"
+)
+
+// IsLive 是否为直播源
+func (s Site) IsLive() bool {
+	return s.Type == SiteTypeLive
+}
+
+// IsMacCMS 是否为可直接访问的 MacCMS 接口站点
+func (s Site) IsMacCMS() bool {
+	return s.Type == "" || s.Type == SiteTypeVod
 }
 
 const (
@@ -29,6 +60,7 @@ const (
 
 var (
 	config     Config
+	configPath string
 	configLock sync.RWMutex
 )
 
@@ -41,8 +73,10 @@ func Load(path string) error {
 	config = Config{
 		Port:    8080,
 		Timeout: 30,
+		DataDir: "data",
 		Sites:   make(map[string]Site),
 	}
+	configPath = path
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -59,11 +93,31 @@ func Load(path string) error {
 		return fmt.Errorf("解析配置文件失败: %w", err)
 	}
 
+	for key := range sitesMap {
+		if err := checkSiteKey(key); err != nil {
+			return err
+		}
+	}
+
 	config.Sites = sitesMap
 	log.Printf("成功加载 %d 个站点配置", len(sitesMap))
 	return nil
 }
 
+// KeySep 虚拟源视频 ID 中站点标识与原视频 ID 的分隔符，站点标识中不能出现
+const KeySep = "_"
+
+// checkSiteKey 校验站点标识，含分隔符的标识会使虚拟源无法解析视频 ID
+func checkSiteKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("站点标识不能为空")
+	}
+	if strings.Contains(key, KeySep) {
+		return fmt.Errorf("站点标识 %q 不能包含 %q", key, KeySep)
+	}
+	return nil
+}
+
 // Get 获取当前配置
 func Get() Config {
 	configLock.RLock()
@@ -91,3 +145,165 @@ func GetAllSites() map[string]Site {
 	}
 	return sites
 }
+
+// GetEnabledSites 获取参与聚合的点播站点配置，includeAdult 为 false 时排除成人站点
+func GetEnabledSites(includeAdult bool) map[string]Site {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	sites := make(map[string]Site, len(config.Sites))
+	for k, v := range config.Sites {
+		if v.Disabled || v.IsLive() || (v.Adult && !includeAdult) {
+			continue
+		}
+		sites[k] = v
+	}
+	return sites
+}
+
+// ResolvePath 将相对路径解析为相对于配置文件所在目录的路径
+func ResolvePath(path string) string {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	if filepath.IsAbs(path) || configPath == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(configPath), path)
+}
+
+// GetLiveSites 获取已启用的直播源配置
+func GetLiveSites(includeAdult bool) map[string]Site {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	sites := make(map[string]Site)
+	for k, v := range config.Sites {
+		if v.Disabled || !v.IsLive() || (v.Adult && !includeAdult) {
+			continue
+		}
+		sites[k] = v
+	}
+	return sites
+}
+
+// MergeSites 将站点合并到当前配置并写回配置文件
+// 已存在的站点标识会被覆盖，文件中原有站点保持原顺序，新站点追加在末尾
+func MergeSites(sites map[string]Site) error {
+	configLock.Lock()
+	defer configLock.Unlock()
+
+	if configPath == "" {
+		return fmt.Errorf("配置尚未加载")
+	}
+	for key := range sites {
+		if err := checkSiteKey(key); err != nil {
+			return err
+		}
+	}
+
+	// 读取原文件中的站点顺序
+	var order []string
+	if data, err := os.ReadFile(configPath); err == nil {
+		order, err = objectKeys(data)
+		if err != nil {
+			return fmt.Errorf("解析配置文件失败: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	merged := make(map[string]Site, len(config.Sites)+len(sites))
+	for k, v := range config.Sites {
+		merged[k] = v
+	}
+	seen := make(map[string]bool, len(order))
+	for _, k := range order {
+		seen[k] = true
+	}
+	for k := range config.Sites {
+		if !seen[k] {
+			order = append(order, k)
+			seen[k] = true
+		}
+	}
+	newKeys := make([]string, 0, len(sites))
+	for k, v := range sites {
+		merged[k] = v
+		if !seen[k] {
+			newKeys = append(newKeys, k)
+		}
+	}
+	sort.Strings(newKeys)
+	order = append(order, newKeys...)
+
+	// 文件被外部修改时可能包含未加载的站点，只写出当前配置中的站点
+	keys := make([]string, 0, len(order))
+	for _, k := range order {
+		if _, ok := merged[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, k := range keys {
+		key, _ := json.Marshal(k)
+		value, err := json.MarshalIndent(merged[k], "  ", "  ")
+		if err != nil {
+			return err
+		}
+		buf.WriteString("  ")
+		buf.Write(key)
+		buf.WriteString(": ")
+		buf.Write(value)
+		if i < len(keys)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("}\n")
+
+	// 先写临时文件再重命名保证原子性
+	tmp, err := os.CreateTemp(filepath.Dir(configPath), filepath.Base(configPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), configPath); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+
+	config.Sites = merged
+	return nil
+}
+
+// objectKeys 按出现顺序返回 JSON 对象的顶层键
+func objectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("配置文件不是 JSON 对象")
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		keys = append(keys, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
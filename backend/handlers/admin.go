@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/models"
+	"ReelNest/services/importer"
+	"ReelNest/utils"
+)
+
+// AdminTokenEnv 管理接口令牌的环境变量，未设置时管理接口不可用
+const AdminTokenEnv = "REELNEST_ADMIN_TOKEN"
+
+// maxImportBytes 导入的站点列表最大字节数
+const maxImportBytes = 2 << 20
+
+// requireAdmin 校验管理令牌，支持 Authorization: Bearer 与 X-Admin-Token 请求头
+func requireAdmin(c *app.RequestContext) bool {
+	expected := os.Getenv(AdminTokenEnv)
+	if expected == "" {
+		c.JSON(403, models.APIResponse{
+			Code: 403,
+			Msg:  "管理接口未启用，请设置环境变量 " + AdminTokenEnv,
+		})
+		return false
+	}
+
+	token := string(c.GetHeader("X-Admin-Token"))
+	if auth := string(c.GetHeader("Authorization")); token == "" && strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		c.JSON(401, models.APIResponse{
+			Code: 401,
+			Msg:  "管理令牌无效",
+		})
+		return false
+	}
+	return true
+}
+
+// NewImportHandler 创建站点列表导入处理器
+// 请求体为 LibreTV API_SITES 或 TVBox JSON；也可通过 url 参数指定远程列表
+// probe=1 时探测站点可用性，dry_run=1 时只返回导入结果不写入配置
+func NewImportHandler(hc *client.Client, im *importer.Importer) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		data := c.Request.Body()
+		if source := string(c.Query("url")); source != "" {
+			result, err := utils.Fetch(ctx, hc, source, "", maxImportBytes)
+			if err != nil || result.StatusCode != 200 {
+				msg := "获取站点列表失败"
+				if err != nil {
+					msg += ": " + err.Error()
+				}
+				c.JSON(502, models.APIResponse{Code: 502, Msg: msg})
+				return
+			}
+			data = result.Body
+		}
+		if len(data) == 0 {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少站点列表内容",
+			})
+			return
+		}
+		if len(data) > maxImportBytes {
+			c.JSON(413, models.APIResponse{
+				Code: 413,
+				Msg:  "站点列表过大",
+			})
+			return
+		}
+
+		report, err := im.Import(ctx, data, importer.Options{
+			Probe:  string(c.Query("probe")) == "1",
+			DryRun: string(c.Query("dry_run")) == "1",
+		})
+		if err != nil {
+			code := 500
+			if errors.Is(err, importer.ErrUnknownFormat) {
+				code = 400
+			}
+			c.JSON(code, models.APIResponse{
+				Code: code,
+				Msg:  "导入失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, map[string]interface{}{
+			"code":   200,
+			"msg":    "ok",
+			"report": report,
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/users"
+)
+
+// AnonymousEnv 设为 1 时，创建用户后仍允许未携带令牌的请求以默认用户读写观看记录等个人数据
+const AnonymousEnv = "REELNEST_ALLOW_ANONYMOUS"
+
+// 请求上下文中保存认证结果的键
+const (
+	profileKey   = "reelnest.profile"   // 令牌对应的用户
+	anonymousKey = "reelnest.anonymous" // 已创建用户但请求未携带令牌
+	guestKey     = "reelnest.guest"     // 匿名请求可以使用默认用户
+)
+
+// NewAuthMiddleware 创建认证中间件，根据 Authorization: Bearer 令牌识别用户
+// 令牌不是接口令牌(如管理令牌)时交由具体接口校验；创建用户后，未携带令牌的请求
+// 不显示成人内容，且只有 allowAnonymous 为 true 时才能以默认用户访问个人数据
+func NewAuthMiddleware(store *users.Store, allowAnonymous bool) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		token := bearerToken(c)
+		if !strings.HasPrefix(token, users.TokenPrefix) {
+			if store.Count() > 0 {
+				c.Set(anonymousKey, true)
+				c.Set(guestKey, allowAnonymous)
+			}
+			c.Next(ctx)
+			return
+		}
+
+		profile, err := store.Authenticate(token)
+		if err != nil {
+			code, msg := 401, "接口令牌无效或已撤销"
+			if !errors.Is(err, users.ErrInvalidToken) {
+				code, msg = 500, "校验接口令牌失败: "+err.Error()
+			}
+			c.AbortWithStatusJSON(code, models.APIResponse{
+				Code: code,
+				Msg:  msg,
+			})
+			return
+		}
+		c.Set(profileKey, profile)
+		c.Next(ctx)
+	}
+}
+
+// bearerToken 读取 Authorization: Bearer 请求头中的令牌
+func bearerToken(c *app.RequestContext) string {
+	auth := string(c.GetHeader("Authorization"))
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+}
+
+// requestProfile 返回请求令牌对应的用户，匿名请求返回 nil
+func requestProfile(c *app.RequestContext) *models.UserProfile {
+	if v, ok := c.Get(profileKey); ok {
+		if p, ok := v.(*models.UserProfile); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// requireProfile 要求请求携带有效的接口令牌
+func requireProfile(c *app.RequestContext) (*models.UserProfile, bool) {
+	p := requestProfile(c)
+	if p == nil {
+		c.JSON(401, models.APIResponse{
+			Code: 401,
+			Msg:  "需要接口令牌，请使用 Authorization: Bearer 请求头",
+		})
+		return nil, false
+	}
+	return p, true
+}
+
+// adultAllowed 是否包含成人内容：登录用户按个人设置，创建用户后匿名请求一律不包含，
+// 未创建任何用户时按 adult=1 参数
+func adultAllowed(c *app.RequestContext) bool {
+	if p := requestProfile(c); p != nil {
+		return p.Settings.Adult
+	}
+	if c.GetBool(anonymousKey) {
+		return false
+	}
+	return string(c.Query("adult")) == "1"
+}
+
+// enabledSites 参与聚合的站点，登录用户设置了常用站点时只查询其中仍可用的站点
+func enabledSites(c *app.RequestContext) map[string]config.Site {
+	sites := config.GetEnabledSites(adultAllowed(c))
+	p := requestProfile(c)
+	if p == nil || len(p.Settings.PreferredSources) == 0 {
+		return sites
+	}
+
+	preferred := make(map[string]config.Site, len(p.Settings.PreferredSources))
+	for _, key := range p.Settings.PreferredSources {
+		if site, ok := sites[key]; ok {
+			preferred[key] = site
+		}
+	}
+	// 常用站点全部停用时退回全部站点，避免结果为空
+	if len(preferred) == 0 {
+		return sites
+	}
+	return preferred
+}
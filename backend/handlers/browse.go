@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/browse"
+	"ReelNest/services/expand"
+)
+
+const (
+	// defaultLatestHours 最新更新默认时间窗口
+	defaultLatestHours = 24
+	// maxLatestHours 最新更新最大时间窗口(30天)
+	maxLatestHours = 720
+)
+
+// NewCategoriesHandler 创建站点分类处理器
+func NewCategoriesHandler(b *browse.Browser) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		sourceCode := string(c.Query("source"))
+		site, ok := requireSite(c, sourceCode)
+		if !ok {
+			return
+		}
+
+		categories, err := b.Categories(ctx, sourceCode, site)
+		if err != nil {
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "获取分类失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(categories),
+			List:  categories,
+		})
+	}
+}
+
+// NewBrowseHandler 创建分类浏览处理器
+func NewBrowseHandler(b *browse.Browser, index *expand.Index) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		sourceCode := string(c.Query("source"))
+		site, ok := requireSite(c, sourceCode)
+		if !ok {
+			return
+		}
+
+		page, _ := strconv.Atoi(string(c.Query("pg")))
+		result, err := b.Videos(ctx, sourceCode, site, string(c.Query("t")), string(c.Query("type")), page)
+		if err != nil {
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "获取视频列表失败: " + err.Error(),
+			})
+			return
+		}
+
+		indexTitles(index, result)
+		absoluteCovers(c, result.List)
+		c.JSON(200, pageResponse(result))
+	}
+}
+
+// NewLatestHandler 创建跨站最新更新处理器
+func NewLatestHandler(b *browse.Browser, index *expand.Index) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		hours := defaultLatestHours
+		if raw := string(c.Query("h")); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 || n > maxLatestHours {
+				c.JSON(400, models.APIResponse{
+					Code: 400,
+					Msg:  "h 需为 1-" + strconv.Itoa(maxLatestHours) + " 之间的整数",
+				})
+				return
+			}
+			hours = n
+		}
+		page, _ := strconv.Atoi(string(c.Query("pg")))
+
+		// 指定 source 时只查询单个站点
+		sites := enabledSites(c)
+		if sourceCode := string(c.Query("source")); sourceCode != "" {
+			site, ok := requireSite(c, sourceCode)
+			if !ok {
+				return
+			}
+			sites = map[string]config.Site{sourceCode: site}
+		}
+
+		result := b.Latest(ctx, sites, hours, page, string(c.Query("type")))
+		indexTitles(index, result)
+		absoluteCovers(c, result.List)
+		c.JSON(200, pageResponse(result))
+	}
+}
+
+// requireSite 校验 source 参数，失败时直接写入错误响应
+func requireSite(c *app.RequestContext, sourceCode string) (config.Site, bool) {
+	if sourceCode == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少必要参数 source",
+		})
+		return config.Site{}, false
+	}
+	site, ok := config.GetSite(sourceCode)
+	if !ok || site.IsLive() {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "不支持的源: " + sourceCode,
+		})
+		return config.Site{}, false
+	}
+	if !siteAllowed(c, sourceCode, site) {
+		return config.Site{}, false
+	}
+	return site, true
+}
+
+// siteAllowed 校验指定的站点对当前请求可用：未停用，成人站点需开启成人内容
+func siteAllowed(c *app.RequestContext, sourceCode string, site config.Site) bool {
+	if site.Disabled {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "站点已停用: " + sourceCode,
+		})
+		return false
+	}
+	if site.Adult && !adultAllowed(c) {
+		c.JSON(403, models.APIResponse{
+			Code: 403,
+			Msg:  "未开启成人内容: " + sourceCode,
+		})
+		return false
+	}
+	return true
+}
+
+// pageResponse 将分页结果转换为通用响应
+func pageResponse(p *browse.Page) models.APIResponse {
+	return models.APIResponse{
+		Code:      200,
+		Msg:       "ok",
+		Page:      p.Page,
+		PageCount: p.PageCount,
+		Total:     p.Total,
+		List:      p.List,
+	}
+}
+
+// indexTitles 将列表中的标题加入搜索词扩展索引
+func indexTitles(index *expand.Index, p *browse.Page) {
+	titles := make([]string, 0, len(p.List))
+	for _, v := range p.List {
+		titles = append(titles, v.Title)
+	}
+	index.Add(titles...)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/linkcheck"
+	"ReelNest/services/maccms"
+)
+
+// maxCheckURLs 单次检测的最大链接数
+const maxCheckURLs = 500
+
+// episodeCheckRequest 剧集检测请求体
+type episodeCheckRequest struct {
+	Source string   `json:"source"`
+	ID     string   `json:"id"`
+	URLs   []string `json:"urls"`
+}
+
+// NewEpisodeCheckHandler 创建剧集链接检测处理器
+func NewEpisodeCheckHandler(mac *maccms.Client, checker *linkcheck.Checker) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		handleEpisodeCheck(ctx, c, mac, checker)
+	}
+}
+
+// handleEpisodeCheck 检测详情中的剧集或直接提交的链接列表
+func handleEpisodeCheck(ctx context.Context, c *app.RequestContext, mac *maccms.Client, checker *linkcheck.Checker) {
+	var req episodeCheckRequest
+	if body := c.Request.Body(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "请求体格式错误: " + err.Error(),
+			})
+			return
+		}
+	}
+	if req.Source == "" {
+		req.Source = string(c.Query("source"))
+	}
+	if req.ID == "" {
+		req.ID = string(c.Query("id"))
+	}
+
+	var episodes []models.EpisodeInfo
+	switch {
+	case len(req.URLs) > 0:
+		for i, u := range req.URLs {
+			episodes = append(episodes, models.EpisodeInfo{Title: fmt.Sprintf("第%d集", i+1), Url: u})
+		}
+	case req.Source != "" && req.ID != "":
+		site, ok := requireSite(c, req.Source)
+		if !ok {
+			return
+		}
+		video, err := mac.Detail(ctx, site, req.ID)
+		if err != nil {
+			code := 502
+			if errors.Is(err, maccms.ErrNotFound) {
+				code = 404
+			}
+			c.JSON(code, models.APIResponse{
+				Code: code,
+				Msg:  "获取详情失败: " + err.Error(),
+			})
+			return
+		}
+		episodes = video.Episodes()
+	default:
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "请提供 source 和 id，或链接列表 urls",
+		})
+		return
+	}
+
+	if len(episodes) > maxCheckURLs {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  fmt.Sprintf("单次最多检测 %d 个链接", maxCheckURLs),
+		})
+		return
+	}
+
+	results := checker.Check(ctx, episodes)
+	c.JSON(200, models.APIResponse{
+		Code:  200,
+		Msg:   "ok",
+		Total: len(results),
+		List:  results,
+	})
+}
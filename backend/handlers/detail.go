@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/catalog"
+	"ReelNest/services/hls"
+	"ReelNest/services/maccms"
+	"ReelNest/services/suggest"
+)
+
+// maxProbeEpisodes 单次详情请求最多新探测的剧集数，已缓存的结果不计入
+// 每集探测需请求播放列表并下载测速分片，限制数量以免单个请求放大为大量上游流量
+const maxProbeEpisodes = 10
+
+// NewDetailHandler 创建标准化详情处理器
+func NewDetailHandler(mac *maccms.Client, store *catalog.Store, prober *hls.Prober, sug *suggest.Index) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		handleDetail(ctx, c, mac, store, prober, sug)
+	}
+}
+
+// handleDetail 获取视频详情并转换为统一结构，probe=1 时附带各集清晰度信息
+// 优先读取本地目录，本地没有时再请求上游
+func handleDetail(ctx context.Context, c *app.RequestContext, mac *maccms.Client, store *catalog.Store, prober *hls.Prober, sug *suggest.Index) {
+	id := string(c.Query("id"))
+	sourceCode := string(c.Query("source"))
+
+	if id == "" || sourceCode == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少必要参数 id 或 source",
+		})
+		return
+	}
+
+	site, ok := requireSite(c, sourceCode)
+	if !ok {
+		return
+	}
+
+	video, err := store.Get(sourceCode, id)
+	if err != nil {
+		video, err = mac.Detail(ctx, site, id)
+	}
+	if err != nil {
+		code := 502
+		if errors.Is(err, maccms.ErrNotFound) {
+			code = 404
+		}
+		c.JSON(code, models.APIResponse{
+			Code: code,
+			Msg:  "获取详情失败: " + err.Error(),
+		})
+		return
+	}
+
+	sug.AddTitles(video.VodName)
+
+	// 登录用户按个人设置选择默认播放线路
+	line := ""
+	if p := requestProfile(c); p != nil {
+		line = p.Settings.DefaultPlayLine
+	}
+	episodes := video.EpisodesFrom(line)
+	info := video.Info(sourceCode, site)
+	if site.Type == config.SiteTypeWebDAV || site.Type == config.SiteTypeLocal {
+		base := requestBaseURL(c)
+		for i := range episodes {
+			episodes[i].Url = absoluteURL(base, episodes[i].Url)
+		}
+		info.CoverUrl = absoluteURL(base, info.CoverUrl)
+	}
+	if string(c.Query("probe")) == "1" {
+		prober.ProbeEpisodes(ctx, episodes, maxProbeEpisodes)
+	}
+
+	response := models.SpecialDetailResponse{
+		Code:      200,
+		Episodes:  episodes,
+		VideoInfo: info,
+	}
+	if site.Detail != "" {
+		response.DetailUrl = buildDetailUrl(site.Detail, id)
+	}
+
+	c.JSON(200, response)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"path"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/webdav"
+	"ReelNest/utils"
+)
+
+// driveForwardHeaders 转发给网盘的请求头，用于拖动进度与缓存校验
+var driveForwardHeaders = []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+
+// driveResponseHeaders 返回给播放器的网盘响应头
+var driveResponseHeaders = []string{"Content-Range", "Accept-Ranges", "Last-Modified", "ETag"}
+
+// NewDriveStreamHandler 创建网盘视频播放处理器
+// 以站点配置中的凭据请求网盘文件并流式转发，凭据不会出现在播放地址中
+func NewDriveStreamHandler(drive *webdav.Drive) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		key := string(c.Query("site"))
+		site, ok := config.GetSite(key)
+		if !ok || site.Type != config.SiteTypeWebDAV {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "不支持的网盘: " + key,
+			})
+			return
+		}
+		if !siteAllowed(c, key, site) {
+			return
+		}
+		// 只允许访问根目录下的视频文件
+		p := path.Clean("/" + string(c.Query("path")))
+		if !utils.IsVideoFile(p) {
+			c.JSON(403, models.APIResponse{
+				Code: 403,
+				Msg:  "只能播放视频文件",
+			})
+			return
+		}
+
+		header := make(map[string]string)
+		for _, name := range driveForwardHeaders {
+			if v := string(c.GetHeader(name)); v != "" {
+				header[name] = v
+			}
+		}
+		method := "GET"
+		if string(c.Method()) == "HEAD" {
+			method = "HEAD"
+		}
+
+		resp, err := drive.Stream(ctx, site, p, method, header)
+		if err != nil {
+			log.Printf("请求网盘文件 %s%s 失败: %v", key, p, err)
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "请求网盘文件失败: " + err.Error(),
+			})
+			return
+		}
+
+		switch code := resp.StatusCode(); code {
+		case 200, 206, 304, 416:
+		default:
+			resp.CloseBodyStream()
+			protocol.ReleaseResponse(resp)
+			if code == 404 {
+				c.JSON(404, models.APIResponse{Code: 404, Msg: "文件不存在"})
+				return
+			}
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "网盘返回错误状态",
+			})
+			return
+		}
+
+		c.Status(resp.StatusCode())
+		c.Header("Content-Type", string(resp.Header.ContentType()))
+		for _, name := range driveResponseHeaders {
+			if v := resp.Header.Peek(name); len(v) > 0 {
+				c.Header(name, string(v))
+			}
+		}
+		if method == "HEAD" || !resp.IsBodyStream() {
+			if method == "HEAD" {
+				c.Header("Content-Length", string(resp.Header.Peek("Content-Length")))
+			}
+			c.Response.SetBody(resp.Body())
+			resp.CloseBodyStream()
+			protocol.ReleaseResponse(resp)
+			return
+		}
+		// 响应体由服务端写完后关闭，届时释放上游连接
+		c.Response.SetBodyStream(&upstreamBody{resp: resp}, resp.Header.ContentLength())
+	}
+}
+
+// upstreamBody 转发中的上游响应体，关闭时释放上游响应
+type upstreamBody struct {
+	resp *protocol.Response
+}
+
+func (b *upstreamBody) Read(p []byte) (int, error) {
+	return b.resp.BodyStream().Read(p)
+}
+
+func (b *upstreamBody) Close() error {
+	err := b.resp.CloseBodyStream()
+	protocol.ReleaseResponse(b.resp)
+	return err
+}
+
+var _ io.ReadCloser = (*upstreamBody)(nil)
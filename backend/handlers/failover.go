@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/failover"
+)
+
+// NewFailoverHandler 创建跨源备选地址处理器
+func NewFailoverHandler(finder *failover.Finder) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		handleFailover(ctx, c, finder)
+	}
+}
+
+// handleFailover 根据标题、年份和集数查找其它源的可播放地址
+func handleFailover(ctx context.Context, c *app.RequestContext, finder *failover.Finder) {
+	title := string(c.Query("title"))
+	if title == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少必要参数 title",
+		})
+		return
+	}
+
+	episode := 1
+	if raw := string(c.Query("episode")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "episode 必须为正整数",
+			})
+			return
+		}
+		episode = n
+	}
+
+	var preferred []string
+	if p := requestProfile(c); p != nil {
+		preferred = p.Settings.PreferredSources
+	}
+	alternatives := finder.Find(ctx, failover.Query{
+		Title:        title,
+		Year:         string(c.Query("year")),
+		Episode:      episode,
+		Exclude:      string(c.Query("exclude")),
+		IncludeAdult: adultAllowed(c),
+		Check:        string(c.Query("check")) != "0",
+		Preferred:    preferred,
+	})
+
+	c.JSON(200, models.APIResponse{
+		Code:  200,
+		Msg:   "ok",
+		Total: len(alternatives),
+		List:  alternatives,
+	})
+}
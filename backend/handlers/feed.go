@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/browse"
+	"ReelNest/services/expand"
+	"ReelNest/services/feed"
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+	"ReelNest/services/taxonomy"
+)
+
+const (
+	// maxFeedItems 订阅中的最大条目数
+	maxFeedItems = 100
+	// maxSearchFeedPages 搜索订阅最多查看的最新更新页数
+	maxSearchFeedPages = 3
+	// maxFeedSummary 条目简介的最大字符数
+	maxFeedSummary = 300
+)
+
+// NewSiteFeedHandler 创建站点最新更新订阅处理器，参数 source、h(小时)、type(统一分类)、format=rss|atom
+func NewSiteFeedHandler(b *browse.Browser) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		sourceCode := string(c.Query("source"))
+		site, ok := requireSite(c, sourceCode)
+		if !ok {
+			return
+		}
+		hours, ok := feedHours(c)
+		if !ok {
+			return
+		}
+
+		page := b.Latest(ctx, map[string]config.Site{sourceCode: site}, hours, 1, string(c.Query("type")))
+		base := requestBaseURL(c)
+		writeFeed(c, &feed.Feed{
+			Title:       site.Name + " 最新更新",
+			Link:        base + "/api/latest?" + url.Values{"source": {sourceCode}}.Encode(),
+			Description: "站点 " + site.Name + " 最近 " + strconv.Itoa(hours) + " 小时更新的视频",
+			Items:       feedItems(base, page.List),
+		})
+	}
+}
+
+// NewCategoryFeedHandler 创建统一分类最新更新订阅处理器，参数 type(如 series 或 series/kr)、h、adult、format
+func NewCategoryFeedHandler(b *browse.Browser) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		canonical := strings.ToLower(strings.TrimSpace(string(c.Query("type"))))
+		label, ok := canonicalLabel(canonical)
+		if !ok {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "不支持的分类: " + canonical,
+			})
+			return
+		}
+		hours, ok := feedHours(c)
+		if !ok {
+			return
+		}
+
+		sites := enabledSites(c)
+		page := b.Latest(ctx, sites, hours, 1, canonical)
+		base := requestBaseURL(c)
+		writeFeed(c, &feed.Feed{
+			Title:       label + " 最新更新",
+			Link:        base + "/api/latest?" + url.Values{"type": {canonical}}.Encode(),
+			Description: "所有站点最近 " + strconv.Itoa(hours) + " 小时更新的" + label,
+			Items:       feedItems(base, page.List),
+		})
+	}
+}
+
+// NewSearchFeedHandler 创建搜索订阅处理器：最近更新的视频中标题匹配 wd 的结果
+// 订阅地址本身即保存的搜索，参数 wd、source、h、adult、format
+func NewSearchFeedHandler(b *browse.Browser, exp *expand.Expander) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		keyword := strings.TrimSpace(string(c.Query("wd")))
+		if keyword == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 wd",
+			})
+			return
+		}
+		hours, ok := feedHours(c)
+		if !ok {
+			return
+		}
+		sites := enabledSites(c)
+		if sourceCode := string(c.Query("source")); sourceCode != "" {
+			site, ok := requireSite(c, sourceCode)
+			if !ok {
+				return
+			}
+			sites = map[string]config.Site{sourceCode: site}
+		}
+
+		var keys []string
+		for _, kw := range exp.Expand(keyword) {
+			if k := matcher.Normalize(kw); k != "" {
+				keys = append(keys, k)
+			}
+		}
+		var matched []models.VideoInfo
+		for pg := 1; pg <= maxSearchFeedPages; pg++ {
+			page := b.Latest(ctx, sites, hours, pg, string(c.Query("type")))
+			for _, v := range page.List {
+				title := matcher.Normalize(v.Title)
+				for _, k := range keys {
+					if strings.Contains(title, k) {
+						matched = append(matched, v)
+						break
+					}
+				}
+			}
+			if pg >= page.PageCount {
+				break
+			}
+		}
+
+		base := requestBaseURL(c)
+		writeFeed(c, &feed.Feed{
+			Title:       "搜索: " + keyword,
+			Link:        base + "/api/search?" + url.Values{"wd": {keyword}}.Encode(),
+			Description: "最近 " + strconv.Itoa(hours) + " 小时更新的视频中与「" + keyword + "」匹配的结果",
+			Items:       feedItems(base, matched),
+		})
+	}
+}
+
+// writeFeed 按 format 参数输出 RSS 或 Atom，支持 If-None-Match 与 If-Modified-Since 条件请求
+func writeFeed(c *app.RequestContext, f *feed.Feed) {
+	format := feed.FormatRSS
+	if string(c.Query("format")) == feed.FormatAtom {
+		format = feed.FormatAtom
+	}
+	f.Self = requestBaseURL(c) + string(c.Request.URI().RequestURI())
+
+	etag := f.ETag(format)
+	updated := f.Updated()
+	c.Header("ETag", etag)
+	if !updated.IsZero() {
+		c.Header("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	if notModified(c, etag, updated) {
+		c.Status(304)
+		return
+	}
+
+	data, contentType, err := f.Render(format)
+	if err != nil {
+		c.JSON(500, models.APIResponse{
+			Code: 500,
+			Msg:  "生成订阅失败: " + err.Error(),
+		})
+		return
+	}
+	c.Data(200, contentType, data)
+}
+
+// notModified 判断条件请求是否命中，If-None-Match 优先于 If-Modified-Since
+func notModified(c *app.RequestContext, etag string, updated time.Time) bool {
+	if inm := string(c.GetHeader("If-None-Match")); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := string(c.GetHeader("If-Modified-Since")); ims != "" && !updated.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !updated.After(t)
+		}
+	}
+	return false
+}
+
+// feedItems 将视频列表转换为订阅条目，条目标识包含更新时间，视频再次更新时作为新条目出现
+func feedItems(base string, list []models.VideoInfo) []feed.Item {
+	if len(list) > maxFeedItems {
+		list = list[:maxFeedItems]
+	}
+	items := make([]feed.Item, 0, len(list))
+	for _, v := range list {
+		updated, _ := time.ParseInLocation(maccms.TimeLayout, v.UpdatedAt, time.Local)
+		title := v.Title
+		if v.Remarks != "" {
+			title += " " + v.Remarks
+		}
+		summary := v.Desc
+		if utf8.RuneCountInString(summary) > maxFeedSummary {
+			summary = string([]rune(summary)[:maxFeedSummary]) + "…"
+		}
+		if v.SourceName != "" {
+			summary = "[" + v.SourceName + "] " + summary
+		}
+		items = append(items, feed.Item{
+			ID:      v.SourceCode + ":" + url.PathEscape(v.ID) + ":" + strconv.FormatInt(updated.Unix(), 10),
+			Title:   title,
+			Link:    detailPageURL(base, v.SourceCode, v.ID),
+			Summary: strings.TrimSpace(summary),
+			Image:   absoluteURL(base, v.CoverUrl),
+			Updated: updated,
+		})
+	}
+	return items
+}
+
+// feedHours 读取 h 参数(小时)，默认 24，无效时写入错误响应
+func feedHours(c *app.RequestContext) (int, bool) {
+	raw := string(c.Query("h"))
+	if raw == "" {
+		return defaultLatestHours, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 || n > maxLatestHours {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "h 需为 1-" + strconv.Itoa(maxLatestHours) + " 之间的整数",
+		})
+		return 0, false
+	}
+	return n, true
+}
+
+// canonicalLabel 查找统一分类(type 或 type/genre)的名称
+func canonicalLabel(canonical string) (string, bool) {
+	r := taxonomy.ParseResult(canonical)
+	for _, t := range taxonomy.Types() {
+		if t.ID != r.Type {
+			continue
+		}
+		if r.Genre == "" {
+			return t.Label, true
+		}
+		for _, g := range t.Genres {
+			if g.ID == r.Genre {
+				return g.Label, true
+			}
+		}
+	}
+	return "", false
+}
+
+// detailPageURL 视频的详情地址：站点配置了详情页时使用站点页面，否则为本服务的详情接口
+func detailPageURL(base, source, id string) string {
+	if site, ok := config.GetSite(source); ok && site.Detail != "" {
+		return buildDetailUrl(site.Detail, id)
+	}
+	return base + "/api/detail?" + url.Values{"source": {source}, "id": {id}}.Encode()
+}
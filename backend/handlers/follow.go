@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/follow"
+	"ReelNest/services/maccms"
+)
+
+// maxCalendarUpdates 日历中包含的最近更新记录数
+const maxCalendarUpdates = 500
+
+// followRequest 追剧请求体
+type followRequest struct {
+	Source string `json:"source"`
+	ID     string `json:"id"`
+}
+
+// markReadRequest 标记已读请求体，ids 为空时全部标记为已读
+type markReadRequest struct {
+	IDs []uint64 `json:"ids"`
+}
+
+// NewFollowsHandler 创建追剧列表处理器
+func NewFollowsHandler(store *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		list, err := store.Follows(user)
+		if err != nil {
+			followError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// NewFollowHandler 创建追剧处理器，记录当前集数作为之后检查新剧集的基准
+func NewFollowHandler(store *follow.Store, watcher *follow.Watcher) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var req followRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.Source == "" || req.ID == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 source 或 id",
+			})
+			return
+		}
+		if _, ok := requireSite(c, req.Source); !ok {
+			return
+		}
+
+		f, episodes, err := watcher.Fetch(ctx, req.Source, req.ID)
+		if err != nil {
+			code := 502
+			if errors.Is(err, maccms.ErrNotFound) {
+				code = 404
+			}
+			c.JSON(code, models.APIResponse{
+				Code: code,
+				Msg:  "获取详情失败: " + err.Error(),
+			})
+			return
+		}
+		f.EpisodeCount = len(episodes)
+		if len(episodes) > 0 {
+			f.LastEpisode = episodes[len(episodes)-1]
+		}
+		saved, err := store.Follow(user, f)
+		if err != nil {
+			followError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":   200,
+			"msg":    "ok",
+			"follow": saved,
+		})
+	}
+}
+
+// NewUnfollowHandler 创建取消追剧处理器
+func NewUnfollowHandler(store *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		source, id := string(c.Query("source")), string(c.Query("id"))
+		if source == "" || id == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 source 或 id",
+			})
+			return
+		}
+		if err := store.Unfollow(user, source, id); err != nil {
+			followError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{Code: 200, Msg: "ok"})
+	}
+}
+
+// NewFollowUpdatesHandler 创建追剧更新列表处理器，unread=1 时只返回未读更新
+func NewFollowUpdatesHandler(store *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		limit, _ := strconv.Atoi(string(c.Query("limit")))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		list, unread, err := store.Updates(user, string(c.Query("unread")) == "1", limit)
+		if err != nil {
+			followError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":   200,
+			"msg":    "ok",
+			"unread": unread,
+			"list":   list,
+		})
+	}
+}
+
+// NewFollowMarkReadHandler 创建追剧更新标记已读处理器
+func NewFollowMarkReadHandler(store *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var req markReadRequest
+		if len(c.Request.Body()) > 0 && !bindJSON(c, &req) {
+			return
+		}
+		marked, err := store.MarkRead(user, req.IDs)
+		if err != nil {
+			followError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":   200,
+			"msg":    "ok",
+			"marked": marked,
+		})
+	}
+}
+
+// NewFollowCheckHandler 创建立即检查追剧更新的管理处理器
+func NewFollowCheckHandler(watcher *follow.Watcher) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !requireAdmin(c) {
+			return
+		}
+		n, err := watcher.Check(ctx)
+		if err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "检查追剧更新失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":    200,
+			"msg":     "ok",
+			"updates": n,
+		})
+	}
+}
+
+// NewFollowCalendarTokenHandler 创建日历订阅令牌处理器：GET 获取(没有时生成)，POST 重新生成并使旧地址失效
+func NewFollowCalendarTokenHandler(store *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		token, err := store.CalendarToken(user, string(c.Method()) == "POST")
+		if err != nil {
+			followError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":  200,
+			"msg":   "ok",
+			"token": token,
+			"url":   requestBaseURL(c) + "/api/follows/calendar.ics?" + url.Values{"token": {token}}.Encode(),
+		})
+	}
+}
+
+// NewFollowCalendarHandler 创建追剧日历处理器，以订阅令牌识别用户，供日历应用订阅
+func NewFollowCalendarHandler(store *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		token := string(c.Query("token"))
+		user, ok, err := store.CalendarUser(token)
+		if err != nil {
+			followError(c, err)
+			return
+		}
+		if token == "" || !ok {
+			c.JSON(401, models.APIResponse{
+				Code: 401,
+				Msg:  "日历订阅令牌无效",
+			})
+			return
+		}
+		updates, _, err := store.Updates(user, false, maxCalendarUpdates)
+		if err != nil {
+			followError(c, err)
+			return
+		}
+
+		base := requestBaseURL(c)
+		detailURL := func(source, id string) string {
+			return detailPageURL(base, source, id)
+		}
+		c.Header("Content-Disposition", `inline; filename="follows.ics"`)
+		c.Data(200, "text/calendar; charset=utf-8", follow.Calendar("追剧更新", updates, detailURL))
+	}
+}
+
+// followError 按错误类型写入追剧接口的错误响应
+func followError(c *app.RequestContext, err error) {
+	code := 500
+	switch {
+	case errors.Is(err, follow.ErrNotFound):
+		code = 404
+	case errors.Is(err, follow.ErrTooMany):
+		code = 409
+	}
+	c.JSON(code, models.APIResponse{
+		Code: code,
+		Msg:  err.Error(),
+	})
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/history"
+	"ReelNest/services/users"
+)
+
+const (
+	// defaultUser 未指定用户时使用的用户名
+	defaultUser = users.DefaultUser
+	// maxNameLength 用户名与设备名的最大长度
+	maxNameLength = 64
+	// maxSyncEntries 单次同步最多提交的记录数
+	maxSyncEntries = history.MaxEntries
+)
+
+// historySyncRequest 同步请求体：cursor 为上次同步返回的变更序号，entries 为本机变化的记录
+type historySyncRequest struct {
+	Cursor  uint64                 `json:"cursor"`
+	Entries []models.WatchProgress `json:"entries"`
+}
+
+// NewHistoryListHandler 创建观看记录列表处理器，按最近观看排序分页
+func NewHistoryListHandler(store *history.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		page, _ := strconv.Atoi(string(c.Query("pg")))
+		size, _ := strconv.Atoi(string(c.Query("size")))
+		page, size = max(page, 1), min(max(size, 0), 100)
+		if size == 0 {
+			size = 20
+		}
+
+		list, total, err := store.List(user, page, size)
+		if err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "读取观看记录失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:      200,
+			Msg:       "ok",
+			Page:      page,
+			PageCount: (total + size - 1) / size,
+			Total:     total,
+			List:      list,
+		})
+	}
+}
+
+// NewContinueWatchingHandler 创建继续观看处理器：未看完的视频与有下一集的剧集
+func NewContinueWatchingHandler(store *history.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		limit, _ := strconv.Atoi(string(c.Query("limit")))
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+
+		list, err := store.Continue(user, limit)
+		if err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "读取观看记录失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// NewHistoryProgressHandler 创建播放进度查询处理器，用于从上次位置继续播放
+func NewHistoryProgressHandler(store *history.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		source, id := string(c.Query("source")), string(c.Query("id"))
+		if source == "" || id == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 source 或 id",
+			})
+			return
+		}
+
+		p, err := store.Get(user, source, id)
+		if err != nil {
+			code := 500
+			if errors.Is(err, history.ErrNotFound) {
+				code = 404
+			}
+			c.JSON(code, models.APIResponse{
+				Code: code,
+				Msg:  err.Error(),
+			})
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":     200,
+			"msg":      "ok",
+			"progress": p,
+		})
+	}
+}
+
+// NewHistoryRecordHandler 创建播放进度上报处理器，播放器定期提交当前集数与位置
+func NewHistoryRecordHandler(store *history.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var p models.WatchProgress
+		if err := json.Unmarshal(c.Request.Body(), &p); err != nil {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "请求体格式错误: " + err.Error(),
+			})
+			return
+		}
+		if p.Source == "" || p.VideoID == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 source 或 id",
+			})
+			return
+		}
+		if p.Episode < 0 || p.Position < 0 || p.Duration < 0 {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "episode、position、duration 不能为负数",
+			})
+			return
+		}
+		if device := requestDevice(c); device != "" {
+			p.Device = device
+		}
+
+		saved, err := store.Put(user, p)
+		if err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "保存观看记录失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":     200,
+			"msg":      "ok",
+			"progress": saved,
+		})
+	}
+}
+
+// NewHistoryDeleteHandler 创建观看记录删除处理器，不指定 source 与 id 时清空全部记录
+func NewHistoryDeleteHandler(store *history.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		source, id := string(c.Query("source")), string(c.Query("id"))
+		if (source == "") != (id == "") {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "source 与 id 需同时提供",
+			})
+			return
+		}
+		if err := store.Delete(user, source, id); err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "删除观看记录失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(200, models.APIResponse{Code: 200, Msg: "ok"})
+	}
+}
+
+// NewHistorySyncHandler 创建观看记录同步处理器
+// 设备提交上次同步以来本机变化的记录，服务端按更新时间合并后返回上次同步以来的变化
+func NewHistorySyncHandler(store *history.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var req historySyncRequest
+		if body := c.Request.Body(); len(body) > 0 {
+			if err := json.Unmarshal(body, &req); err != nil {
+				c.JSON(400, models.APIResponse{
+					Code: 400,
+					Msg:  "请求体格式错误: " + err.Error(),
+				})
+				return
+			}
+		}
+		if len(req.Entries) > maxSyncEntries {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "单次最多同步 " + strconv.Itoa(maxSyncEntries) + " 条记录",
+			})
+			return
+		}
+
+		device := requestDevice(c)
+		for i := range req.Entries {
+			if req.Entries[i].Device == "" {
+				req.Entries[i].Device = device
+			}
+		}
+		applied, err := store.Merge(user, req.Entries)
+		if err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "合并观看记录失败: " + err.Error(),
+			})
+			return
+		}
+		changes, cursor, err := store.Since(user, req.Cursor)
+		if err != nil {
+			c.JSON(500, models.APIResponse{
+				Code: 500,
+				Msg:  "读取观看记录失败: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":    200,
+			"msg":     "ok",
+			"applied": applied,
+			"cursor":  cursor,
+			"entries": changes,
+		})
+	}
+}
+
+// requestUser 读取请求的用户名，未指定时为默认用户，无效时写入错误响应
+// 携带接口令牌时为令牌对应的用户；已创建用户后匿名请求需要令牌，
+// 允许匿名访问时使用默认用户；未创建任何用户时沿用 X-User 请求头或 user 参数
+func requestUser(c *app.RequestContext) (string, bool) {
+	if p := requestProfile(c); p != nil {
+		return p.Name, true
+	}
+	if c.GetBool(anonymousKey) {
+		if !c.GetBool(guestKey) {
+			requireProfile(c)
+			return "", false
+		}
+		return defaultUser, true
+	}
+
+	user := strings.TrimSpace(string(c.GetHeader("X-User")))
+	if user == "" {
+		user = strings.TrimSpace(string(c.Query("user")))
+	}
+	if user == "" {
+		return defaultUser, true
+	}
+	if len(user) > maxNameLength || strings.ContainsRune(user, 0) {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "无效的用户名",
+		})
+		return "", false
+	}
+	return user, true
+}
+
+// requestDevice 读取请求的设备名(X-Device 请求头或 device 参数)
+func requestDevice(c *app.RequestContext) string {
+	device := strings.TrimSpace(string(c.GetHeader("X-Device")))
+	if device == "" {
+		device = strings.TrimSpace(string(c.Query("device")))
+	}
+	if len(device) > maxNameLength {
+		device = device[:maxNameLength]
+	}
+	return device
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/imageproxy"
+	"ReelNest/utils"
+)
+
+// NewImageHandler 创建封面图片代理处理器
+func NewImageHandler(client *client.Client, cache *imageproxy.Cache) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		handleImageProxy(ctx, c, client, cache)
+	}
+}
+
+// handleImageProxy 代理封面图片，支持缩放、转码与磁盘缓存
+func handleImageProxy(ctx context.Context, c *app.RequestContext, client *client.Client, cache *imageproxy.Cache) {
+	rawURL := string(c.Query("url"))
+	target, err := url.Parse(rawURL)
+	if rawURL == "" || err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少或无效的图片地址 url",
+		})
+		return
+	}
+
+	width, _ := strconv.Atoi(string(c.Query("w")))
+	height, _ := strconv.Atoi(string(c.Query("h")))
+	quality, _ := strconv.Atoi(string(c.Query("q")))
+	opt, err := imageproxy.Options{
+		Width:   width,
+		Height:  height,
+		Format:  string(c.Query("format")),
+		Quality: quality,
+	}.Normalize()
+	if err != nil {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  err.Error(),
+		})
+		return
+	}
+
+	// 命中缓存直接返回
+	key := opt.Key(rawURL)
+	if data, contentType, ok := cache.Get(key); ok {
+		writeImage(c, data, contentType, "HIT")
+		return
+	}
+
+	// 确定防盗链所需的 Referer：优先使用站点配置，否则使用图片自身域名
+	referer := target.Scheme + "://" + target.Host + "/"
+	if site, ok := config.GetSite(string(c.Query("site"))); ok {
+		referer = site.Api
+		if site.Detail != "" {
+			referer = site.Detail
+		}
+	}
+
+	data, err := fetchImage(ctx, client, rawURL, referer)
+	if errors.Is(err, utils.ErrPrivateAddress) {
+		c.JSON(403, models.APIResponse{
+			Code: 403,
+			Msg:  err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("获取封面失败 %s: %v", rawURL, err)
+		writePlaceholder(c, opt.Width)
+		return
+	}
+
+	contentType, ok := imageproxy.ContentTypeOf(data)
+	if !ok {
+		log.Printf("封面不是有效图片 %s", rawURL)
+		writePlaceholder(c, opt.Width)
+		return
+	}
+
+	if !opt.IsPassthrough() {
+		data, contentType, err = imageproxy.Transform(data, opt)
+		if err != nil {
+			log.Printf("处理封面失败 %s: %v", rawURL, err)
+			writePlaceholder(c, opt.Width)
+			return
+		}
+	}
+
+	if err := cache.Put(key, data); err != nil {
+		log.Printf("写入图片缓存失败: %v", err)
+	}
+	writeImage(c, data, contentType, "MISS")
+}
+
+// fetchImage 下载图片，带 Referer 被拒绝时去掉 Referer 重试一次
+// 跟随重定向，每一跳都只允许访问公网地址
+func fetchImage(ctx context.Context, client *client.Client, rawURL, referer string) ([]byte, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	result, err := utils.FetchChecked(reqCtx, client, rawURL, referer, imageproxy.MaxSourceBytes, utils.CheckPublicURL)
+	if err == nil && (result.StatusCode == 403 || result.StatusCode == 401) {
+		result, err = utils.FetchChecked(reqCtx, client, rawURL, "", imageproxy.MaxSourceBytes, utils.CheckPublicURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.StatusCode != 200 {
+		return nil, fmt.Errorf("状态码: %d", result.StatusCode)
+	}
+	return result.Body, nil
+}
+
+// writeImage 输出图片并设置缓存头
+func writeImage(c *app.RequestContext, data []byte, contentType, cacheStatus string) {
+	c.Header("Cache-Control", "public, max-age=604800")
+	c.Header("X-Cache", cacheStatus)
+	c.Data(200, contentType, data)
+}
+
+// writePlaceholder 输出占位图，短时间缓存以便源站恢复后重新获取
+func writePlaceholder(c *app.RequestContext, width int) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Header("X-Image-Placeholder", "1")
+	c.Data(200, "image/png", imageproxy.Placeholder(width))
+}
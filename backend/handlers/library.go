@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/library"
+	"ReelNest/services/webdav"
+)
+
+// NewLibraryFileHandler 创建本地片库文件处理器，支持 Range 请求以便拖动进度
+func NewLibraryFileHandler(lib *library.Library) func(context.Context, *app.RequestContext) {
+	var mu sync.Mutex
+	// 每个片库目录一个文件处理器，复用其中的文件句柄缓存
+	handlers := make(map[string]app.HandlerFunc)
+	handlerFor := func(root string) app.HandlerFunc {
+		mu.Lock()
+		defer mu.Unlock()
+		h, ok := handlers[root]
+		if !ok {
+			h = (&app.FS{Root: root, AcceptByteRange: true}).NewRequestHandler()
+			handlers[root] = h
+		}
+		return h
+	}
+
+	return func(ctx context.Context, c *app.RequestContext) {
+		key := string(c.Query("site"))
+		site, ok := config.GetSite(key)
+		if !ok || site.Type != config.SiteTypeLocal {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "不支持的片库: " + key,
+			})
+			return
+		}
+		if !siteAllowed(c, key, site) {
+			return
+		}
+		root, rel, ok := lib.Resolve(site, string(c.Query("path")))
+		if !ok {
+			c.JSON(404, models.APIResponse{
+				Code: 404,
+				Msg:  "文件不存在",
+			})
+			return
+		}
+
+		// 文件处理器按请求路径查找文件，改写为片库目录中的相对路径
+		c.Request.URI().SetPath("/" + rel)
+		handlerFor(root)(ctx, c)
+	}
+}
+
+// absoluteURL 将网盘与本地片库的相对地址补全为完整地址，其它地址保持不变
+func absoluteURL(base, u string) string {
+	if strings.HasPrefix(u, webdav.StreamPath) || strings.HasPrefix(u, library.FilePath) {
+		return base + u
+	}
+	return u
+}
+
+// absoluteCovers 补全列表中本地片库海报的相对地址
+func absoluteCovers(c *app.RequestContext, list []models.VideoInfo) {
+	base := requestBaseURL(c)
+	for i := range list {
+		list[i].CoverUrl = absoluteURL(base, list[i].CoverUrl)
+	}
+}
+
+// absolutePlayURL 补全播放列表中网盘与本地片库的相对地址
+func absolutePlayURL(base, playURL string) string {
+	for _, prefix := range []string{webdav.StreamPath, library.FilePath} {
+		playURL = strings.ReplaceAll(playURL, "$"+prefix, "$"+base+prefix)
+	}
+	return playURL
+}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/epg"
+	"ReelNest/services/linkcheck"
+	"ReelNest/services/live"
+)
+
+// maxLiveCheckChannels 单次检测的最大频道数
+const maxLiveCheckChannels = 100
+
+// liveChannelCheck 频道检测结果，任一播放地址可用即视为可用
+type liveChannelCheck struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Group     string                `json:"group"`
+	Available bool                  `json:"available"`
+	Checks    []models.EpisodeCheck `json:"checks"`
+}
+
+// liveNowNext 频道的正在播出与下一档节目
+type liveNowNext struct {
+	ID    string            `json:"id"`
+	Name  string            `json:"name"`
+	Group string            `json:"group"`
+	Now   *models.Programme `json:"now,omitempty"`
+	Next  *models.Programme `json:"next,omitempty"`
+}
+
+// NewLiveChannelsHandler 创建直播频道列表处理器
+// source 为空时返回所有直播源，group 筛选分组，结果按分组整理
+func NewLiveChannelsHandler(lm *live.Manager) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		sites, ok := liveSites(c)
+		if !ok {
+			return
+		}
+
+		var channels []models.LiveChannel
+		if source := string(c.Query("source")); source != "" {
+			list, err := lm.Channels(ctx, source, sites[source])
+			if err != nil {
+				c.JSON(502, models.APIResponse{
+					Code: 502,
+					Msg:  "加载频道列表失败: " + err.Error(),
+				})
+				return
+			}
+			channels = list
+		} else {
+			channels = lm.All(ctx, sites)
+		}
+		if group := string(c.Query("group")); group != "" {
+			channels = live.FilterGroup(channels, group)
+		}
+
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(channels),
+			List:  live.Groups(channels),
+		})
+	}
+}
+
+// NewLiveSearchHandler 创建直播频道搜索处理器
+func NewLiveSearchHandler(lm *live.Manager) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		wd := string(c.Query("wd"))
+		if wd == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少搜索关键词",
+			})
+			return
+		}
+		sites, ok := liveSites(c)
+		if !ok {
+			return
+		}
+
+		list := lm.Search(ctx, sites, wd)
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// NewLiveCheckHandler 创建直播频道检测处理器
+// 按 id 检测单个频道，或按 group 检测整个分组，逐个检测频道的全部播放地址
+func NewLiveCheckHandler(lm *live.Manager, checker *linkcheck.Checker) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		source, site, ok := requireLiveSource(c)
+		if !ok {
+			return
+		}
+
+		id, group := string(c.Query("id")), string(c.Query("group"))
+		var targets []models.LiveChannel
+		switch {
+		case id != "":
+			ch, found, err := lm.Channel(ctx, source, site, id)
+			if err != nil {
+				c.JSON(502, models.APIResponse{
+					Code: 502,
+					Msg:  "加载频道列表失败: " + err.Error(),
+				})
+				return
+			}
+			if !found {
+				c.JSON(404, models.APIResponse{
+					Code: 404,
+					Msg:  "频道不存在: " + id,
+				})
+				return
+			}
+			targets = []models.LiveChannel{ch}
+		case group != "":
+			channels, err := lm.Channels(ctx, source, site)
+			if err != nil {
+				c.JSON(502, models.APIResponse{
+					Code: 502,
+					Msg:  "加载频道列表失败: " + err.Error(),
+				})
+				return
+			}
+			targets = live.FilterGroup(channels, group)
+		default:
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "请提供频道 id 或分组 group",
+			})
+			return
+		}
+		if len(targets) > maxLiveCheckChannels {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  fmt.Sprintf("单次最多检测 %d 个频道", maxLiveCheckChannels),
+			})
+			return
+		}
+
+		// 所有播放地址一起交给检测器并发检测，再按频道拆分结果
+		var episodes []models.EpisodeInfo
+		for _, ch := range targets {
+			for _, u := range ch.URLs {
+				episodes = append(episodes, models.EpisodeInfo{Title: ch.Name, Url: u})
+			}
+		}
+		checks := checker.Check(ctx, episodes)
+
+		results := make([]liveChannelCheck, 0, len(targets))
+		offset := 0
+		for _, ch := range targets {
+			result := liveChannelCheck{
+				ID:     ch.ID,
+				Name:   ch.Name,
+				Group:  ch.Group,
+				Checks: checks[offset : offset+len(ch.URLs)],
+			}
+			offset += len(ch.URLs)
+			for _, check := range result.Checks {
+				if check.Status == linkcheck.StatusOK {
+					result.Available = true
+					break
+				}
+			}
+			results = append(results, result)
+		}
+
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(results),
+			List:  results,
+		})
+	}
+}
+
+// NewLiveM3UHandler 创建直播源 M3U 输出处理器，TXT 列表统一转换为 M3U
+func NewLiveM3UHandler(lm *live.Manager) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		source, site, ok := requireLiveSource(c)
+		if !ok {
+			return
+		}
+		pl, err := lm.Playlist(ctx, source, site)
+		if err != nil {
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "加载频道列表失败: " + err.Error(),
+			})
+			return
+		}
+		c.Data(200, "audio/x-mpegurl; charset=utf-8", live.Encode(pl.EPG, pl.Channels))
+	}
+}
+
+// NewLiveEPGHandler 创建频道正在播出/下一档节目处理器
+// 给出 id 时返回单个频道，否则返回直播源(可按 group 筛选)中所有有节目单的频道
+func NewLiveEPGHandler(lm *live.Manager, guide *epg.Service) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		source, site, ok := requireLiveSource(c)
+		if !ok {
+			return
+		}
+		channels, err := lm.Channels(ctx, source, site)
+		if err != nil {
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "加载频道列表失败: " + err.Error(),
+			})
+			return
+		}
+
+		now := time.Now()
+		if id := string(c.Query("id")); id != "" {
+			for _, ch := range channels {
+				if ch.ID != id {
+					continue
+				}
+				g, found := guide.NowNext(ch, now)
+				if !found {
+					c.JSON(404, models.APIResponse{
+						Code: 404,
+						Msg:  "频道没有节目单: " + ch.Name,
+					})
+					return
+				}
+				c.JSON(200, models.APIResponse{
+					Code:  200,
+					Msg:   "ok",
+					Total: 1,
+					List:  []liveNowNext{{ID: ch.ID, Name: ch.Name, Group: ch.Group, Now: g.Now, Next: g.Next}},
+				})
+				return
+			}
+			c.JSON(404, models.APIResponse{
+				Code: 404,
+				Msg:  "频道不存在: " + id,
+			})
+			return
+		}
+
+		if group := string(c.Query("group")); group != "" {
+			channels = live.FilterGroup(channels, group)
+		}
+		list := make([]liveNowNext, 0, len(channels))
+		for _, ch := range channels {
+			if g, found := guide.NowNext(ch, now); found {
+				list = append(list, liveNowNext{ID: ch.ID, Name: ch.Name, Group: ch.Group, Now: g.Now, Next: g.Next})
+			}
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// NewLiveScheduleHandler 创建频道节目表处理器
+// date 为 YYYY-MM-DD(服务器时区)，缺省为当天
+func NewLiveScheduleHandler(lm *live.Manager, guide *epg.Service) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		source, site, ok := requireLiveSource(c)
+		if !ok {
+			return
+		}
+		id := string(c.Query("id"))
+		if id == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少频道 id",
+			})
+			return
+		}
+
+		day := time.Now()
+		if date := string(c.Query("date")); date != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", date, time.Local)
+			if err != nil {
+				c.JSON(400, models.APIResponse{
+					Code: 400,
+					Msg:  "日期格式应为 YYYY-MM-DD",
+				})
+				return
+			}
+			day = parsed
+		}
+		from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+
+		ch, found, err := lm.Channel(ctx, source, site, id)
+		if err != nil {
+			c.JSON(502, models.APIResponse{
+				Code: 502,
+				Msg:  "加载频道列表失败: " + err.Error(),
+			})
+			return
+		}
+		if !found {
+			c.JSON(404, models.APIResponse{
+				Code: 404,
+				Msg:  "频道不存在: " + id,
+			})
+			return
+		}
+		list, found := guide.Schedule(ch, from, from.AddDate(0, 0, 1))
+		if !found {
+			c.JSON(404, models.APIResponse{
+				Code: 404,
+				Msg:  "频道没有节目单: " + ch.Name,
+			})
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// liveSites 解析 source 参数，为空时返回所有已启用的直播源
+// 开启成人内容时包含成人直播源；参数无效时写入错误响应并返回 false
+func liveSites(c *app.RequestContext) (map[string]config.Site, bool) {
+	source := string(c.Query("source"))
+	if source == "" {
+		return config.GetLiveSites(adultAllowed(c)), true
+	}
+	site, ok := config.GetSite(source)
+	if !ok || !site.IsLive() {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "不支持的直播源: " + source,
+		})
+		return nil, false
+	}
+	if !siteAllowed(c, source, site) {
+		return nil, false
+	}
+	return map[string]config.Site{source: site}, true
+}
+
+// requireLiveSource 读取必填的 source 参数并校验为已启用的直播源
+func requireLiveSource(c *app.RequestContext) (string, config.Site, bool) {
+	source := string(c.Query("source"))
+	if source == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少直播源 source",
+		})
+		return "", config.Site{}, false
+	}
+	sites, ok := liveSites(c)
+	if !ok {
+		return "", config.Site{}, false
+	}
+	return source, sites[source], true
+}
@@ -40,7 +40,7 @@ func proxyHandler(ctx context.Context, c *app.RequestContext, client *client.Cli
 		base = customAPI
 	} else if site != "" {
 		siteConfig, ok := config.GetSite(site)
-		if !ok {
+		if !ok || !siteConfig.IsMacCMS() {
 			c.String(400, "未知数据源: %s", site)
 			return
 		}
@@ -174,4 +174,4 @@ func handleResponse(ctx context.Context, c *app.RequestContext, resp *protocol.R
 	c.Write(body)
 
 	return nil
-}
\ No newline at end of file
+}
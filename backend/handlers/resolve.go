@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/resolver"
+)
+
+// NewResolveHandler 创建播放页直链解析处理器
+func NewResolveHandler(r *resolver.Resolver) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		handleResolve(ctx, c, r)
+	}
+}
+
+// handleResolve 将分享页或播放页地址解析为 m3u8/mp4 直链
+// format=tvbox 时按 TVBox JSON 解析接口的格式返回
+func handleResolve(ctx context.Context, c *app.RequestContext, r *resolver.Resolver) {
+	tvboxFormat := string(c.Query("format")) == "tvbox"
+
+	rawURL := string(c.Query("url"))
+	target, err := url.Parse(rawURL)
+	if rawURL == "" || err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少或无效的地址 url",
+		})
+		return
+	}
+
+	stream, err := r.Resolve(ctx, rawURL, string(c.Query("referer")))
+	if tvboxFormat {
+		if err != nil {
+			c.JSON(422, map[string]interface{}{"url": "", "msg": err.Error()})
+			return
+		}
+		c.JSON(200, map[string]interface{}{"url": stream.Url, "header": stream.Headers})
+		return
+	}
+	if err != nil {
+		c.JSON(422, map[string]interface{}{
+			"code": 422,
+			"msg":  "解析失败: " + err.Error(),
+			"hops": stream.Hops,
+		})
+		return
+	}
+
+	c.JSON(200, map[string]interface{}{
+		"code":   200,
+		"msg":    "ok",
+		"stream": stream,
+	})
+}
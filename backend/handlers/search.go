@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/expand"
+	"ReelNest/services/matcher"
+	"ReelNest/services/search"
+	"ReelNest/services/suggest"
+	"ReelNest/services/taxonomy"
+)
+
+const (
+	// defaultSearchPageSize 搜索默认每页条数
+	defaultSearchPageSize = 20
+	// maxSearchPageSize 搜索最大每页条数
+	maxSearchPageSize = 100
+)
+
+// NewSearchHandler 创建聚合搜索处理器
+func NewSearchHandler(agg *search.Aggregator, exp *expand.Expander, sug *suggest.Index) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		handleSearch(ctx, c, agg, exp, sug)
+	}
+}
+
+// handleSearch 聚合搜索所有启用站点(或指定站点)，按作品去重后筛选、排序并分页
+// 搜索词会扩展为繁简体及拼音对应的候选标题后一并查询
+// 筛选参数: year、area、type(统一分类)、has_m3u8=1；分页参数: pg、size
+// 结果标题与有结果的搜索词计入搜索联想索引
+func handleSearch(ctx context.Context, c *app.RequestContext, agg *search.Aggregator, exp *expand.Expander, sug *suggest.Index) {
+	keyword := strings.TrimSpace(string(c.Query("wd")))
+	if keyword == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少必要参数 wd",
+		})
+		return
+	}
+
+	sites := enabledSites(c)
+	if sourceCode := string(c.Query("source")); sourceCode != "" {
+		site, ok := requireSite(c, sourceCode)
+		if !ok {
+			return
+		}
+		sites = map[string]config.Site{sourceCode: site}
+	}
+
+	page, _ := strconv.Atoi(string(c.Query("pg")))
+	size, _ := strconv.Atoi(string(c.Query("size")))
+	if size <= 0 || size > maxSearchPageSize {
+		size = defaultSearchPageSize
+	}
+	filter := search.Filter{
+		Year:      string(c.Query("year")),
+		Area:      string(c.Query("area")),
+		Canonical: string(c.Query("type")),
+		HasM3U8:   string(c.Query("has_m3u8")) == "1",
+	}
+
+	keywords := exp.Expand(keyword)
+	var items []matcher.Item
+	var titles []string
+	for _, hit := range search.Hits(agg.SearchAll(ctx, keywords, sites)) {
+		item := hit.Item()
+		items = append(items, item)
+		titles = append(titles, item.Info.Title)
+	}
+	exp.Index().Add(titles...)
+	sug.AddTitles(titles...)
+	if len(items) > 0 {
+		sug.RecordQuery(keyword)
+	}
+
+	// 同一作品在多个源的结果合并为一条，再筛选、排序、分页
+	works := filter.Apply(matcher.Group(items))
+	works = search.Rank(keywords, works, agg.Tracker())
+	list, pageCount := search.Paginate(works, page, size)
+	base := requestBaseURL(c)
+	for i := range list {
+		list[i].Info.CoverUrl = absoluteURL(base, list[i].Info.CoverUrl)
+	}
+
+	c.JSON(200, models.APIResponse{
+		Code:      200,
+		Msg:       "ok",
+		Page:      max(page, 1),
+		PageCount: pageCount,
+		Total:     len(works),
+		List:      list,
+	})
+}
+
+// NewTaxonomyHandler 创建统一分类体系处理器
+func NewTaxonomyHandler() func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		types := taxonomy.Types()
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(types),
+			List:  types,
+		})
+	}
+}
@@ -49,7 +49,7 @@ func handleSpecialSourceDetail(ctx context.Context, c *app.RequestContext, clien
 
 	// 检查是否支持该源
 	siteConfig, ok := config.GetSite(sourceCode)
-	if !ok {
+	if !ok || !siteConfig.IsMacCMS() {
 		c.JSON(400, models.APIResponse{
 			Code: 400,
 			Msg:  "不支持的源: " + sourceCode,
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/suggest"
+)
+
+const (
+	// defaultSuggestLimit 联想词默认条数
+	defaultSuggestLimit = 10
+	// maxSuggestLimit 联想词最大条数
+	maxSuggestLimit = 20
+)
+
+// NewSuggestHandler 创建搜索联想处理器
+// prefix 支持中文(简繁均可)、全拼与拼音首字母前缀
+func NewSuggestHandler(index *suggest.Index) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		limit, _ := strconv.Atoi(string(c.Query("limit")))
+		if limit <= 0 || limit > maxSuggestLimit {
+			limit = defaultSuggestLimit
+		}
+
+		list := index.Suggest(string(c.Query("prefix")), limit)
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/services/health"
+	"ReelNest/services/tvbox"
+)
+
+// NewTVBoxHandler 创建 TVBox 配置导出处理器
+// adult=1 时包含成人站点，all=1 时保留当前不可用的站点
+func NewTVBoxHandler(tracker *health.Tracker) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		cfg := tvbox.Build(tracker, tvbox.Options{
+			BaseURL:      requestBaseURL(c),
+			IncludeAdult: adultAllowed(c),
+			IncludeDown:  string(c.Query("all")) == "1",
+		})
+		c.JSON(200, cfg)
+	}
+}
+
+// requestBaseURL 根据请求推断本服务对外地址，优先使用反向代理传入的头
+func requestBaseURL(c *app.RequestContext) string {
+	scheme := string(c.GetHeader("X-Forwarded-Proto"))
+	if scheme == "" {
+		scheme = string(c.URI().Scheme())
+	}
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := string(c.GetHeader("X-Forwarded-Host"))
+	if host == "" {
+		host = string(c.Host())
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/follow"
+	"ReelNest/services/history"
+	"ReelNest/services/users"
+	"ReelNest/services/watchlist"
+)
+
+// maxPlayLineLength 默认播放线路名称的最大长度
+const maxPlayLineLength = 64
+
+// createUserRequest 创建用户请求体
+type createUserRequest struct {
+	Name     string                  `json:"name"`
+	Settings *models.ProfileSettings `json:"settings"`
+}
+
+// issueTokenRequest 签发令牌请求体，user 只在管理接口中使用
+type issueTokenRequest struct {
+	User string `json:"user"`
+	Name string `json:"name"`
+}
+
+// NewUsersHandler 创建用户列表处理器(管理接口)
+func NewUsersHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !requireAdmin(c) {
+			return
+		}
+		list, err := store.List()
+		if err != nil {
+			usersError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// NewCreateUserHandler 创建新建用户处理器(管理接口)，可同时指定个人设置
+func NewCreateUserHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !requireAdmin(c) {
+			return
+		}
+		var req createUserRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.Settings != nil && !validSettings(c, req.Settings) {
+			return
+		}
+
+		profile, err := store.Create(req.Name)
+		if err == nil && req.Settings != nil {
+			profile, err = store.UpdateSettings(profile.Name, *req.Settings)
+		}
+		if err != nil {
+			usersError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":    200,
+			"msg":     "ok",
+			"profile": profile,
+		})
+	}
+}
+
+// NewDeleteUserHandler 创建删除用户处理器(管理接口)，同时撤销其全部令牌
+// 观看记录、收藏夹与追剧数据按用户名保存，一并删除，避免之后同名的新用户读到
+func NewDeleteUserHandler(store *users.Store, watched *history.Store, lists *watchlist.Store, follows *follow.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !requireAdmin(c) {
+			return
+		}
+		name := string(c.Query("name"))
+		if name == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 name",
+			})
+			return
+		}
+		if err := store.Delete(name); err != nil {
+			usersError(c, err)
+			return
+		}
+		for _, purge := range []func(string) error{watched.Purge, lists.Purge, follows.Purge} {
+			if err := purge(name); err != nil {
+				c.JSON(500, models.APIResponse{
+					Code: 500,
+					Msg:  "删除用户数据失败: " + err.Error(),
+				})
+				return
+			}
+		}
+		c.JSON(200, models.APIResponse{Code: 200, Msg: "ok"})
+	}
+}
+
+// NewAdminIssueTokenHandler 创建为指定用户签发令牌的处理器(管理接口)
+func NewAdminIssueTokenHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !requireAdmin(c) {
+			return
+		}
+		var req issueTokenRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		issueToken(c, store, req.User, req.Name)
+	}
+}
+
+// NewProfileHandler 创建当前用户信息处理器
+func NewProfileHandler() func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		profile, ok := requireProfile(c)
+		if !ok {
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":    200,
+			"msg":     "ok",
+			"profile": profile,
+		})
+	}
+}
+
+// NewProfileSettingsHandler 创建更新个人设置处理器，请求体为完整的设置
+func NewProfileSettingsHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		profile, ok := requireProfile(c)
+		if !ok {
+			return
+		}
+		var settings models.ProfileSettings
+		if !bindJSON(c, &settings) || !validSettings(c, &settings) {
+			return
+		}
+
+		updated, err := store.UpdateSettings(profile.Name, settings)
+		if err != nil {
+			usersError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":    200,
+			"msg":     "ok",
+			"profile": updated,
+		})
+	}
+}
+
+// NewProfileTokensHandler 创建当前用户的令牌列表处理器
+func NewProfileTokensHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		profile, ok := requireProfile(c)
+		if !ok {
+			return
+		}
+		list, err := store.Tokens(profile.Name)
+		if err != nil {
+			usersError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(list),
+			List:  list,
+		})
+	}
+}
+
+// NewProfileIssueTokenHandler 创建为当前用户签发新令牌的处理器
+func NewProfileIssueTokenHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		profile, ok := requireProfile(c)
+		if !ok {
+			return
+		}
+		var req issueTokenRequest
+		if len(c.Request.Body()) > 0 && !bindJSON(c, &req) {
+			return
+		}
+		issueToken(c, store, profile.Name, req.Name)
+	}
+}
+
+// NewProfileRevokeTokenHandler 创建撤销当前用户令牌的处理器
+func NewProfileRevokeTokenHandler(store *users.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		profile, ok := requireProfile(c)
+		if !ok {
+			return
+		}
+		id := string(c.Query("id"))
+		if id == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 id",
+			})
+			return
+		}
+		if err := store.RevokeToken(profile.Name, id); err != nil {
+			usersError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{Code: 200, Msg: "ok"})
+	}
+}
+
+// issueToken 签发令牌并返回明文，明文只在此时返回一次
+func issueToken(c *app.RequestContext, store *users.Store, user, name string) {
+	if len(name) > maxNameLength {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "令牌名称过长",
+		})
+		return
+	}
+	token, err := store.IssueToken(user, name)
+	if err != nil {
+		usersError(c, err)
+		return
+	}
+	c.JSON(200, map[string]interface{}{
+		"code":  200,
+		"msg":   "ok",
+		"token": token,
+	})
+}
+
+// validSettings 校验个人设置：常用站点需为已配置的点播站点
+func validSettings(c *app.RequestContext, settings *models.ProfileSettings) bool {
+	seen := make(map[string]bool, len(settings.PreferredSources))
+	sources := settings.PreferredSources[:0]
+	for _, key := range settings.PreferredSources {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		if site, ok := config.GetSite(key); !ok || site.IsLive() {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "不支持的源: " + key,
+			})
+			return false
+		}
+		seen[key] = true
+		sources = append(sources, key)
+	}
+	settings.PreferredSources = sources
+
+	settings.DefaultPlayLine = strings.TrimSpace(settings.DefaultPlayLine)
+	if len(settings.DefaultPlayLine) > maxPlayLineLength {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "默认播放线路名称过长",
+		})
+		return false
+	}
+	return true
+}
+
+// usersError 按错误类型写入用户接口的错误响应
+func usersError(c *app.RequestContext, err error) {
+	code := 500
+	switch {
+	case errors.Is(err, users.ErrNotFound), errors.Is(err, users.ErrInvalidToken):
+		code = 404
+	case errors.Is(err, users.ErrInvalidName), errors.Is(err, users.ErrReservedName):
+		code = 400
+	case errors.Is(err, users.ErrExists), errors.Is(err, users.ErrTooManyTokens):
+		code = 409
+	}
+	c.JSON(code, models.APIResponse{
+		Code: code,
+		Msg:  err.Error(),
+	})
+}
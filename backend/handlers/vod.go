@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/services/maccms"
+	"ReelNest/services/vodsource"
+)
+
+// NewVodHandler 创建 MacCMS 兼容的虚拟源处理器
+// 支持 ac=list、ac=videolist、ac=detail、ac=search，参数 wd、ids、t、pg、h 与 MacCMS 一致
+func NewVodHandler(src *vodsource.Source) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		ac := string(c.Query("ac"))
+		wd := strings.TrimSpace(string(c.Query("wd")))
+		ids := strings.TrimSpace(string(c.Query("ids")))
+		page, _ := strconv.Atoi(string(c.Query("pg")))
+		hours, _ := strconv.Atoi(string(c.Query("h")))
+		adult := adultAllowed(c)
+
+		var resp *maccms.Response
+		switch {
+		case ids != "":
+			resp = src.Detail(ctx, strings.Split(ids, ","), adult)
+		case wd != "":
+			resp = src.Search(ctx, wd, page, adult)
+		case ac == "search":
+			c.JSON(400, maccms.Response{Code: 0, Msg: "缺少必要参数 wd"})
+			return
+		default:
+			resp = src.List(ctx, string(c.Query("t")), page, hours, adult, ac != "" && ac != "list")
+		}
+
+		// ac=list 只返回简要字段，并附带分类列表
+		if ac == "" || ac == "list" {
+			for i := range resp.List {
+				resp.List[i] = brief(resp.List[i])
+			}
+			resp.Class = src.Classes()
+		} else {
+			// 网盘与本地片库的地址为本服务的相对地址，外部客户端需要完整地址
+			base := requestBaseURL(c)
+			for i := range resp.List {
+				resp.List[i].VodPlayURL = absolutePlayURL(base, resp.List[i].VodPlayURL)
+				resp.List[i].VodPic = absoluteURL(base, resp.List[i].VodPic)
+			}
+		}
+		c.JSON(200, resp)
+	}
+}
+
+// brief 只保留 ac=list 需要的字段
+func brief(v maccms.Video) maccms.Video {
+	return maccms.Video{
+		VodID:       v.VodID,
+		VodName:     v.VodName,
+		VodRemarks:  v.VodRemarks,
+		VodTime:     v.VodTime,
+		VodPlayFrom: v.VodPlayFrom,
+		TypeID:      v.TypeID,
+		TypeName:    v.TypeName,
+	}
+}
@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+
+	"ReelNest/models"
+	"ReelNest/services/catalog"
+	"ReelNest/services/maccms"
+	"ReelNest/services/watchlist"
+)
+
+// maxWatchlistImportBytes 导入收藏夹请求体大小上限
+const maxWatchlistImportBytes = 4 << 20
+
+// watchlistRequest 创建或重命名收藏夹的请求体
+type watchlistRequest struct {
+	Name string `json:"name"`
+}
+
+// watchlistItemRequest 收藏视频的请求体，未提供 info 时从本地目录或上游获取详情快照
+type watchlistItemRequest struct {
+	List   string            `json:"list"`
+	Source string            `json:"source"`
+	ID     string            `json:"id"`
+	Info   *models.VideoInfo `json:"info"`
+}
+
+// watchlistOrderRequest 调整收藏夹顺序的请求体
+type watchlistOrderRequest struct {
+	List  string              `json:"list"`
+	Items []watchlist.ItemKey `json:"items"`
+}
+
+// watchlistExport 收藏夹导出文件格式
+type watchlistExport struct {
+	Version    int                `json:"version"`
+	ExportedAt int64              `json:"exported_at"`
+	Lists      []models.Watchlist `json:"lists"`
+}
+
+// NewWatchlistsHandler 创建收藏夹查询处理器：指定 id 时返回收藏夹及其中的视频，否则列出全部收藏夹
+func NewWatchlistsHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		if id := string(c.Query("id")); id != "" {
+			wl, err := store.Get(user, id)
+			if err != nil {
+				watchlistError(c, err)
+				return
+			}
+			c.JSON(200, map[string]interface{}{
+				"code":      200,
+				"msg":       "ok",
+				"watchlist": wl,
+			})
+			return
+		}
+
+		lists, err := store.Lists(user)
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{
+			Code:  200,
+			Msg:   "ok",
+			Total: len(lists),
+			List:  lists,
+		})
+	}
+}
+
+// NewWatchlistCreateHandler 创建新建收藏夹处理器
+func NewWatchlistCreateHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var req watchlistRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		wl, err := store.Create(user, req.Name)
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":      200,
+			"msg":       "ok",
+			"watchlist": wl,
+		})
+	}
+}
+
+// NewWatchlistRenameHandler 创建收藏夹重命名处理器
+func NewWatchlistRenameHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		id, ok := requireListID(c, string(c.Query("id")))
+		if !ok {
+			return
+		}
+		var req watchlistRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		wl, err := store.Rename(user, id, req.Name)
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		wl.Items = nil
+		c.JSON(200, map[string]interface{}{
+			"code":      200,
+			"msg":       "ok",
+			"watchlist": wl,
+		})
+	}
+}
+
+// NewWatchlistDeleteHandler 创建收藏夹删除处理器
+func NewWatchlistDeleteHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		id, ok := requireListID(c, string(c.Query("id")))
+		if !ok {
+			return
+		}
+		if err := store.Delete(user, id); err != nil {
+			watchlistError(c, err)
+			return
+		}
+		c.JSON(200, models.APIResponse{Code: 200, Msg: "ok"})
+	}
+}
+
+// NewWatchlistAddHandler 创建收藏视频处理器，list 为空时收藏到默认收藏夹
+func NewWatchlistAddHandler(store *watchlist.Store, mac *maccms.Client, catalogStore *catalog.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var req watchlistItemRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		if req.List == "" {
+			req.List = watchlist.DefaultID
+		}
+		if req.Source == "" || req.ID == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 source 或 id",
+			})
+			return
+		}
+
+		site, ok := requireSite(c, req.Source)
+		if !ok {
+			return
+		}
+		info := req.Info
+		if info == nil {
+			video, err := catalogStore.Get(req.Source, req.ID)
+			if err != nil {
+				video, err = mac.Detail(ctx, site, req.ID)
+			}
+			if err != nil {
+				code := 502
+				if errors.Is(err, maccms.ErrNotFound) {
+					code = 404
+				}
+				c.JSON(code, models.APIResponse{
+					Code: code,
+					Msg:  "获取详情失败: " + err.Error(),
+				})
+				return
+			}
+			snapshot := video.Info(req.Source, site)
+			info = &snapshot
+		}
+
+		wl, err := store.Add(user, req.List, models.WatchlistItem{
+			Source:  req.Source,
+			VideoID: req.ID,
+			Info:    *info,
+		})
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		wl.Items = nil
+		c.JSON(200, map[string]interface{}{
+			"code":      200,
+			"msg":       "ok",
+			"watchlist": wl,
+		})
+	}
+}
+
+// NewWatchlistRemoveHandler 创建取消收藏处理器，list 为空时从默认收藏夹移除
+func NewWatchlistRemoveHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		list := string(c.Query("list"))
+		if list == "" {
+			list = watchlist.DefaultID
+		}
+		key := watchlist.ItemKey{Source: string(c.Query("source")), VideoID: string(c.Query("id"))}
+		if key.Source == "" || key.VideoID == "" {
+			c.JSON(400, models.APIResponse{
+				Code: 400,
+				Msg:  "缺少必要参数 source 或 id",
+			})
+			return
+		}
+		wl, err := store.Remove(user, list, key)
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		wl.Items = nil
+		c.JSON(200, map[string]interface{}{
+			"code":      200,
+			"msg":       "ok",
+			"watchlist": wl,
+		})
+	}
+}
+
+// NewWatchlistOrderHandler 创建收藏夹排序处理器
+func NewWatchlistOrderHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		var req watchlistOrderRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		id, ok := requireListID(c, req.List)
+		if !ok {
+			return
+		}
+		wl, err := store.Reorder(user, id, req.Items)
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":      200,
+			"msg":       "ok",
+			"watchlist": wl,
+		})
+	}
+}
+
+// NewWatchlistExportHandler 创建收藏夹导出处理器，返回可再次导入的 JSON 文件
+func NewWatchlistExportHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		lists, err := store.Export(user)
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="watchlists.json"`)
+		c.JSON(200, watchlistExport{
+			Version:    1,
+			ExportedAt: time.Now().UnixMilli(),
+			Lists:      lists,
+		})
+	}
+}
+
+// NewWatchlistImportHandler 创建收藏夹导入处理器，mode=replace 时覆盖现有收藏夹，默认与同名收藏夹合并
+func NewWatchlistImportHandler(store *watchlist.Store) func(context.Context, *app.RequestContext) {
+	return func(ctx context.Context, c *app.RequestContext) {
+		user, ok := requestUser(c)
+		if !ok {
+			return
+		}
+		if len(c.Request.Body()) > maxWatchlistImportBytes {
+			c.JSON(413, models.APIResponse{
+				Code: 413,
+				Msg:  "导入文件过大",
+			})
+			return
+		}
+		var req watchlistExport
+		if !bindJSON(c, &req) {
+			return
+		}
+		lists, added, err := store.Import(user, req.Lists, string(c.Query("mode")) == "replace")
+		if err != nil {
+			watchlistError(c, err)
+			return
+		}
+		c.JSON(200, map[string]interface{}{
+			"code":  200,
+			"msg":   "ok",
+			"lists": lists,
+			"added": added,
+		})
+	}
+}
+
+// bindJSON 解析请求体，失败时写入错误响应
+func bindJSON(c *app.RequestContext, v interface{}) bool {
+	if err := json.Unmarshal(c.Request.Body(), v); err != nil {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "请求体格式错误: " + err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// requireListID 校验收藏夹 ID，失败时写入错误响应
+func requireListID(c *app.RequestContext, id string) (string, bool) {
+	if id == "" {
+		c.JSON(400, models.APIResponse{
+			Code: 400,
+			Msg:  "缺少必要参数 id",
+		})
+		return "", false
+	}
+	return id, true
+}
+
+// watchlistError 按错误类型写入收藏夹接口的错误响应
+func watchlistError(c *app.RequestContext, err error) {
+	code := 500
+	switch {
+	case errors.Is(err, watchlist.ErrNotFound), errors.Is(err, watchlist.ErrItemNotFound):
+		code = 404
+	case errors.Is(err, watchlist.ErrInvalidName):
+		code = 400
+	case errors.Is(err, watchlist.ErrTooMany):
+		code = 409
+	}
+	c.JSON(code, models.APIResponse{
+		Code: code,
+		Msg:  err.Error(),
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/config"
+	"ReelNest/services/importer"
+	"ReelNest/services/maccms"
+	"ReelNest/utils"
+)
+
+// runImport 执行 import 子命令：从文件、URL 或标准输入导入站点列表
+//
+//	reelnest import [-probe] [-dry-run] [-config path] <file|url|->
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	configPath := fs.String("config", "../config/api_sites.json", "站点配置文件")
+	probe := fs.Bool("probe", false, "探测站点可用性，不可用的站点以禁用状态导入")
+	dryRun := fs.Bool("dry-run", false, "只输出导入结果，不写入配置")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: import [-probe] [-dry-run] [-config path] <file|url|->")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("需要指定一个站点列表")
+	}
+
+	if err := config.Load(*configPath); err != nil {
+		return err
+	}
+
+	hc, err := client.NewClient(client.WithDialTimeout(5*time.Second), client.WithTLSConfig(nil))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	data, err := readSource(ctx, hc, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	report, err := importer.NewImporter(maccms.NewClient(hc)).Import(ctx, data, importer.Options{
+		Probe:  *probe,
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(report)
+}
+
+// readSource 读取站点列表：- 为标准输入，http(s) 开头为远程地址，否则为本地文件
+func readSource(ctx context.Context, hc *client.Client, source string) ([]byte, error) {
+	switch {
+	case source == "-":
+		return io.ReadAll(os.Stdin)
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		result, err := utils.Fetch(ctx, hc, source, "", 2<<20)
+		if err != nil {
+			return nil, err
+		}
+		if result.StatusCode != 200 {
+			return nil, fmt.Errorf("获取站点列表失败，状态码: %d", result.StatusCode)
+		}
+		return result.Body, nil
+	default:
+		return os.ReadFile(source)
+	}
+}
@@ -0,0 +1,19 @@
+// Package testutil 单元测试共用的辅助函数
+package testutil
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// OpenStore 在测试临时目录中打开数据库，测试结束时自动关闭
+func OpenStore[T io.Closer](t testing.TB, name string, open func(path string) (T, error)) T {
+	t.Helper()
+	s, err := open(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("打开 %s 失败: %v", name, err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
@@ -10,14 +10,28 @@ import (
 
 	"ReelNest/config"
 	"ReelNest/server"
+	"ReelNest/services/taxonomy"
 )
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			log.Fatalf("导入站点失败: %v", err)
+		}
+		return
+	}
+
 	// 加载配置
 	if err := config.Load("../config/api_sites.json"); err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 加载分类映射规则
+	if err := taxonomy.Load("../config/taxonomy.json"); err != nil {
+		log.Fatalf("加载分类映射失败: %v", err)
+	}
+
 	// 初始化并启动服务器
 	srv := server.New()
 
@@ -2,6 +2,7 @@ package models
 
 // VideoInfo 视频详情信息
 type VideoInfo struct {
+	ID         string   `json:"id,omitempty"`
 	Title      string   `json:"title"`
 	SubTitle   string   `json:"sub_title,omitempty"`
 	Desc       string   `json:"desc"`
@@ -17,12 +18,78 @@ type VideoInfo struct {
 	Remarks    string   `json:"remarks,omitempty"`
 	Duration   string   `json:"duration,omitempty"`
 	Score      string   `json:"score,omitempty"`
+	UpdatedAt  string   `json:"updated_at,omitempty"`
+	// CanonicalType 统一分类，如 series；CanonicalGenre 为子类，如 kr
+	CanonicalType  string `json:"canonical_type,omitempty"`
+	CanonicalGenre string `json:"canonical_genre,omitempty"`
 }
 
 // EpisodeInfo 剧集信息
 type EpisodeInfo struct {
-	Title string `json:"title"`
-	Url   string `json:"url"`
+	Title   string       `json:"title"`
+	Url     string       `json:"url"`
+	Quality *QualityInfo `json:"quality,omitempty"`
+}
+
+// StreamVariant HLS 码率档位
+type StreamVariant struct {
+	Resolution string  `json:"resolution,omitempty"`
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	Bandwidth  int     `json:"bandwidth,omitempty"`
+	Codecs     string  `json:"codecs,omitempty"`
+	FrameRate  float64 `json:"frame_rate,omitempty"`
+}
+
+// QualityInfo 播放流质量信息
+type QualityInfo struct {
+	Status         string          `json:"status"`
+	Label          string          `json:"label,omitempty"`
+	Resolution     string          `json:"resolution,omitempty"`
+	Width          int             `json:"width,omitempty"`
+	Height         int             `json:"height,omitempty"`
+	Bandwidth      int             `json:"bandwidth,omitempty"`
+	Codecs         string          `json:"codecs,omitempty"`
+	Variants       []StreamVariant `json:"variants,omitempty"`
+	Segments       int             `json:"segments"`
+	Duration       float64         `json:"duration"`
+	ThroughputKbps int             `json:"throughput_kbps,omitempty"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// EpisodeCheck 剧集链接检测结果
+type EpisodeCheck struct {
+	Title      string  `json:"title,omitempty"`
+	Url        string  `json:"url"`
+	Status     string  `json:"status"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Segments   int     `json:"segments"`
+	Duration   float64 `json:"duration"`
+	Error      string  `json:"error,omitempty"`
+	Cached     bool    `json:"cached"`
+	CheckedAt  int64   `json:"checked_at"`
+}
+
+// PlaybackAlternative 同一剧集在其它源的备选播放地址
+type PlaybackAlternative struct {
+	SourceCode   string        `json:"source_code"`
+	SourceName   string        `json:"source_name"`
+	VideoID      string        `json:"video_id"`
+	Title        string        `json:"title"`
+	Year         string        `json:"year,omitempty"`
+	EpisodeIndex int           `json:"episode_index"`
+	EpisodeTitle string        `json:"episode_title"`
+	Url          string        `json:"url"`
+	Score        float64       `json:"score"`
+	Check        *EpisodeCheck `json:"check,omitempty"`
+}
+
+// ResolvedStream 分享页解析得到的直链
+type ResolvedStream struct {
+	Url     string            `json:"url"`
+	Type    string            `json:"type"`
+	Headers map[string]string `json:"headers"`
+	Hops    []string          `json:"hops"`
 }
 
 // SpecialDetailResponse 特殊源详情响应
@@ -33,10 +100,181 @@ type SpecialDetailResponse struct {
 	VideoInfo VideoInfo     `json:"videoInfo"`
 }
 
+// SourceHit 作品在某个源上的条目
+type SourceHit struct {
+	SourceCode   string `json:"source_code"`
+	SourceName   string `json:"source_name"`
+	VideoID      string `json:"video_id"`
+	Title        string `json:"title"`
+	Remarks      string `json:"remarks,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+	EpisodeCount int    `json:"episode_count"`
+	HasM3U8      bool   `json:"has_m3u8"`
+}
+
+// Work 跨源去重后的作品
+type Work struct {
+	Key     string      `json:"key"`
+	Score   float64     `json:"score"`
+	Info    VideoInfo   `json:"info"`
+	Sources []SourceHit `json:"sources"`
+}
+
+// SiteHealth 站点健康状态
+type SiteHealth struct {
+	Status              string  `json:"status"`
+	Score               float64 `json:"score"`
+	SuccessRate         float64 `json:"success_rate"`
+	AvgLatencyMs        int     `json:"avg_latency_ms"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	LastError           string  `json:"last_error,omitempty"`
+	LastCheck           int64   `json:"last_check,omitempty"`
+}
+
+// Category 站点分类
+type Category struct {
+	ID             string     `json:"id"`
+	ParentID       string     `json:"parent_id,omitempty"`
+	Name           string     `json:"name"`
+	CanonicalType  string     `json:"canonical_type,omitempty"`
+	CanonicalGenre string     `json:"canonical_genre,omitempty"`
+	Children       []Category `json:"children,omitempty"`
+}
+
 // APIResponse API通用响应
 type APIResponse struct {
-	Code  int         `json:"code"`
-	Msg   string      `json:"msg"`
-	Total int         `json:"total,omitempty"`
-	List  interface{} `json:"list,omitempty"`
+	Code      int         `json:"code"`
+	Msg       string      `json:"msg"`
+	Page      int         `json:"page,omitempty"`
+	PageCount int         `json:"pagecount,omitempty"`
+	Total     int         `json:"total,omitempty"`
+	List      interface{} `json:"list,omitempty"`
+}
+
+// LiveChannel 直播频道，同名频道的多个播放地址合并到 URLs
+type LiveChannel struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Group      string   `json:"group"`
+	Logo       string   `json:"logo,omitempty"`
+	TvgID      string   `json:"tvg_id,omitempty"`
+	TvgName    string   `json:"tvg_name,omitempty"`
+	URLs       []string `json:"urls"`
+	SourceCode string   `json:"source_code"`
+	SourceName string   `json:"source_name"`
+}
+
+// LiveGroup 直播频道分组
+type LiveGroup struct {
+	Name     string        `json:"name"`
+	Channels []LiveChannel `json:"channels"`
+}
+
+// Programme 电子节目单中的一档节目，时间为 Unix 秒
+type Programme struct {
+	Title    string `json:"title"`
+	SubTitle string `json:"sub_title,omitempty"`
+	Desc     string `json:"desc,omitempty"`
+	Category string `json:"category,omitempty"`
+	Start    int64  `json:"start"`
+	Stop     int64  `json:"stop"`
+}
+
+// ChannelGuide 频道的正在播出与下一档节目
+type ChannelGuide struct {
+	ChannelID string     `json:"channel_id"`
+	Name      string     `json:"name"`
+	Now       *Programme `json:"now,omitempty"`
+	Next      *Programme `json:"next,omitempty"`
+}
+
+// WatchProgress 观看记录与播放进度，时间为 Unix 毫秒，位置与时长为秒
+type WatchProgress struct {
+	Source       string  `json:"source"`
+	VideoID      string  `json:"id"`
+	Title        string  `json:"title,omitempty"`
+	CoverUrl     string  `json:"cover_url,omitempty"`
+	Episode      int     `json:"episode"` // 剧集序号，从 0 开始
+	EpisodeTitle string  `json:"episode_title,omitempty"`
+	EpisodeCount int     `json:"episode_count,omitempty"`
+	Position     float64 `json:"position"`
+	Duration     float64 `json:"duration"`
+	Finished     bool    `json:"finished"`
+	Device       string  `json:"device,omitempty"`
+	UpdatedAt    int64   `json:"updated_at"`
+	// Seq 服务端写入时分配的变更序号，设备间同步以此判断变化，不依赖设备时钟
+	Seq uint64 `json:"seq,omitempty"`
+	// Deleted 已删除的记录保留一段时间，用于同步到其它设备
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Watchlist 收藏夹(片单)，时间为 Unix 毫秒
+type Watchlist struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Count     int             `json:"count"`
+	Items     []WatchlistItem `json:"items,omitempty"`
+	CreatedAt int64           `json:"created_at"`
+	UpdatedAt int64           `json:"updated_at"`
+}
+
+// WatchlistItem 收藏夹中的视频，Info 为收藏时的详情快照
+type WatchlistItem struct {
+	Source  string    `json:"source"`
+	VideoID string    `json:"id"`
+	Info    VideoInfo `json:"info"`
+	AddedAt int64     `json:"added_at"`
+}
+
+// FollowedSeries 追剧：关注的剧集与已知的集数，时间为 Unix 毫秒
+type FollowedSeries struct {
+	Source       string `json:"source"`
+	VideoID      string `json:"id"`
+	Title        string `json:"title"`
+	CoverUrl     string `json:"cover_url,omitempty"`
+	EpisodeCount int    `json:"episode_count"`
+	LastEpisode  string `json:"last_episode,omitempty"`
+	Remarks      string `json:"remarks,omitempty"`
+	FollowedAt   int64  `json:"followed_at"`
+	CheckedAt    int64  `json:"checked_at,omitempty"`
+	UpdatedAt    int64  `json:"updated_at,omitempty"` // 最近一次发现新剧集的时间
+	LastError    string `json:"last_error,omitempty"`
+}
+
+// EpisodeUpdate 追剧更新：一次检查中发现的新剧集
+type EpisodeUpdate struct {
+	ID         uint64   `json:"id"`
+	Source     string   `json:"source"`
+	VideoID    string   `json:"video_id"`
+	Title      string   `json:"title"`
+	CoverUrl   string   `json:"cover_url,omitempty"`
+	Episodes   []string `json:"episodes"` // 新剧集标题
+	From       int      `json:"from"`     // 之前的集数
+	To         int      `json:"to"`       // 现在的集数
+	DetectedAt int64    `json:"detected_at"`
+	Read       bool     `json:"read"`
+}
+
+// ProfileSettings 个人设置
+type ProfileSettings struct {
+	Adult            bool     `json:"adult"`                       // 是否显示成人内容
+	PreferredSources []string `json:"preferred_sources,omitempty"` // 默认只查询这些站点，为空时查询全部站点
+	DefaultPlayLine  string   `json:"default_play_line,omitempty"` // 详情中优先选择的播放线路
+}
+
+// UserProfile 用户与个人设置，时间为 Unix 毫秒
+type UserProfile struct {
+	Name      string          `json:"name"`
+	CreatedAt int64           `json:"created_at"`
+	Settings  ProfileSettings `json:"settings"`
+}
+
+// APIToken 接口令牌，Token 只在创建时返回，服务端只保存其摘要
+type APIToken struct {
+	ID         string `json:"id"`
+	User       string `json:"user"`
+	Name       string `json:"name,omitempty"`
+	Token      string `json:"token,omitempty"`
+	CreatedAt  int64  `json:"created_at"`
+	LastUsedAt int64  `json:"last_used_at,omitempty"`
 }
@@ -3,6 +3,10 @@ package server
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -13,12 +17,62 @@ import (
 
 	"ReelNest/config"
 	"ReelNest/handlers"
+	"ReelNest/services/browse"
+	"ReelNest/services/catalog"
+	"ReelNest/services/epg"
+	"ReelNest/services/expand"
+	"ReelNest/services/failover"
+	"ReelNest/services/follow"
+	"ReelNest/services/health"
+	"ReelNest/services/history"
+	"ReelNest/services/hls"
+	"ReelNest/services/imageproxy"
+	"ReelNest/services/importer"
+	"ReelNest/services/library"
+	"ReelNest/services/linkcheck"
+	"ReelNest/services/live"
+	"ReelNest/services/maccms"
+	"ReelNest/services/resolver"
+	"ReelNest/services/search"
+	"ReelNest/services/suggest"
+	"ReelNest/services/users"
+	"ReelNest/services/vodsource"
+	"ReelNest/services/watchlist"
+	"ReelNest/services/webdav"
 )
 
 // Server 应用服务器
 type Server struct {
-	h      *server.Hertz
-	client *client.Client
+	h        *server.Hertz
+	client   *client.Client
+	images   *imageproxy.Cache
+	mac      *maccms.Client
+	checker  *linkcheck.Checker
+	prober   *hls.Prober
+	search   *search.Aggregator
+	finder   *failover.Finder
+	resolver *resolver.Resolver
+	browser  *browse.Browser
+	tracker  *health.Tracker
+	expander *expand.Expander
+	suggest  *suggest.Index
+	vod      *vodsource.Source
+	store    *catalog.Store
+	crawler  *catalog.Crawler
+	live     *live.Manager
+	epg      *epg.Service
+	drive    *webdav.Drive
+	library  *library.Library
+	history  *history.Store
+	lists    *watchlist.Store
+	follows  *follow.Store
+	watcher  *follow.Watcher
+	users    *users.Store
+
+	// 后台任务(目录采集、节目单刷新、索引保存)的生命周期
+	ctx    context.Context
+	cancel context.CancelFunc
+	tasks  sync.WaitGroup
 }
 
 // New 创建新的服务器实例
@@ -37,6 +91,59 @@ func New() *Server {
 		panic(fmt.Sprintf("创建HTTP客户端失败: %v", err))
 	}
 
+	// 创建封面图片缓存
+	images, err := imageproxy.NewCache(filepath.Join(cfg.DataDir, "images"), 7*24*time.Hour)
+	if err != nil {
+		panic(fmt.Sprintf("创建图片缓存失败: %v", err))
+	}
+
+	// 加载搜索词扩展用的标题索引
+	index, err := expand.NewIndex(filepath.Join(cfg.DataDir, "titles.json"))
+	if err != nil {
+		panic(fmt.Sprintf("加载标题索引失败: %v", err))
+	}
+
+	// 打开本地视频目录
+	store, err := catalog.Open(filepath.Join(cfg.DataDir, "catalog.db"))
+	if err != nil {
+		panic(fmt.Sprintf("打开本地目录失败: %v", err))
+	}
+
+	// 加载搜索联想索引，首次启动时以本地目录中的标题作为候选词
+	sug, err := suggest.NewIndex(filepath.Join(cfg.DataDir, "suggest.json"))
+	if err != nil {
+		panic(fmt.Sprintf("加载搜索联想索引失败: %v", err))
+	}
+	if sug.Len() == 0 {
+		if err := store.ForEachTitle(func(title string) { sug.Seed(title) }); err != nil {
+			log.Printf("从本地目录加载搜索联想候选词失败: %v", err)
+		}
+	}
+
+	// 打开观看记录
+	watched, err := history.Open(filepath.Join(cfg.DataDir, "history.db"))
+	if err != nil {
+		panic(fmt.Sprintf("打开观看记录失败: %v", err))
+	}
+
+	// 打开收藏夹
+	favorites, err := watchlist.Open(filepath.Join(cfg.DataDir, "watchlists.db"))
+	if err != nil {
+		panic(fmt.Sprintf("打开收藏夹失败: %v", err))
+	}
+
+	// 打开追剧记录
+	follows, err := follow.Open(filepath.Join(cfg.DataDir, "follows.db"))
+	if err != nil {
+		panic(fmt.Sprintf("打开追剧记录失败: %v", err))
+	}
+
+	// 打开用户与接口令牌
+	accounts, err := users.Open(filepath.Join(cfg.DataDir, "users.db"))
+	if err != nil {
+		panic(fmt.Sprintf("打开用户数据失败: %v", err))
+	}
+
 	// 创建服务器
 	h := server.New(server.WithHostPorts(fmt.Sprintf(":%d", cfg.Port)))
 
@@ -44,10 +151,77 @@ func New() *Server {
 	h.Use(recovery.Recovery()) // 异常恢复
 	h.Use(cors.Default())      // CORS支持
 
+	// 接口令牌认证 - 识别请求对应的用户
+	h.Use(handlers.NewAuthMiddleware(accounts, os.Getenv(handlers.AnonymousEnv) == "1"))
+
+	// 创建业务组件
+	mac := maccms.NewClient(hzClient)
+	drive := webdav.NewDrive(hzClient, 10*time.Minute)
+	mac.Register(config.SiteTypeWebDAV, drive)
+	lib := library.NewLibrary(10 * time.Minute)
+	mac.Register(config.SiteTypeLocal, lib)
+	watcher := follow.NewWatcher(follows, mac, hzClient, follow.Options{
+		Interval: time.Hour,
+		Webhook:  os.Getenv(follow.WebhookEnv),
+	})
+	checker := linkcheck.NewChecker(hzClient, 8, 10*time.Minute)
+	tracker := health.NewTracker()
+	agg := search.NewAggregator(mac, tracker, store, 5*time.Minute)
+	expander := expand.NewExpander(index, 4)
+	crawler := catalog.NewCrawler(mac, store, catalog.Options{
+		Interval:   30 * time.Minute,
+		PageDelay:  2 * time.Second,
+		Workers:    2,
+		MaxRetries: 3,
+		Disabled:   os.Getenv(catalog.DisableEnv) == "1",
+		OnPage: func(siteKey string, videos []maccms.Video) {
+			titles := make([]string, 0, len(videos))
+			for _, v := range videos {
+				titles = append(titles, v.VodName)
+			}
+			index.Add(titles...)
+			sug.Seed(titles...)
+		},
+	})
+	browser := browse.NewBrowser(mac, store)
+	lm := live.NewManager(hzClient, 10*time.Minute)
+	guide := epg.NewService(hzClient, lm.EPGSources, epg.Options{
+		Interval:      6 * time.Hour,
+		KeepPast:      6 * time.Hour,
+		KeepFuture:    72 * time.Hour,
+		MaxProgrammes: 500000,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// 创建实例
 	srv := &Server{
-		h:      h,
-		client: hzClient,
+		h:        h,
+		client:   hzClient,
+		images:   images,
+		mac:      mac,
+		checker:  checker,
+		prober:   hls.NewProber(hzClient, 4, 30*time.Minute),
+		search:   agg,
+		finder:   failover.NewFinder(agg, mac, checker),
+		resolver: resolver.NewResolver(hzClient, 10*time.Minute),
+		browser:  browser,
+		vod:      vodsource.NewSource(agg, browser, mac, store),
+		tracker:  tracker,
+		expander: expander,
+		suggest:  sug,
+		store:    store,
+		crawler:  crawler,
+		live:     lm,
+		epg:      guide,
+		drive:    drive,
+		library:  lib,
+		history:  watched,
+		lists:    favorites,
+		follows:  follows,
+		watcher:  watcher,
+		users:    accounts,
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 
 	// 设置路由
@@ -58,12 +232,73 @@ func New() *Server {
 
 // Run 启动服务器
 func (s *Server) Run() error {
+	s.tasks.Add(4)
+	go func() {
+		defer s.tasks.Done()
+		s.saveIndexLoop()
+	}()
+	go func() {
+		defer s.tasks.Done()
+		s.crawler.Run(s.ctx)
+	}()
+	go func() {
+		defer s.tasks.Done()
+		s.epg.Run(s.ctx)
+	}()
+	go func() {
+		defer s.tasks.Done()
+		s.watcher.Run(s.ctx)
+	}()
 	return s.h.Run()
 }
 
-// Shutdown 优雅关闭服务器
+// Shutdown 优雅关闭服务器，等待后台任务退出后再关闭本地目录
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.h.Shutdown(ctx)
+	err := s.h.Shutdown(ctx)
+
+	s.cancel()
+	s.tasks.Wait()
+	if err := s.expander.Index().Save(); err != nil {
+		log.Printf("保存标题索引失败: %v", err)
+	}
+	if err := s.suggest.Save(); err != nil {
+		log.Printf("保存搜索联想索引失败: %v", err)
+	}
+	if err := s.store.Close(); err != nil {
+		log.Printf("关闭本地目录失败: %v", err)
+	}
+	if err := s.history.Close(); err != nil {
+		log.Printf("关闭观看记录失败: %v", err)
+	}
+	if err := s.lists.Close(); err != nil {
+		log.Printf("关闭收藏夹失败: %v", err)
+	}
+	if err := s.follows.Close(); err != nil {
+		log.Printf("关闭追剧记录失败: %v", err)
+	}
+	if err := s.users.Close(); err != nil {
+		log.Printf("关闭用户数据失败: %v", err)
+	}
+	return err
+}
+
+// saveIndexLoop 定期保存标题索引与搜索联想索引
+func (s *Server) saveIndexLoop() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.expander.Index().Save(); err != nil {
+				log.Printf("保存标题索引失败: %v", err)
+			}
+			if err := s.suggest.Save(); err != nil {
+				log.Printf("保存搜索联想索引失败: %v", err)
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
 }
 
 // setupRoutes 设置路由
@@ -86,10 +321,13 @@ func (s *Server) setupRoutes() {
 		result := make([]map[string]interface{}, 0)
 		for id, site := range config.GetAllSites() {
 			result = append(result, map[string]interface{}{
-				"id":     id,
-				"name":   site.Name,
-				"detail": site.Detail,
-				"adult":  site.Adult,
+				"id":       id,
+				"name":     site.Name,
+				"type":     site.Type,
+				"detail":   site.Detail,
+				"adult":    site.Adult,
+				"disabled": site.Disabled,
+				"health":   s.tracker.Get(id),
 			})
 		}
 		c.JSON(200, result)
@@ -98,6 +336,124 @@ func (s *Server) setupRoutes() {
 	// 特殊处理接口 - 处理特定的API请求
 	s.h.GET("/api/special-detail", handlers.NewSpecialHandler(s.client))
 
+	// 标准化详情接口 - probe=1 时附带各集清晰度与测速信息
+	s.h.GET("/api/detail", handlers.NewDetailHandler(s.mac, s.store, s.prober, s.suggest))
+
+	// 聚合搜索接口 - type 参数按统一分类筛选
+	s.h.GET("/api/search", handlers.NewSearchHandler(s.search, s.expander, s.suggest))
+
+	// 搜索联想接口 - prefix 支持中文与拼音首字母
+	s.h.GET("/api/suggest", handlers.NewSuggestHandler(s.suggest))
+
+	// 统一分类体系接口
+	s.h.GET("/api/taxonomy", handlers.NewTaxonomyHandler())
+
+	// 分类浏览接口 - 站点分类树、按分类分页、跨站最新更新
+	s.h.GET("/api/categories", handlers.NewCategoriesHandler(s.browser))
+	s.h.GET("/api/browse", handlers.NewBrowseHandler(s.browser, s.expander.Index()))
+	s.h.GET("/api/latest", handlers.NewLatestHandler(s.browser, s.expander.Index()))
+
+	// MacCMS 兼容虚拟源接口 - 供 TVBox 等客户端将本服务作为单一站点使用
+	vod := handlers.NewVodHandler(s.vod)
+	s.h.GET("/api.php/provide/vod/", vod)
+	s.h.GET("/api.php/provide/vod", vod)
+
+	// TVBox 配置导出接口 - 站点列表与本服务保持一致
+	s.h.GET("/api/tvbox", handlers.NewTVBoxHandler(s.tracker))
+
+	// 管理接口 - 导入 LibreTV/TVBox 站点列表
+	s.h.POST("/api/admin/import", handlers.NewImportHandler(s.client, importer.NewImporter(s.mac)))
+
+	// 本地目录同步状态接口
+	s.h.GET("/api/catalog/status", func(ctx context.Context, c *app.RequestContext) {
+		c.JSON(200, s.crawler.Status())
+	})
+
+	// 直播接口 - 频道列表、搜索、可用性检测与 M3U 输出
+	s.h.GET("/api/live/channels", handlers.NewLiveChannelsHandler(s.live))
+	s.h.GET("/api/live/search", handlers.NewLiveSearchHandler(s.live))
+	s.h.GET("/api/live/check", handlers.NewLiveCheckHandler(s.live, s.checker))
+	s.h.GET("/api/live/m3u", handlers.NewLiveM3UHandler(s.live))
+
+	// 电子节目单接口 - 正在播出/下一档、按日节目表与加载状态
+	s.h.GET("/api/live/epg", handlers.NewLiveEPGHandler(s.live, s.epg))
+	s.h.GET("/api/live/epg/schedule", handlers.NewLiveScheduleHandler(s.live, s.epg))
+	s.h.GET("/api/live/epg/status", func(ctx context.Context, c *app.RequestContext) {
+		c.JSON(200, s.epg.Status())
+	})
+
+	// 网盘视频播放接口 - 携带服务端保存的凭据转发 WebDAV 文件
+	s.h.GET(webdav.StreamPath, handlers.NewDriveStreamHandler(s.drive))
+	s.h.HEAD(webdav.StreamPath, handlers.NewDriveStreamHandler(s.drive))
+
+	// 本地片库文件接口 - 播放本地视频与读取海报，支持 Range 请求
+	libraryFile := handlers.NewLibraryFileHandler(s.library)
+	s.h.GET(library.FilePath, libraryFile)
+	s.h.HEAD(library.FilePath, libraryFile)
+
+	// 观看记录接口 - 播放进度上报、继续观看与多设备同步
+	s.h.GET("/api/history", handlers.NewHistoryListHandler(s.history))
+	s.h.POST("/api/history", handlers.NewHistoryRecordHandler(s.history))
+	s.h.DELETE("/api/history", handlers.NewHistoryDeleteHandler(s.history))
+	s.h.GET("/api/history/progress", handlers.NewHistoryProgressHandler(s.history))
+	s.h.GET("/api/history/continue", handlers.NewContinueWatchingHandler(s.history))
+	s.h.POST("/api/history/sync", handlers.NewHistorySyncHandler(s.history))
+
+	// 收藏夹接口 - 片单管理、收藏与排序、导入导出
+	s.h.GET("/api/watchlists", handlers.NewWatchlistsHandler(s.lists))
+	s.h.POST("/api/watchlists", handlers.NewWatchlistCreateHandler(s.lists))
+	s.h.PUT("/api/watchlists", handlers.NewWatchlistRenameHandler(s.lists))
+	s.h.DELETE("/api/watchlists", handlers.NewWatchlistDeleteHandler(s.lists))
+	s.h.POST("/api/watchlists/items", handlers.NewWatchlistAddHandler(s.lists, s.mac, s.store))
+	s.h.DELETE("/api/watchlists/items", handlers.NewWatchlistRemoveHandler(s.lists))
+	s.h.POST("/api/watchlists/order", handlers.NewWatchlistOrderHandler(s.lists))
+	s.h.GET("/api/watchlists/export", handlers.NewWatchlistExportHandler(s.lists))
+	s.h.POST("/api/watchlists/import", handlers.NewWatchlistImportHandler(s.lists))
+
+	// 追剧接口 - 关注剧集、新剧集更新列表与已读标记
+	s.h.GET("/api/follows", handlers.NewFollowsHandler(s.follows))
+	s.h.POST("/api/follows", handlers.NewFollowHandler(s.follows, s.watcher))
+	s.h.DELETE("/api/follows", handlers.NewUnfollowHandler(s.follows))
+	s.h.GET("/api/follows/updates", handlers.NewFollowUpdatesHandler(s.follows))
+	s.h.POST("/api/follows/updates/read", handlers.NewFollowMarkReadHandler(s.follows))
+	s.h.GET("/api/follows/calendar/token", handlers.NewFollowCalendarTokenHandler(s.follows))
+	s.h.POST("/api/follows/calendar/token", handlers.NewFollowCalendarTokenHandler(s.follows))
+	s.h.POST("/api/admin/follows/check", handlers.NewFollowCheckHandler(s.watcher))
+
+	// 用户管理接口 - 创建、删除用户与签发接口令牌(管理令牌)
+	s.h.GET("/api/admin/users", handlers.NewUsersHandler(s.users))
+	s.h.POST("/api/admin/users", handlers.NewCreateUserHandler(s.users))
+	s.h.DELETE("/api/admin/users", handlers.NewDeleteUserHandler(s.users, s.history, s.lists, s.follows))
+	s.h.POST("/api/admin/users/tokens", handlers.NewAdminIssueTokenHandler(s.users))
+
+	// 个人接口 - 当前用户信息、个人设置与接口令牌(接口令牌)
+	s.h.GET("/api/profile", handlers.NewProfileHandler())
+	s.h.PUT("/api/profile/settings", handlers.NewProfileSettingsHandler(s.users))
+	s.h.GET("/api/profile/tokens", handlers.NewProfileTokensHandler(s.users))
+	s.h.POST("/api/profile/tokens", handlers.NewProfileIssueTokenHandler(s.users))
+	s.h.DELETE("/api/profile/tokens", handlers.NewProfileRevokeTokenHandler(s.users))
+
+	// 追剧日历订阅接口 - iCalendar 格式，以订阅令牌识别用户
+	s.h.GET("/api/follows/calendar.ics", handlers.NewFollowCalendarHandler(s.follows))
+
+	// 订阅接口 - 站点、统一分类与搜索的最新更新，RSS 2.0 或 Atom
+	s.h.GET("/api/feeds/site", handlers.NewSiteFeedHandler(s.browser))
+	s.h.GET("/api/feeds/category", handlers.NewCategoryFeedHandler(s.browser))
+	s.h.GET("/api/feeds/search", handlers.NewSearchFeedHandler(s.browser, s.expander))
+
+	// 跨源备选播放地址接口 - 供播放器失败时自动切换
+	s.h.GET("/api/failover", handlers.NewFailoverHandler(s.finder))
+
+	// 播放页直链解析接口 - 将分享页地址解析为 m3u8/mp4
+	s.h.GET("/api/resolve", handlers.NewResolveHandler(s.resolver))
+
+	// 封面图片代理接口 - 处理防盗链、缩放与缓存
+	s.h.GET("/api/image", handlers.NewImageHandler(s.client, s.images))
+
+	// 剧集链接检测接口 - 支持按详情检测或提交链接列表
+	s.h.GET("/api/episodes/check", handlers.NewEpisodeCheckHandler(s.mac, s.checker))
+	s.h.POST("/api/episodes/check", handlers.NewEpisodeCheckHandler(s.mac, s.checker))
+
 	// 主代理接口 - 支持所有HTTP方法
 	s.h.Any("/api/proxy", handlers.NewProxyHandler(s.client))
 }
@@ -0,0 +1,281 @@
+package browse
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/catalog"
+	"ReelNest/services/maccms"
+	"ReelNest/services/taxonomy"
+	"ReelNest/utils"
+)
+
+const (
+	// siteTimeout 聚合最新更新时单个站点的超时
+	siteTimeout = 8 * time.Second
+	// categoryTTL 分类列表缓存时间，分类很少变化
+	categoryTTL = time.Hour
+	// maxMergedClasses 按统一分类浏览时最多合并的站点分类数
+	maxMergedClasses = 8
+)
+
+// Page 分页结果
+type Page struct {
+	Page      int
+	PageCount int
+	Total     int
+	List      []models.VideoInfo
+}
+
+// Browser 分类浏览与最新更新
+type Browser struct {
+	mac     *maccms.Client
+	store   *catalog.Store
+	classes *utils.TTLCache[[]maccms.Class]
+}
+
+// NewBrowser 创建浏览服务，store 非空时已完成同步的站点直接查询本地目录
+func NewBrowser(mac *maccms.Client, store *catalog.Store) *Browser {
+	return &Browser{
+		mac:     mac,
+		store:   store,
+		classes: utils.NewTTLCache[[]maccms.Class](categoryTTL, 1000),
+	}
+}
+
+// Classes 获取站点原始分类列表，带缓存
+func (b *Browser) Classes(ctx context.Context, key string, site config.Site) ([]maccms.Class, error) {
+	if classes, ok := b.classes.Get(key); ok {
+		return classes, nil
+	}
+	classes, err := b.mac.Categories(ctx, site)
+	if err != nil {
+		return nil, err
+	}
+	b.classes.Set(key, classes)
+	return classes, nil
+}
+
+// Categories 获取站点分类树
+func (b *Browser) Categories(ctx context.Context, key string, site config.Site) ([]models.Category, error) {
+	classes, err := b.Classes(ctx, key, site)
+	if err != nil {
+		return nil, err
+	}
+	return maccms.CategoryTree(key, classes), nil
+}
+
+// Videos 按分类分页获取站点视频，canonical 为统一分类筛选
+// 只给出统一分类时，查询站点中所有映射到该分类的叶子分类并合并
+func (b *Browser) Videos(ctx context.Context, key string, site config.Site, typeID, canonical string, page int) (*Page, error) {
+	if typeID == "" && canonical != "" {
+		return b.videosByCanonical(ctx, key, site, canonical, page)
+	}
+
+	var typeIDs []string
+	if typeID != "" {
+		typeIDs = []string{typeID}
+	}
+	q := catalog.Query{TypeIDs: typeIDs, Page: page, Filter: canonicalFilter(key, site, canonical)}
+	if result, ok := b.listLocal(key, site, q); ok {
+		return result, nil
+	}
+
+	resp, err := b.mac.List(ctx, site, maccms.ListQuery{TypeID: typeID, Page: page})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Page{
+		Page:      max(int(resp.Page), page, 1),
+		PageCount: int(resp.PageCount),
+		Total:     int(resp.Total),
+		List:      make([]models.VideoInfo, 0, len(resp.List)),
+	}
+	for i := range resp.List {
+		info := resp.List[i].Info(key, site)
+		if canonical != "" && !matchesCanonical(info, canonical) {
+			continue
+		}
+		result.List = append(result.List, info)
+	}
+	// 上游按原分类分页，筛选后无法得知总数，只保留上游页数用于翻页
+	if len(result.List) < len(resp.List) {
+		result.Total = 0
+	}
+	return result, nil
+}
+
+// videosByCanonical 合并站点中属于指定统一分类的各叶子分类
+func (b *Browser) videosByCanonical(ctx context.Context, key string, site config.Site, canonical string, page int) (*Page, error) {
+	classes, err := b.Classes(ctx, key, site)
+	if err != nil {
+		return nil, err
+	}
+
+	typeIDs := matchingLeafClasses(key, classes, canonical)
+	// 本地目录可一次查询全部分类，无需合并
+	if len(typeIDs) > 0 {
+		if result, ok := b.listLocal(key, site, catalog.Query{TypeIDs: typeIDs, Page: page}); ok {
+			return result, nil
+		}
+	}
+	if len(typeIDs) > maxMergedClasses {
+		typeIDs = typeIDs[:maxMergedClasses]
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		merged = &Page{Page: max(page, 1), List: make([]models.VideoInfo, 0)}
+	)
+	for _, typeID := range typeIDs {
+		wg.Add(1)
+		go func(typeID string) {
+			defer wg.Done()
+			// 分类本身已匹配，不再按视频自身的分类名二次过滤
+			result, err := b.Videos(ctx, key, site, typeID, "", page)
+			if err != nil {
+				log.Printf("获取站点 %s 分类 %s 失败: %v", key, typeID, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			merged.Total += result.Total
+			merged.PageCount = max(merged.PageCount, result.PageCount)
+			merged.List = append(merged.List, result.List...)
+		}(typeID)
+	}
+	wg.Wait()
+
+	sortByUpdated(merged.List)
+	return merged, nil
+}
+
+// listLocal 从本地目录分页查询，站点未完成同步或查询失败时返回 false
+func (b *Browser) listLocal(key string, site config.Site, q catalog.Query) (*Page, bool) {
+	if b.store == nil || !catalog.Crawlable(site) || !b.store.Synced(key) {
+		return nil, false
+	}
+	q.Size = catalog.DefaultPageSize
+	videos, total, err := b.store.List(key, q)
+	if err != nil {
+		log.Printf("查询站点 %s 本地目录失败: %v", key, err)
+		return nil, false
+	}
+
+	result := &Page{
+		Page:      max(q.Page, 1),
+		PageCount: (total + q.Size - 1) / q.Size,
+		Total:     total,
+		List:      make([]models.VideoInfo, 0, len(videos)),
+	}
+	for i := range videos {
+		result.List = append(result.List, videos[i].Info(key, site))
+	}
+	return result, true
+}
+
+// matchingLeafClasses 返回映射到指定统一分类的叶子分类，按站点原顺序
+func matchingLeafClasses(key string, classes []maccms.Class, canonical string) []string {
+	hasChildren := make(map[string]bool)
+	for _, cls := range classes {
+		hasChildren[string(cls.TypePID)] = true
+	}
+
+	results := maccms.ClassifyClasses(key, classes)
+	var typeIDs []string
+	for _, cls := range classes {
+		id := string(cls.TypeID)
+		if hasChildren[id] || !results[id].Matches(canonical) {
+			continue
+		}
+		typeIDs = append(typeIDs, id)
+	}
+	return typeIDs
+}
+
+// matchesCanonical 判断视频是否属于统一分类
+func matchesCanonical(info models.VideoInfo, canonical string) bool {
+	return taxonomy.Result{Type: info.CanonicalType, Genre: info.CanonicalGenre}.Matches(canonical)
+}
+
+// canonicalFilter 本地目录按统一分类筛选的条件，canonical 为空时不筛选
+func canonicalFilter(key string, site config.Site, canonical string) func(maccms.Video) bool {
+	if canonical == "" {
+		return nil
+	}
+	return func(v maccms.Video) bool {
+		return matchesCanonical(v.Info(key, site), canonical)
+	}
+}
+
+// sortByUpdated 按更新时间倒序排序
+func sortByUpdated(list []models.VideoInfo) {
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.UpdatedAt != b.UpdatedAt {
+			return a.UpdatedAt > b.UpdatedAt
+		}
+		return a.SourceCode < b.SourceCode
+	})
+}
+
+// Latest 合并多个站点最近 hours 小时内的更新，按更新时间倒序
+// 第 page 页由各站点的第 page 页合并而成
+// canonical 非空时只保留属于该统一分类的视频
+func (b *Browser) Latest(ctx context.Context, sites map[string]config.Site, hours, page int, canonical string) *Page {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		merged = &Page{Page: max(page, 1), List: make([]models.VideoInfo, 0)}
+	)
+
+	for key, site := range sites {
+		wg.Add(1)
+		go func(key string, site config.Site) {
+			defer wg.Done()
+
+			since := time.Now().Add(-time.Duration(hours) * time.Hour)
+			q := catalog.Query{Since: since, Page: page, Filter: canonicalFilter(key, site, canonical)}
+			if local, ok := b.listLocal(key, site, q); ok {
+				mu.Lock()
+				defer mu.Unlock()
+				merged.Total += local.Total
+				merged.PageCount = max(merged.PageCount, local.PageCount)
+				merged.List = append(merged.List, local.List...)
+				return
+			}
+
+			reqCtx, cancel := context.WithTimeout(ctx, siteTimeout)
+			defer cancel()
+
+			resp, err := b.mac.List(reqCtx, site, maccms.ListQuery{Hours: hours, Page: page})
+			if err != nil {
+				log.Printf("获取站点 %s 最新更新失败: %v", key, err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			merged.Total += int(resp.Total)
+			merged.PageCount = max(merged.PageCount, int(resp.PageCount))
+			for i := range resp.List {
+				info := resp.List[i].Info(key, site)
+				if canonical != "" && !matchesCanonical(info, canonical) {
+					continue
+				}
+				merged.List = append(merged.List, info)
+			}
+		}(key, site)
+	}
+	wg.Wait()
+
+	sortByUpdated(merged.List)
+	return merged
+}
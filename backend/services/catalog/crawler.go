@@ -0,0 +1,307 @@
+package catalog
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/services/maccms"
+)
+
+// maxDeltaHours 距上次同步超过该小时数时改为重新全量同步
+const maxDeltaHours = 30 * 24
+
+// DisableEnv 设为 1 时不启动后台目录采集
+const DisableEnv = "REELNEST_NO_CRAWL"
+
+// Options 采集选项
+type Options struct {
+	Interval   time.Duration // 两轮增量同步的间隔
+	PageDelay  time.Duration // 同一站点两次翻页请求的间隔
+	Workers    int           // 同时采集的站点数
+	MaxRetries int           // 单页请求失败的重试次数
+	Disabled   bool          // 不进行后台采集，Run 直接返回
+
+	// OnPage 每写入一页视频后调用，可为 nil
+	OnPage func(siteKey string, videos []maccms.Video)
+}
+
+// SiteStatus 站点采集状态
+type SiteStatus struct {
+	Checkpoint
+	Videos  int  `json:"videos"`
+	Running bool `json:"running"`
+}
+
+// Crawler 后台增量同步各站点目录到本地
+// 首次按 ac=videolist&pg= 全量翻页，之后按 h= 只拉取增量
+type Crawler struct {
+	mac   *maccms.Client
+	store *Store
+	opts  Options
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewCrawler 创建目录采集器
+func NewCrawler(mac *maccms.Client, store *Store, opts Options) *Crawler {
+	if opts.Interval <= 0 {
+		opts.Interval = 30 * time.Minute
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	return &Crawler{
+		mac:     mac,
+		store:   store,
+		opts:    opts,
+		running: make(map[string]bool),
+	}
+}
+
+// Run 循环同步所有参与采集的站点，直到 ctx 取消
+func (c *Crawler) Run(ctx context.Context) {
+	if c.opts.Disabled {
+		return
+	}
+	for {
+		c.SyncAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.opts.Interval):
+		}
+	}
+}
+
+// Crawlable 站点是否参与后台采集：只采集 MacCMS 接口站点，网盘与本地片库直接读取，
+// 站点配置 no_crawl 时不采集
+func Crawlable(site config.Site) bool {
+	return site.IsMacCMS() && !site.NoCrawl
+}
+
+// crawlableSites 参与采集的启用站点
+func crawlableSites() map[string]config.Site {
+	sites := config.GetEnabledSites(true)
+	for key, site := range sites {
+		if !Crawlable(site) {
+			delete(sites, key)
+		}
+	}
+	return sites
+}
+
+// SyncAll 同步一轮所有参与采集的站点
+func (c *Crawler) SyncAll(ctx context.Context) {
+	sites := crawlableSites()
+	keys := make([]string, 0, len(sites))
+	for key := range sites {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sem := make(chan struct{}, c.opts.Workers)
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string, site config.Site) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			if err := c.SyncSite(ctx, key, site); err != nil && ctx.Err() == nil {
+				log.Printf("同步站点 %s 目录失败: %v", key, err)
+			}
+		}(key, sites[key])
+	}
+	wg.Wait()
+}
+
+// SyncSite 同步单个站点：未完成全量同步时从断点继续，否则拉取增量
+func (c *Crawler) SyncSite(ctx context.Context, key string, site config.Site) error {
+	// 同一站点同时只允许一个同步任务
+	c.mu.Lock()
+	if c.running[key] {
+		c.mu.Unlock()
+		return nil
+	}
+	c.running[key] = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.running, key)
+		c.mu.Unlock()
+	}()
+
+	cp, err := c.store.Checkpoint(key)
+	if err != nil {
+		return err
+	}
+
+	hours := 0
+	if cp.Done {
+		hours = int(math.Ceil(time.Since(cp.LastSyncAt).Hours())) + 1
+		if hours > maxDeltaHours {
+			// 太久未同步，增量接口未必覆盖，重新全量同步
+			cp = Checkpoint{}
+			hours = 0
+		}
+	}
+
+	if hours > 0 {
+		err = c.syncDelta(ctx, key, site, &cp, hours)
+	} else {
+		err = c.syncFull(ctx, key, site, &cp)
+	}
+	if err != nil {
+		cp.LastError = err.Error()
+	} else {
+		cp.LastError = ""
+	}
+	if saveErr := c.store.SaveCheckpoint(key, cp); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return err
+}
+
+// syncFull 全量翻页同步，每页完成后保存断点
+func (c *Crawler) syncFull(ctx context.Context, key string, site config.Site, cp *Checkpoint) error {
+	if cp.Page == 0 {
+		cp.StartedAt = time.Now()
+	}
+
+	var prev string
+	for page := cp.Page + 1; ; page++ {
+		resp, err := c.fetchPage(ctx, key, site, maccms.ListQuery{Page: page})
+		if err != nil {
+			return err
+		}
+
+		cp.Page = page
+		cp.PageCount = int(resp.PageCount)
+		if lastPage(resp, page, &prev) {
+			// 增量从全量开始的时间算起，覆盖全量期间的更新
+			cp.Done = true
+			cp.LastSyncAt = cp.StartedAt
+			return nil
+		}
+		if err := c.store.SaveCheckpoint(key, *cp); err != nil {
+			return err
+		}
+		if !c.wait(ctx) {
+			return ctx.Err()
+		}
+	}
+}
+
+// syncDelta 拉取最近 hours 小时内的更新
+func (c *Crawler) syncDelta(ctx context.Context, key string, site config.Site, cp *Checkpoint, hours int) error {
+	started := time.Now()
+	var prev string
+	for page := 1; ; page++ {
+		resp, err := c.fetchPage(ctx, key, site, maccms.ListQuery{Page: page, Hours: hours})
+		if err != nil {
+			return err
+		}
+		if lastPage(resp, page, &prev) {
+			cp.LastSyncAt = started
+			return nil
+		}
+		if !c.wait(ctx) {
+			return ctx.Err()
+		}
+	}
+}
+
+// lastPage 是否已到最后一页：列表为空或到达 pagecount；站点不返回 pagecount 时
+// 一直翻到空页，忽略 pg 参数、每页内容相同的站点以首条视频重复判断
+func lastPage(resp *maccms.Response, page int, prev *string) bool {
+	if len(resp.List) == 0 {
+		return true
+	}
+	if resp.PageCount > 0 {
+		return page >= int(resp.PageCount)
+	}
+	first := string(resp.List[0].VodID)
+	if page > 1 && first == *prev {
+		return true
+	}
+	*prev = first
+	return false
+}
+
+// fetchPage 请求一页并写入本地，失败时按指数退避重试
+func (c *Crawler) fetchPage(ctx context.Context, key string, site config.Site, q maccms.ListQuery) (*maccms.Response, error) {
+	var (
+		resp *maccms.Response
+		err  error
+	)
+	for attempt := 0; attempt <= c.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := max(c.opts.PageDelay, time.Second) << attempt
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+		resp, err = c.mac.List(ctx, site, q)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store.Put(key, resp.List); err != nil {
+		return nil, err
+	}
+	if c.opts.OnPage != nil && len(resp.List) > 0 {
+		c.opts.OnPage(key, resp.List)
+	}
+	return resp, nil
+}
+
+// wait 翻页间隔，ctx 取消时返回 false
+func (c *Crawler) wait(ctx context.Context) bool {
+	if c.opts.PageDelay <= 0 {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(c.opts.PageDelay):
+		return true
+	}
+}
+
+// Status 获取所有参与采集的站点的采集状态
+func (c *Crawler) Status() map[string]SiteStatus {
+	c.mu.Lock()
+	running := make(map[string]bool, len(c.running))
+	for key := range c.running {
+		running[key] = true
+	}
+	c.mu.Unlock()
+
+	result := make(map[string]SiteStatus)
+	for key := range crawlableSites() {
+		cp, _ := c.store.Checkpoint(key)
+		result[key] = SiteStatus{
+			Checkpoint: cp,
+			Videos:     c.store.Count(key),
+			Running:    running[key],
+		}
+	}
+	return result
+}
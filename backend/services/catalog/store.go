@@ -0,0 +1,480 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+)
+
+// 存储桶名称
+var (
+	bucketSites       = []byte("sites")
+	bucketCheckpoints = []byte("checkpoints")
+	bucketVideos      = []byte("videos")
+	bucketMeta        = []byte("meta")
+	bucketGrams       = []byte("grams") // 标题索引，键为 "单字或二字\x00视频ID"
+)
+
+// vodTimeLayout MacCMS vod_time 格式
+const vodTimeLayout = "2006-01-02 15:04:05"
+
+// DefaultPageSize 本地分页默认条数，与 MacCMS 接口默认一致
+const DefaultPageSize = 20
+
+// StaleAfter 超过该时间未成功同步的站点目录视为过期，改为实时请求
+const StaleAfter = 6 * time.Hour
+
+// ErrNotFound 本地目录中没有该视频
+var ErrNotFound = errors.New("本地目录中未找到视频")
+
+// meta 视频摘要，用于不解析完整条目即可完成搜索与筛选
+type meta struct {
+	Key     string `json:"k"` // 规范化标题
+	TypeID  string `json:"t"`
+	Updated string `json:"u"` // vod_time
+}
+
+// Checkpoint 站点同步进度，全量同步中断后从 Page+1 继续
+type Checkpoint struct {
+	Page       int       `json:"page"`
+	PageCount  int       `json:"page_count"`
+	Done       bool      `json:"done"`
+	StartedAt  time.Time `json:"started_at"`
+	LastSyncAt time.Time `json:"last_sync_at"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// Query 本地列表查询条件
+type Query struct {
+	TypeIDs []string  // 分类，为空时不限
+	Since   time.Time // 只返回此后更新的视频，零值不限
+	Page    int
+	Size    int
+	// Filter 按完整条目筛选，在分页之前执行；设置后需读取全部候选条目
+	Filter func(maccms.Video) bool
+}
+
+// Store 基于 bbolt 的本地视频目录
+type Store struct {
+	db *bolt.DB
+}
+
+// Open 打开(或创建)本地目录数据库
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSites, bucketCheckpoints} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		// 旧版本的目录没有标题索引，按已有摘要补建
+		return tx.Bucket(bucketSites).ForEachBucket(func(k []byte) error {
+			site := tx.Bucket(bucketSites).Bucket(k)
+			mb := site.Bucket(bucketMeta)
+			if mb == nil || site.Bucket(bucketGrams) != nil {
+				return nil
+			}
+			gb, err := site.CreateBucket(bucketGrams)
+			if err != nil {
+				return err
+			}
+			return mb.ForEach(func(id, v []byte) error {
+				var m meta
+				if json.Unmarshal(v, &m) != nil {
+					return nil
+				}
+				return indexTitle(gb, id, m.Key)
+			})
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put 写入或更新站点视频
+func (s *Store) Put(siteKey string, videos []maccms.Video) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		site, err := tx.Bucket(bucketSites).CreateBucketIfNotExists([]byte(siteKey))
+		if err != nil {
+			return err
+		}
+		vb, err := site.CreateBucketIfNotExists(bucketVideos)
+		if err != nil {
+			return err
+		}
+		mb, err := site.CreateBucketIfNotExists(bucketMeta)
+		if err != nil {
+			return err
+		}
+		gb, err := site.CreateBucketIfNotExists(bucketGrams)
+		if err != nil {
+			return err
+		}
+
+		for i := range videos {
+			v := &videos[i]
+			id := []byte(v.VodID)
+			if len(id) == 0 {
+				continue
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			key := matcher.Normalize(v.VodName)
+			m, err := json.Marshal(meta{
+				Key:     key,
+				TypeID:  string(v.TypeID),
+				Updated: v.VodTime,
+			})
+			if err != nil {
+				return err
+			}
+			// 标题变化时先删除旧标题的索引
+			var old meta
+			if prev := mb.Get(id); prev != nil && json.Unmarshal(prev, &old) == nil && old.Key != key {
+				if err := unindexTitle(gb, id, old.Key); err != nil {
+					return err
+				}
+			}
+			if err := indexTitle(gb, id, key); err != nil {
+				return err
+			}
+			if err := vb.Put(id, data); err != nil {
+				return err
+			}
+			if err := mb.Put(id, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Get 获取单个视频
+func (s *Store) Get(siteKey, id string) (*maccms.Video, error) {
+	var video maccms.Video
+	err := s.db.View(func(tx *bolt.Tx) error {
+		vb := siteBucket(tx, siteKey, bucketVideos)
+		if vb == nil {
+			return ErrNotFound
+		}
+		data := vb.Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &video)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// Count 站点已同步的视频数
+func (s *Store) Count(siteKey string) int {
+	n := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		if mb := siteBucket(tx, siteKey, bucketMeta); mb != nil {
+			n = mb.Stats().KeyN
+		}
+		return nil
+	})
+	return n
+}
+
+// Search 按标题包含关系搜索站点视频，最近更新的优先
+// 先按标题索引取候选视频，再核对规范化标题，不扫描整个目录
+func (s *Store) Search(siteKey, keyword string, limit int) ([]maccms.Video, error) {
+	key := matcher.Normalize(keyword)
+	if key == "" {
+		return nil, nil
+	}
+	candidates := func(site *bolt.Bucket) [][]byte {
+		return lookupTitle(site.Bucket(bucketGrams), key)
+	}
+	videos, _, err := s.collect(siteKey, candidates, func(m meta) bool {
+		return strings.Contains(m.Key, key)
+	}, nil, 1, limit)
+	return videos, err
+}
+
+// ForEachTitle 遍历所有站点视频的标题
+func (s *Store) ForEachTitle(fn func(title string)) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		sites := tx.Bucket(bucketSites)
+		return sites.ForEachBucket(func(k []byte) error {
+			vb := sites.Bucket(k).Bucket(bucketVideos)
+			if vb == nil {
+				return nil
+			}
+			return vb.ForEach(func(_, v []byte) error {
+				var video struct {
+					Name string `json:"vod_name"`
+				}
+				if json.Unmarshal(v, &video) == nil && video.Name != "" {
+					fn(video.Name)
+				}
+				return nil
+			})
+		})
+	})
+}
+
+// List 按分类与更新时间分页列出站点视频，返回当前页与总数
+func (s *Store) List(siteKey string, q Query) ([]maccms.Video, int, error) {
+	typeIDs := make(map[string]bool, len(q.TypeIDs))
+	for _, id := range q.TypeIDs {
+		typeIDs[id] = true
+	}
+	since := ""
+	if !q.Since.IsZero() {
+		since = q.Since.In(time.Local).Format(vodTimeLayout)
+	}
+
+	return s.collect(siteKey, nil, func(m meta) bool {
+		if len(typeIDs) > 0 && !typeIDs[m.TypeID] {
+			return false
+		}
+		// vod_time 为定长格式，可直接按字符串比较
+		return since == "" || m.Updated >= since
+	}, q.Filter, q.Page, q.Size)
+}
+
+// collect 筛选摘要，按更新时间倒序分页后读取完整条目
+// candidates 为 nil 时扫描站点全部摘要，否则只检查其返回的视频；filter 非空时按完整条目筛选后再分页
+func (s *Store) collect(siteKey string, candidates func(site *bolt.Bucket) [][]byte, match func(meta) bool, filter func(maccms.Video) bool, page, size int) ([]maccms.Video, int, error) {
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	page = max(page, 1)
+
+	type hit struct {
+		id      string
+		updated string
+	}
+	var (
+		hits   []hit
+		videos []maccms.Video
+		total  int
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		mb := siteBucket(tx, siteKey, bucketMeta)
+		vb := siteBucket(tx, siteKey, bucketVideos)
+		if mb == nil || vb == nil {
+			return nil
+		}
+
+		visit := func(k, v []byte) error {
+			var m meta
+			if err := json.Unmarshal(v, &m); err != nil {
+				return nil
+			}
+			if match(m) {
+				hits = append(hits, hit{string(k), m.Updated})
+			}
+			return nil
+		}
+		if candidates == nil {
+			if err := mb.ForEach(visit); err != nil {
+				return err
+			}
+		} else {
+			for _, id := range candidates(tx.Bucket(bucketSites).Bucket([]byte(siteKey))) {
+				if v := mb.Get(id); v != nil {
+					visit(id, v)
+				}
+			}
+		}
+
+		sort.Slice(hits, func(i, j int) bool {
+			if hits[i].updated != hits[j].updated {
+				return hits[i].updated > hits[j].updated
+			}
+			return hits[i].id > hits[j].id
+		})
+
+		if filter != nil {
+			for _, h := range hits {
+				var video maccms.Video
+				if err := json.Unmarshal(vb.Get([]byte(h.id)), &video); err != nil {
+					return fmt.Errorf("解析本地视频 %s 失败: %w", h.id, err)
+				}
+				if !filter(video) {
+					continue
+				}
+				if total/size == page-1 {
+					videos = append(videos, video)
+				}
+				total++
+			}
+			return nil
+		}
+
+		// 超出总页数时直接返回空页，避免页码过大时计算起点溢出
+		total = len(hits)
+		if len(hits) == 0 || page-1 > (len(hits)-1)/size {
+			return nil
+		}
+		start := (page - 1) * size
+		for _, h := range hits[start:min(start+size, len(hits))] {
+			var video maccms.Video
+			if err := json.Unmarshal(vb.Get([]byte(h.id)), &video); err != nil {
+				return fmt.Errorf("解析本地视频 %s 失败: %w", h.id, err)
+			}
+			videos = append(videos, video)
+		}
+		return nil
+	})
+	return videos, total, err
+}
+
+// Checkpoint 获取站点同步进度
+func (s *Store) Checkpoint(siteKey string) (Checkpoint, error) {
+	var cp Checkpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketCheckpoints).Get([]byte(siteKey))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &cp)
+	})
+	return cp, err
+}
+
+// SaveCheckpoint 保存站点同步进度
+func (s *Store) SaveCheckpoint(siteKey string, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketCheckpoints).Put([]byte(siteKey), data)
+	})
+}
+
+// Synced 站点是否已完成全量同步且最近同步过，是才可用本地目录代替实时请求
+func (s *Store) Synced(siteKey string) bool {
+	cp, err := s.Checkpoint(siteKey)
+	return err == nil && cp.Done && time.Since(cp.LastSyncAt) < StaleAfter
+}
+
+// titleGrams 规范化标题中的单字与相邻二字，去重
+func titleGrams(key string) []string {
+	runes := []rune(key)
+	seen := make(map[string]bool, 2*len(runes))
+	grams := make([]string, 0, 2*len(runes))
+	add := func(g string) {
+		if !seen[g] {
+			seen[g] = true
+			grams = append(grams, g)
+		}
+	}
+	for i := range runes {
+		add(string(runes[i]))
+		if i+1 < len(runes) {
+			add(string(runes[i : i+2]))
+		}
+	}
+	return grams
+}
+
+// queryGrams 查询词用于查找的索引项：单字查询为该字，否则为全部相邻二字
+func queryGrams(key string) []string {
+	if runes := []rune(key); len(runes) == 1 {
+		return []string{key}
+	}
+	var grams []string
+	for _, g := range titleGrams(key) {
+		if len([]rune(g)) == 2 {
+			grams = append(grams, g)
+		}
+	}
+	return grams
+}
+
+// gramKey 标题索引键
+func gramKey(gram string, id []byte) []byte {
+	return append([]byte(gram+"\x00"), id...)
+}
+
+// indexTitle 写入视频标题的索引
+func indexTitle(gb *bolt.Bucket, id []byte, key string) error {
+	for _, g := range titleGrams(key) {
+		if err := gb.Put(gramKey(g, id), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexTitle 删除视频标题的索引
+func unindexTitle(gb *bolt.Bucket, id []byte, key string) error {
+	for _, g := range titleGrams(key) {
+		if err := gb.Delete(gramKey(g, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupTitle 按标题索引查找标题可能包含 key 的视频：各索引项对应视频的交集
+func lookupTitle(gb *bolt.Bucket, key string) [][]byte {
+	if gb == nil {
+		return nil
+	}
+	var ids map[string]bool
+	for _, g := range queryGrams(key) {
+		prefix := []byte(g + "\x00")
+		next := make(map[string]bool)
+		c := gb.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			if id := string(k[len(prefix):]); ids == nil || ids[id] {
+				next[id] = true
+			}
+		}
+		if ids = next; len(ids) == 0 {
+			break
+		}
+	}
+	result := make([][]byte, 0, len(ids))
+	for id := range ids {
+		result = append(result, []byte(id))
+	}
+	return result
+}
+
+// siteBucket 获取站点下的子桶，不存在时返回 nil
+func siteBucket(tx *bolt.Tx, siteKey string, name []byte) *bolt.Bucket {
+	site := tx.Bucket(bucketSites).Bucket([]byte(siteKey))
+	if site == nil {
+		return nil
+	}
+	return site.Bucket(name)
+}
@@ -0,0 +1,212 @@
+package catalog
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/internal/testutil"
+	"ReelNest/services/maccms"
+)
+
+func openStore(t *testing.T) *Store {
+	return testutil.OpenStore(t, "catalog.db", Open)
+}
+
+func video(id, name, typeID, updated string) maccms.Video {
+	return maccms.Video{VodID: maccms.FlexString(id), VodName: name, TypeID: maccms.FlexString(typeID), VodTime: updated}
+}
+
+func ids(videos []maccms.Video) string {
+	var list []string
+	for _, v := range videos {
+		list = append(list, string(v.VodID))
+	}
+	return strings.Join(list, ",")
+}
+
+func TestSearchTitleIndex(t *testing.T) {
+	s := openStore(t)
+	err := s.Put("a", []maccms.Video{
+		video("1", "庆余年", "2", "2024-01-01 00:00:00"),
+		video("2", "庆余年第二季", "2", "2024-05-01 00:00:00"),
+		video("3", "余罪", "2", "2024-03-01 00:00:00"),
+		video("4", "The Office", "2", "2024-02-01 00:00:00"),
+		video("", "无 ID", "2", "2024-02-01 00:00:00"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keyword, want string
+	}{
+		{"庆余年", "2,1"},
+		{"慶餘年", "2,1"},
+		{"余", "2,3,1"},
+		{"余年第", "2"},
+		{"年余", ""}, // 二字都出现过但不相邻
+		{"office", "4"},
+		{"不存在", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := s.Search("a", tt.keyword, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(got) != tt.want {
+			t.Errorf("Search(%q) = %s, want %s", tt.keyword, ids(got), tt.want)
+		}
+	}
+	if got, _ := s.Search("other", "庆余年", 10); len(got) != 0 {
+		t.Errorf("unknown site returned %s", ids(got))
+	}
+
+	// 标题变化后旧标题不再命中
+	if err := s.Put("a", []maccms.Video{video("3", "狂飙", "2", "2024-03-01 00:00:00")}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Search("a", "余罪", 10); len(got) != 0 {
+		t.Errorf("stale title still indexed: %s", ids(got))
+	}
+	if got, _ := s.Search("a", "狂飙", 10); ids(got) != "3" {
+		t.Errorf("renamed title = %s", ids(got))
+	}
+	if n := s.Count("a"); n != 4 {
+		t.Errorf("Count = %d, want 4", n)
+	}
+}
+
+func TestTitleGrams(t *testing.T) {
+	got := titleGrams("庆余年余")
+	sort.Strings(got)
+	want := []string{"余", "余年", "年", "年余", "庆", "庆余"}
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("titleGrams = %v, want %v", got, want)
+	}
+	if got := queryGrams("庆"); len(got) != 1 || got[0] != "庆" {
+		t.Errorf("queryGrams single = %v", got)
+	}
+	if got := queryGrams("庆余年"); strings.Join(got, ",") != "庆余,余年" {
+		t.Errorf("queryGrams = %v", got)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	s := openStore(t)
+	odd := func(v maccms.Video) bool { return v.TypeID == "1" }
+	var videos []maccms.Video
+	for i := 1; i <= 5; i++ {
+		typeID := "1"
+		if i%2 == 0 {
+			typeID = "2"
+		}
+		videos = append(videos, video(fmt.Sprint(i), fmt.Sprint("视频", i), typeID, fmt.Sprintf("2024-01-0%d 00:00:00", i)))
+	}
+	if err := s.Put("a", videos); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		q     Query
+		want  string
+		total int
+	}{
+		{Query{Page: 1, Size: 2}, "5,4", 5},
+		{Query{Page: 3, Size: 2}, "1", 5},
+		{Query{Page: 4, Size: 2}, "", 5},
+		{Query{Page: 0}, "5,4,3,2,1", 5},
+		{Query{Page: math.MaxInt, Size: 20}, "", 5},
+		{Query{Page: math.MaxInt / 20, Size: 20}, "", 5},
+		{Query{Page: 1, Size: math.MaxInt}, "5,4,3,2,1", 5},
+		{Query{Page: 2, Size: math.MaxInt}, "", 5},
+		{Query{TypeIDs: []string{"2"}}, "4,2", 2},
+		{Query{Since: time.Date(2024, 1, 4, 0, 0, 0, 0, time.Local)}, "5,4", 2},
+		// 按完整条目筛选后再分页，总数为筛选后的条数
+		{Query{Page: 1, Size: 2, Filter: odd}, "5,3", 3},
+		{Query{Page: 2, Size: 2, Filter: odd}, "1", 3},
+		{Query{Page: 3, Size: 2, Filter: odd}, "", 3},
+	}
+	for _, tt := range tests {
+		got, total, err := s.List("a", tt.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(got) != tt.want || total != tt.total {
+			t.Errorf("List(%+v) = %s (%d), want %s (%d)", tt.q, ids(got), total, tt.want, tt.total)
+		}
+	}
+	if got, total, err := s.List("missing", Query{Page: 1}); err != nil || len(got) != 0 || total != 0 {
+		t.Errorf("missing site = %v, %d, %v", got, total, err)
+	}
+}
+
+func TestSynced(t *testing.T) {
+	s := openStore(t)
+	if s.Synced("a") {
+		t.Error("site without checkpoint reported as synced")
+	}
+	s.SaveCheckpoint("a", Checkpoint{Done: true, LastSyncAt: time.Now()})
+	if !s.Synced("a") {
+		t.Error("fresh checkpoint not synced")
+	}
+	s.SaveCheckpoint("a", Checkpoint{Done: true, LastSyncAt: time.Now().Add(-StaleAfter - time.Minute)})
+	if s.Synced("a") {
+		t.Error("stale checkpoint reported as synced")
+	}
+}
+
+func TestLastPage(t *testing.T) {
+	resp := func(pageCount int, ids ...string) *maccms.Response {
+		r := &maccms.Response{PageCount: maccms.FlexInt(pageCount)}
+		for _, id := range ids {
+			r.List = append(r.List, maccms.Video{VodID: maccms.FlexString(id)})
+		}
+		return r
+	}
+
+	var prev string
+	if !lastPage(resp(3), 1, &prev) {
+		t.Error("empty page should be last")
+	}
+	if lastPage(resp(3, "a"), 2, &prev) || !lastPage(resp(3, "a"), 3, &prev) {
+		t.Error("pagecount not honoured")
+	}
+
+	// 不返回 pagecount：翻到空页或首条重复为止
+	prev = ""
+	steps := []struct {
+		first string
+		last  bool
+	}{{"a", false}, {"b", false}, {"b", true}}
+	for i, s := range steps {
+		if got := lastPage(resp(0, s.first), i+1, &prev); got != s.last {
+			t.Errorf("page %d last = %v, want %v", i+1, got, s.last)
+		}
+	}
+}
+
+func TestCrawlable(t *testing.T) {
+	tests := []struct {
+		site config.Site
+		want bool
+	}{
+		{config.Site{Api: "https://a.com"}, true},
+		{config.Site{Api: "https://a.com", Type: config.SiteTypeVod}, true},
+		{config.Site{Api: "https://a.com", NoCrawl: true}, false},
+		{config.Site{Api: "https://dav.example/", Type: config.SiteTypeWebDAV}, false},
+		{config.Site{Api: "/media", Type: config.SiteTypeLocal}, false},
+		{config.Site{Api: "http://x/live.m3u", Type: config.SiteTypeLive}, false},
+	}
+	for _, tt := range tests {
+		if got := Crawlable(tt.site); got != tt.want {
+			t.Errorf("Crawlable(%+v) = %v, want %v", tt.site, got, tt.want)
+		}
+	}
+}
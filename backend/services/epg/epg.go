@@ -0,0 +1,214 @@
+package epg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/utils"
+)
+
+const (
+	// fetchTimeout 下载单个节目单的超时
+	fetchTimeout = 2 * time.Minute
+	// maxGuideBytes 单个节目单文件大小上限
+	maxGuideBytes = 128 << 20
+	// pruneInterval 清理过期节目的间隔
+	pruneInterval = time.Hour
+)
+
+// Options 节目单选项
+type Options struct {
+	Interval      time.Duration // 重新下载节目单的间隔
+	KeepPast      time.Duration // 保留已结束节目的时长
+	KeepFuture    time.Duration // 保留未来节目的时长
+	MaxProgrammes int           // 内存中保留的节目总数上限
+}
+
+// SourceStatus 节目单来源的加载状态
+type SourceStatus struct {
+	URL        string `json:"url"`
+	Channels   int    `json:"channels"`
+	Programmes int    `json:"programmes"`
+	LoadedAt   int64  `json:"loaded_at,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Status 节目单整体状态
+type Status struct {
+	Channels   int            `json:"channels"`
+	Programmes int            `json:"programmes"`
+	RefreshAt  int64          `json:"refresh_at,omitempty"`
+	Sources    []SourceStatus `json:"sources"`
+}
+
+// Service 电子节目单服务：定期加载各直播源的 XMLTV 节目单，按频道 tvg-id 提供查询
+type Service struct {
+	hc      *client.Client
+	sources func(ctx context.Context) []string
+	opts    Options
+
+	mu        sync.RWMutex
+	guide     *Guide
+	status    []SourceStatus
+	refreshAt time.Time
+}
+
+// NewService 创建节目单服务，sources 返回当前需要加载的节目单地址或文件路径
+func NewService(hc *client.Client, sources func(ctx context.Context) []string, opts Options) *Service {
+	if opts.Interval <= 0 {
+		opts.Interval = 6 * time.Hour
+	}
+	if opts.KeepPast <= 0 {
+		opts.KeepPast = 6 * time.Hour
+	}
+	if opts.KeepFuture <= 0 {
+		opts.KeepFuture = 72 * time.Hour
+	}
+	return &Service{
+		hc:      hc,
+		sources: sources,
+		opts:    opts,
+		guide:   newGuide(opts.MaxProgrammes),
+	}
+}
+
+// Run 加载节目单并定期刷新、清理过期节目，直到 ctx 取消
+func (s *Service) Run(ctx context.Context) {
+	s.Refresh(ctx)
+
+	refresh := time.NewTicker(s.opts.Interval)
+	defer refresh.Stop()
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-refresh.C:
+			s.Refresh(ctx)
+		case <-prune.C:
+			s.mu.Lock()
+			s.guide.prune(time.Now().Add(-s.opts.KeepPast).Unix())
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Refresh 重新加载所有节目单，全部失败时保留现有节目单
+func (s *Service) Refresh(ctx context.Context) {
+	now := time.Now()
+	w := window{
+		from: now.Add(-s.opts.KeepPast).Unix(),
+		to:   now.Add(s.opts.KeepFuture).Unix(),
+	}
+
+	guide := newGuide(s.opts.MaxProgrammes)
+	var statuses []SourceStatus
+	loaded := 0
+	for _, src := range s.sources(ctx) {
+		if ctx.Err() != nil {
+			return
+		}
+		status := SourceStatus{URL: src}
+		part := newGuide(s.opts.MaxProgrammes)
+		if err := s.load(ctx, src, w, part); err != nil {
+			log.Printf("加载节目单 %s 失败: %v", src, err)
+			status.Error = err.Error()
+		} else {
+			part.finish()
+			status.Channels = len(part.programmes)
+			status.Programmes = part.count
+			status.LoadedAt = time.Now().Unix()
+			guide.merge(part)
+			loaded++
+		}
+		statuses = append(statuses, status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if loaded > 0 || len(statuses) == 0 {
+		s.guide = guide
+	} else {
+		s.guide.prune(w.from)
+	}
+	s.status = statuses
+	s.refreshAt = now
+}
+
+// load 读取并解析单个节目单
+func (s *Service) load(ctx context.Context, src string, w window, g *Guide) error {
+	var r io.Reader
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		reqCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+		result, err := utils.Fetch(reqCtx, s.hc, src, "", maxGuideBytes)
+		if err != nil {
+			return err
+		}
+		if result.StatusCode != 200 {
+			return fmt.Errorf("节目单请求失败: HTTP %d", result.StatusCode)
+		}
+		r = bytes.NewReader(result.Body)
+	} else {
+		f, err := os.Open(config.ResolvePath(strings.TrimPrefix(src, "file://")))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	return parseXMLTV(r, w, g)
+}
+
+// NowNext 获取频道的正在播出与下一档节目，频道不在节目单中时返回 false
+func (s *Service) NowNext(ch models.LiveChannel, at time.Time) (models.ChannelGuide, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.guide.resolve(ch.TvgID, ch.TvgName, ch.Name)
+	if !ok {
+		return models.ChannelGuide{}, false
+	}
+	now, next := s.guide.nowNext(id, at.Unix())
+	return models.ChannelGuide{ChannelID: id, Name: s.guide.display[id], Now: now, Next: next}, true
+}
+
+// Schedule 获取频道在 [from, to) 内的节目列表
+func (s *Service) Schedule(ch models.LiveChannel, from, to time.Time) ([]models.Programme, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.guide.resolve(ch.TvgID, ch.TvgName, ch.Name)
+	if !ok {
+		return nil, false
+	}
+	return s.guide.between(id, from.Unix(), to.Unix()), true
+}
+
+// Status 获取节目单加载状态
+func (s *Service) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := Status{
+		Channels:   len(s.guide.programmes),
+		Programmes: s.guide.count,
+		Sources:    append([]SourceStatus{}, s.status...),
+	}
+	if !s.refreshAt.IsZero() {
+		st.RefreshAt = s.refreshAt.Unix()
+	}
+	return st
+}
@@ -0,0 +1,165 @@
+package epg
+
+import (
+	"sort"
+
+	"ReelNest/models"
+	"ReelNest/services/matcher"
+)
+
+// Guide 按频道 ID 索引的节目单
+type Guide struct {
+	programmes map[string][]models.Programme
+	// names 规范化后的频道显示名称到频道 ID，用于没有 tvg-id 的频道
+	names map[string]string
+	// display 频道 ID 到显示名称
+	display map[string]string
+
+	count int
+	limit int
+}
+
+func newGuide(limit int) *Guide {
+	return &Guide{
+		programmes: make(map[string][]models.Programme),
+		names:      make(map[string]string),
+		display:    make(map[string]string),
+		limit:      limit,
+	}
+}
+
+func (g *Guide) addChannel(id string, names []string) {
+	if id == "" {
+		return
+	}
+	for _, name := range names {
+		if _, ok := g.display[id]; !ok && name != "" {
+			g.display[id] = name
+		}
+		if key := matcher.Normalize(name); key != "" {
+			if _, ok := g.names[key]; !ok {
+				g.names[key] = id
+			}
+		}
+	}
+	if key := matcher.Normalize(id); key != "" {
+		if _, ok := g.names[key]; !ok {
+			g.names[key] = id
+		}
+	}
+}
+
+// addProgramme 添加节目，超过总数上限时丢弃
+func (g *Guide) addProgramme(id string, p models.Programme) bool {
+	if g.limit > 0 && g.count >= g.limit {
+		return false
+	}
+	g.programmes[id] = append(g.programmes[id], p)
+	g.count++
+	return true
+}
+
+// merge 合并另一份节目单，已有的频道不覆盖
+func (g *Guide) merge(other *Guide) {
+	for id, list := range other.programmes {
+		if _, ok := g.programmes[id]; ok {
+			continue
+		}
+		if g.limit > 0 && g.count+len(list) > g.limit {
+			continue
+		}
+		g.programmes[id] = list
+		g.count += len(list)
+	}
+	for key, id := range other.names {
+		if _, ok := g.names[key]; !ok {
+			g.names[key] = id
+		}
+	}
+	for id, name := range other.display {
+		if _, ok := g.display[id]; !ok {
+			g.display[id] = name
+		}
+	}
+}
+
+// finish 按开始时间排序、去掉重复时段，并以下一档的开始时间补齐缺失的结束时间
+func (g *Guide) finish() {
+	g.count = 0
+	for id, list := range g.programmes {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Start < list[j].Start })
+		out := list[:0]
+		for _, p := range list {
+			if n := len(out); n > 0 && out[n-1].Start == p.Start {
+				continue
+			}
+			out = append(out, p)
+		}
+		for i := range out {
+			if out[i].Stop <= out[i].Start && i+1 < len(out) {
+				out[i].Stop = out[i+1].Start
+			}
+		}
+		g.programmes[id] = out
+		g.count += len(out)
+	}
+}
+
+// prune 移除在 before 之前已结束的节目
+func (g *Guide) prune(before int64) {
+	g.count = 0
+	for id, list := range g.programmes {
+		i := sort.Search(len(list), func(i int) bool { return list[i].Stop > before })
+		if i == len(list) {
+			delete(g.programmes, id)
+			continue
+		}
+		if i > 0 {
+			list = append([]models.Programme(nil), list[i:]...)
+			g.programmes[id] = list
+		}
+		g.count += len(list)
+	}
+}
+
+// resolve 按 tvg-id、tvg-name、频道名称依次匹配节目单中的频道 ID
+func (g *Guide) resolve(tvgID, tvgName, name string) (string, bool) {
+	if tvgID != "" {
+		if _, ok := g.programmes[tvgID]; ok {
+			return tvgID, true
+		}
+	}
+	for _, candidate := range []string{tvgID, tvgName, name} {
+		if id, ok := g.names[matcher.Normalize(candidate)]; ok && candidate != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// nowNext 返回 at 时刻正在播出与下一档节目
+func (g *Guide) nowNext(id string, at int64) (now, next *models.Programme) {
+	list := g.programmes[id]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Stop > at })
+	if i < len(list) && list[i].Start <= at {
+		p := list[i]
+		now = &p
+		i++
+	}
+	if i < len(list) {
+		p := list[i]
+		next = &p
+	}
+	return now, next
+}
+
+// between 返回与 [from, to) 有交集的节目
+func (g *Guide) between(id string, from, to int64) []models.Programme {
+	list := g.programmes[id]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Stop > from })
+	var out []models.Programme
+	for ; i < len(list) && list[i].Start < to; i++ {
+		out = append(out, list[i])
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package epg
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"ReelNest/models"
+)
+
+// xmltvTimeLayouts XMLTV 时间格式，时区缺省时按 UTC 处理
+var xmltvTimeLayouts = []string{
+	"20060102150405 -0700",
+	"20060102150405-0700",
+	"20060102150405",
+	"200601021504 -0700",
+	"200601021504",
+}
+
+// xmlChannel <channel> 元素
+type xmlChannel struct {
+	ID           string   `xml:"id,attr"`
+	DisplayNames []string `xml:"display-name"`
+}
+
+// xmlProgramme <programme> 元素
+type xmlProgramme struct {
+	Start    string   `xml:"start,attr"`
+	Stop     string   `xml:"stop,attr"`
+	Channel  string   `xml:"channel,attr"`
+	Titles   []string `xml:"title"`
+	SubTitle string   `xml:"sub-title"`
+	Desc     string   `xml:"desc"`
+	Category []string `xml:"category"`
+}
+
+// window 保留的节目时间范围，范围外的节目在解析时丢弃
+type window struct {
+	from, to int64
+}
+
+func (w window) contains(p models.Programme) bool {
+	return p.Stop > w.from && p.Start < w.to
+}
+
+// parseXMLTV 流式解析 XMLTV(自动识别 gzip)，只保留时间窗口内的节目
+func parseXMLTV(r io.Reader, w window, g *Guide) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("解压节目单失败: %w", err)
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	dec := xml.NewDecoder(br)
+	// 部分节目单声明 GBK 等编码，内容实际多为 UTF-8，直接按原样读取
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	dec.Strict = false
+
+	found := false
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("解析节目单失败: %w", err)
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch se.Name.Local {
+		case "tv":
+			found = true
+		case "channel":
+			var ch xmlChannel
+			if err := dec.DecodeElement(&ch, &se); err != nil {
+				return fmt.Errorf("解析频道失败: %w", err)
+			}
+			g.addChannel(ch.ID, ch.DisplayNames)
+		case "programme":
+			var xp xmlProgramme
+			if err := dec.DecodeElement(&xp, &se); err != nil {
+				return fmt.Errorf("解析节目失败: %w", err)
+			}
+			p, ok := convertProgramme(xp)
+			if ok && w.contains(p) {
+				g.addProgramme(xp.Channel, p)
+			}
+		}
+	}
+	if !found {
+		return errors.New("不是 XMLTV 节目单")
+	}
+	return nil
+}
+
+// convertProgramme 转换节目元素，时间无效时返回 false
+func convertProgramme(xp xmlProgramme) (models.Programme, bool) {
+	start, err1 := parseTime(xp.Start)
+	stop, err2 := parseTime(xp.Stop)
+	if err1 != nil || xp.Channel == "" {
+		return models.Programme{}, false
+	}
+	p := models.Programme{
+		SubTitle: strings.TrimSpace(xp.SubTitle),
+		Desc:     strings.TrimSpace(xp.Desc),
+		Start:    start.Unix(),
+	}
+	if len(xp.Titles) > 0 {
+		p.Title = strings.TrimSpace(xp.Titles[0])
+	}
+	if len(xp.Category) > 0 {
+		p.Category = strings.TrimSpace(xp.Category[0])
+	}
+	// 缺少结束时间的节目暂按开始时间处理，整理时以下一档节目的开始时间补齐
+	if err2 == nil && stop.After(start) {
+		p.Stop = stop.Unix()
+	} else {
+		p.Stop = p.Start
+	}
+	return p, true
+}
+
+// parseTime 解析 XMLTV 时间
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range xmltvTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的节目时间: %s", value)
+}
@@ -0,0 +1,114 @@
+package epg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleXMLTV = `<?xml version="1.0" encoding="GBK"?>
+<!DOCTYPE tv SYSTEM "xmltv.dtd">
+<tv>
+  <channel id="CCTV1"><display-name>CCTV-1 综合</display-name><display-name>CCTV1</display-name></channel>
+  <programme start="20240101080000 +0800" stop="20240101090000 +0800" channel="CCTV1">
+    <title> 朝闻天下 </title><desc>新闻</desc><category>新闻</category>
+  </programme>
+  <programme start="20240101090000 +0800" channel="CCTV1"><title>生活提示</title></programme>
+  <programme start="20240101100000 +0800" stop="20240101110000 +0800" channel="CCTV1"><title>电视剧</title></programme>
+  <programme start="20240101090000 +0800" stop="20240101093000 +0800" channel="CCTV1"><title>重复时段</title></programme>
+  <programme start="20240102080000 +0800" stop="20240102090000 +0800" channel="CCTV1"><title>窗口外</title></programme>
+  <programme start="bad" stop="20240101090000 +0800" channel="CCTV1"><title>无效时间</title></programme>
+  <programme start="20240101080000 +0800" stop="20240101090000 +0800"><title>无频道</title></programme>
+</tv>`
+
+// at 东八区时间
+func at(hour, minute int) int64 {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.FixedZone("CST", 8*3600)).Unix()
+}
+
+func TestParseXMLTV(t *testing.T) {
+	w := window{from: at(0, 0), to: at(23, 59)}
+	g := newGuide(0)
+	if err := parseXMLTV(strings.NewReader(sampleXMLTV), w, g); err != nil {
+		t.Fatal(err)
+	}
+	g.finish()
+
+	list := g.programmes["CCTV1"]
+	var titles []string
+	for _, p := range list {
+		titles = append(titles, p.Title)
+	}
+	// 同一开始时间只保留先出现的节目，窗口外与无效节目被丢弃
+	if got := strings.Join(titles, ","); got != "朝闻天下,生活提示,电视剧" {
+		t.Fatalf("titles = %s", got)
+	}
+	if p := list[0]; p.Start != at(8, 0) || p.Stop != at(9, 0) || p.Desc != "新闻" || p.Category != "新闻" {
+		t.Errorf("first = %+v", p)
+	}
+	// 缺少结束时间的节目以下一档的开始时间补齐
+	if list[1].Stop != at(10, 0) {
+		t.Errorf("missing stop = %d, want %d", list[1].Stop, at(10, 0))
+	}
+
+	for _, name := range []string{"CCTV1", "CCTV-1 综合", "cctv 1"} {
+		if id, ok := g.resolve("", "", name); !ok || id != "CCTV1" {
+			t.Errorf("resolve(%q) = %q, %v", name, id, ok)
+		}
+	}
+	now, next := g.nowNext("CCTV1", at(8, 30))
+	if now == nil || now.Title != "朝闻天下" || next == nil || next.Title != "生活提示" {
+		t.Errorf("nowNext = %+v, %+v", now, next)
+	}
+}
+
+func TestParseXMLTVGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(sampleXMLTV))
+	zw.Close()
+
+	g := newGuide(0)
+	if err := parseXMLTV(&buf, window{from: at(0, 0), to: at(23, 59)}, g); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.programmes["CCTV1"]) == 0 {
+		t.Error("no programmes from gzip input")
+	}
+}
+
+func TestParseXMLTVLimitAndInvalid(t *testing.T) {
+	g := newGuide(2)
+	if err := parseXMLTV(strings.NewReader(sampleXMLTV), window{from: at(0, 0), to: at(23, 59)}, g); err != nil {
+		t.Fatal(err)
+	}
+	if g.count != 2 {
+		t.Errorf("count = %d, want limit 2", g.count)
+	}
+
+	if err := parseXMLTV(strings.NewReader(`<html><body>not found</body></html>`), window{}, newGuide(0)); err == nil {
+		t.Error("expected error for non-XMLTV input")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"20240101080000 +0800", at(8, 0), true},
+		{"20240101080000+0800", at(8, 0), true},
+		{"20240101000000", at(8, 0), true},
+		{"202401010800 +0800", at(8, 0), true},
+		{"2024-01-01", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in)
+		if (err == nil) != tt.ok || (tt.ok && got.Unix() != tt.want) {
+			t.Errorf("parseTime(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
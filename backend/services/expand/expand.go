@@ -0,0 +1,98 @@
+package expand
+
+import (
+	"strings"
+
+	"ReelNest/services/zhconv"
+)
+
+// Expander 搜索词扩展：繁简互转，拼音/首字母映射为索引中的候选标题
+type Expander struct {
+	index       *Index
+	maxVariants int
+}
+
+// NewExpander 创建搜索词扩展器，maxVariants 为单次扩展的最大搜索词数(含原词)
+func NewExpander(index *Index, maxVariants int) *Expander {
+	return &Expander{index: index, maxVariants: max(maxVariants, 1)}
+}
+
+// Index 返回标题索引
+func (e *Expander) Index() *Index {
+	return e.index
+}
+
+// Expand 返回去重后的搜索词列表，原词始终排在第一位
+func (e *Expander) Expand(query string) []string {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	variants := make([]string, 0, e.maxVariants)
+	seen := make(map[string]bool)
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] || len(variants) >= e.maxVariants {
+			return
+		}
+		seen[v] = true
+		variants = append(variants, v)
+	}
+
+	add(query)
+	if IsPinyin(query) {
+		for _, title := range e.index.Lookup(query, e.maxVariants) {
+			add(title)
+		}
+		return variants
+	}
+
+	// 上游站点多数只按简体精确匹配，简体放在繁体之前
+	add(zhconv.ToSimplified(query))
+	add(zhconv.ToTraditional(query))
+	return variants
+}
+
+// maxInitials 按首字母缩写识别的搜索词最大长度
+const maxInitials = 6
+
+// IsPinyin 判断搜索词是否为拼音或首字母：以空格或撇号分隔的各段均能切分为拼音音节
+// (末段允许只输入了一半)，或为不含空格、至多一个元音的 2~6 个字母缩写
+// 普通英文词通常无法切分为音节且元音较多，不会被当作拼音
+func IsPinyin(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	letters := 0
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			letters++
+		case r == ' ' || r == '\'':
+		default:
+			return false
+		}
+	}
+	if letters < 2 {
+		return false
+	}
+
+	pieces := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\'' })
+	if len(pieces) == 1 && isInitials(pieces[0]) {
+		return true
+	}
+	for i, piece := range pieces {
+		if !segmentable(piece, i == len(pieces)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// isInitials 判断是否像拼音首字母缩写，如 dmbj、xyj
+func isInitials(s string) bool {
+	if len(s) < 2 || len(s) > maxInitials {
+		return false
+	}
+	return strings.Count(s, "a")+strings.Count(s, "e")+strings.Count(s, "i")+
+		strings.Count(s, "o")+strings.Count(s, "u")+strings.Count(s, "v") <= 1
+}
@@ -0,0 +1,98 @@
+package expand
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPinyin(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"qingyunian", true},
+		{"QingYuNian", true},
+		{"qing yu nian", true},
+		{"xi'an", true},
+		{"zhongg", true}, // 末尾音节只输入了一半
+		{"lvse", true},
+		{"dmbj", true}, // 首字母
+		{"xyj", true},
+		{"avatar", false},
+		{"friends", false},
+		{"hello", false},
+		{"the office", false},
+		{"batman", false},
+		{"dota", false},
+		{"x", false},
+		{"庆余年", false},
+		{"qingyunian2", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsPinyin(tt.in); got != tt.want {
+			t.Errorf("IsPinyin(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	idx, _ := NewIndex("")
+	idx.Add("庆余年", "庆余年第二季", "青云志", "Friends")
+	e := NewExpander(idx, 3)
+
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"qyn", []string{"qyn", "庆余年", "庆余年第二季"}},
+		{"qingyunian", []string{"qingyunian", "庆余年", "庆余年第二季"}},
+		{"慶餘年", []string{"慶餘年", "庆余年"}},
+		{"friends", []string{"friends"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		got := e.Expand(tt.in)
+		if len(got) != len(tt.want) {
+			t.Errorf("Expand(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Expand(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestIndexSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "titles.json")
+	idx, err := NewIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Add("庆余年")
+
+	// 写入失败后保留变更，下次保存时重试
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Save(); err == nil {
+		t.Fatal("expected save error while path is a directory")
+	}
+	os.Remove(path)
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Lookup("qyn", 5); len(got) != 1 || got[0] != "庆余年" {
+		t.Errorf("loaded lookup = %v", got)
+	}
+}
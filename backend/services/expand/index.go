@@ -0,0 +1,230 @@
+package expand
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+
+	"ReelNest/services/matcher"
+	"ReelNest/services/zhconv"
+)
+
+// maxTitles 标题索引容量上限
+const maxTitles = 50000
+
+// entry 索引条目
+type entry struct {
+	Title    string `json:"title"`
+	Pinyin   string `json:"pinyin"`   // 全拼，无声调无分隔
+	Initials string `json:"initials"` // 拼音首字母
+}
+
+// Index 本地标题索引，用于将拼音或首字母映射为候选标题
+// 标题来自搜索与浏览结果，可持久化到磁盘
+type Index struct {
+	mu      sync.RWMutex
+	path    string
+	entries map[string]entry // 规范化标题 -> 条目
+	dirty   bool
+}
+
+// NewIndex 创建标题索引，path 非空时从该文件加载已有索引
+func NewIndex(path string) (*Index, error) {
+	idx := &Index{path: path, entries: make(map[string]entry)}
+	if path == "" {
+		return idx, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []entry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, e := range list {
+		if key := matcher.Normalize(e.Title); key != "" {
+			idx.entries[key] = e
+		}
+	}
+	return idx, nil
+}
+
+// Add 加入标题，已存在的标题忽略
+func (idx *Index) Add(titles ...string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, title := range titles {
+		title = zhconv.ToSimplified(strings.TrimSpace(title))
+		key := matcher.Normalize(title)
+		if key == "" {
+			continue
+		}
+		if _, ok := idx.entries[key]; ok || len(idx.entries) >= maxTitles {
+			continue
+		}
+		full, initials := Romanize(title)
+		if full == "" {
+			continue
+		}
+		idx.entries[key] = entry{Title: title, Pinyin: full, Initials: initials}
+		idx.dirty = true
+	}
+}
+
+// Len 索引中的标题数
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.entries)
+}
+
+// Lookup 按全拼或首字母前缀查找候选标题，完全匹配与较短标题优先
+func (idx *Index) Lookup(query string, limit int) []string {
+	q := compact(query)
+	if q == "" || limit <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		title string
+		rank  int
+	}
+	var candidates []candidate
+
+	idx.mu.RLock()
+	for _, e := range idx.entries {
+		rank := -1
+		switch {
+		case e.Pinyin == q, e.Initials == q:
+			rank = 0
+		case strings.HasPrefix(e.Pinyin, q):
+			rank = 1
+		case len(q) >= 2 && strings.HasPrefix(e.Initials, q):
+			rank = 2
+		}
+		if rank >= 0 {
+			candidates = append(candidates, candidate{e.Title, rank})
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		la, lb := len([]rune(a.title)), len([]rune(b.title))
+		if la != lb {
+			return la < lb
+		}
+		return a.title < b.title
+	})
+
+	result := make([]string, 0, min(limit, len(candidates)))
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		result = append(result, c.title)
+	}
+	return result
+}
+
+// Save 将索引写回磁盘，无变更时跳过；写入失败时保留变更标记，下次重试
+func (idx *Index) Save() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return nil
+	}
+	list := make([]entry, 0, len(idx.entries))
+	for _, e := range idx.entries {
+		list = append(list, e)
+	}
+	idx.dirty = false
+	idx.mu.Unlock()
+
+	if err := writeFile(idx.path, list); err != nil {
+		idx.mu.Lock()
+		idx.dirty = true
+		idx.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeFile 按标题排序写入，先写临时文件再重命名保证原子性
+func writeFile(path string, list []entry) error {
+	sort.Slice(list, func(i, j int) bool { return list[i].Title < list[j].Title })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// pinyinArgs 无声调、多音字取首个读音
+var pinyinArgs = pinyin.NewArgs()
+
+// Romanize 生成标题的全拼与首字母，字母数字原样保留
+func Romanize(title string) (full, initials string) {
+	var fb, ib strings.Builder
+	for _, r := range title {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			py := pinyin.SinglePinyin(r, pinyinArgs)
+			if len(py) == 0 || py[0] == "" {
+				continue
+			}
+			fb.WriteString(py[0])
+			ib.WriteByte(py[0][0])
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			r = unicode.ToLower(r)
+			fb.WriteRune(r)
+			ib.WriteRune(r)
+		}
+	}
+	return fb.String(), ib.String()
+}
+
+// compact 去除空白与分隔符并转小写
+func compact(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,202 @@
+package failover
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/linkcheck"
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+	"ReelNest/services/search"
+)
+
+// 排序权重
+const (
+	scoreExactTitle = 100
+	scoreYearMatch  = 20
+	scoreEpisodeNum = 15
+	scoreM3U8       = 10
+	scoreCheckOK    = 50
+	scorePreferred  = 30
+)
+
+// detailWorkers 同时补查详情的命中数
+const detailWorkers = 8
+
+// Query 备选地址查询条件
+type Query struct {
+	Title        string
+	Year         string
+	Episode      int // 从1开始的集数
+	Exclude      string
+	IncludeAdult bool
+	Check        bool
+	Preferred    []string // 用户常用站点，排序时优先
+}
+
+// Finder 跨源查找同一剧集的备选播放地址
+type Finder struct {
+	agg     *search.Aggregator
+	mac     *maccms.Client
+	checker *linkcheck.Checker
+}
+
+// NewFinder 创建查找器
+func NewFinder(agg *search.Aggregator, mac *maccms.Client, checker *linkcheck.Checker) *Finder {
+	return &Finder{agg: agg, mac: mac, checker: checker}
+}
+
+// Find 搜索所有启用站点，返回按得分排序的备选播放地址
+func (f *Finder) Find(ctx context.Context, q Query) []models.PlaybackAlternative {
+	sites := config.GetEnabledSites(q.IncludeAdult)
+	delete(sites, q.Exclude)
+
+	target := matcher.Key(q.Title)
+	hits := search.Hits(f.agg.Search(ctx, q.Title, sites))
+
+	var matched []search.Hit
+	for _, hit := range hits {
+		if matcher.Key(hit.Video.VodName) != target {
+			continue
+		}
+		if q.Year != "" && hit.Video.VodYear != "" && string(hit.Video.VodYear) != q.Year {
+			continue
+		}
+		matched = append(matched, hit)
+	}
+
+	// 部分命中需要补查详情，限制同时请求的数量
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	jobs := make(chan search.Hit)
+	alternatives := make([]models.PlaybackAlternative, 0)
+	for w := 0; w < detailWorkers && w < len(matched); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for hit := range jobs {
+				alt, ok := f.alternative(ctx, hit, q)
+				if !ok {
+					continue
+				}
+				mu.Lock()
+				alternatives = append(alternatives, alt)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, hit := range matched {
+		jobs <- hit
+	}
+	close(jobs)
+	wg.Wait()
+
+	if q.Check && len(alternatives) > 0 {
+		alternatives = f.check(ctx, alternatives)
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool {
+		if alternatives[i].Score != alternatives[j].Score {
+			return alternatives[i].Score > alternatives[j].Score
+		}
+		return alternatives[i].SourceCode < alternatives[j].SourceCode
+	})
+	return alternatives
+}
+
+// alternative 从命中结果中挑出目标剧集并打分
+func (f *Finder) alternative(ctx context.Context, hit search.Hit, q Query) (models.PlaybackAlternative, bool) {
+	video := hit.Video
+	episodes := video.Episodes()
+
+	// 搜索结果不含播放列表时补查详情
+	if len(episodes) == 0 {
+		detail, err := f.mac.Detail(ctx, hit.Site, string(video.VodID))
+		if err != nil {
+			return models.PlaybackAlternative{}, false
+		}
+		video = *detail
+		episodes = video.Episodes()
+	}
+
+	index, byNumber := pickEpisode(episodes, q.Episode)
+	if index < 0 {
+		return models.PlaybackAlternative{}, false
+	}
+
+	alt := models.PlaybackAlternative{
+		SourceCode:   hit.SiteKey,
+		SourceName:   hit.Site.Name,
+		VideoID:      string(video.VodID),
+		Title:        video.VodName,
+		Year:         string(video.VodYear),
+		EpisodeIndex: index,
+		EpisodeTitle: episodes[index].Title,
+		Url:          episodes[index].Url,
+	}
+
+	if strings.TrimSpace(video.VodName) == strings.TrimSpace(q.Title) {
+		alt.Score += scoreExactTitle
+	}
+	if q.Year != "" && alt.Year == q.Year {
+		alt.Score += scoreYearMatch
+	}
+	if byNumber {
+		alt.Score += scoreEpisodeNum
+	}
+	if strings.Contains(strings.ToLower(alt.Url), ".m3u8") {
+		alt.Score += scoreM3U8
+	}
+	if slices.Contains(q.Preferred, hit.SiteKey) {
+		alt.Score += scorePreferred
+	}
+	return alt, true
+}
+
+// pickEpisode 优先按标题中的集数匹配，否则按位置取，返回下标及是否按集数命中
+func pickEpisode(episodes []models.EpisodeInfo, episode int) (int, bool) {
+	if episode <= 0 {
+		episode = 1
+	}
+	for i, ep := range episodes {
+		if matcher.EpisodeNumber(ep.Title) == episode {
+			return i, true
+		}
+	}
+	if episode <= len(episodes) {
+		return episode - 1, false
+	}
+	return -1, false
+}
+
+// check 检测备选地址可用性，剔除确认不可播放的地址
+// 超时或无法检测的地址保留但不加分
+func (f *Finder) check(ctx context.Context, alternatives []models.PlaybackAlternative) []models.PlaybackAlternative {
+	episodes := make([]models.EpisodeInfo, len(alternatives))
+	for i, alt := range alternatives {
+		episodes[i] = models.EpisodeInfo{Title: alt.EpisodeTitle, Url: alt.Url}
+	}
+
+	results := f.checker.Check(ctx, episodes)
+	playable := alternatives[:0]
+	for i, alt := range alternatives {
+		result := results[i]
+		switch result.Status {
+		case linkcheck.StatusOK:
+			alt.Score += scoreCheckOK
+		case linkcheck.StatusTimeout, linkcheck.StatusUnsupported:
+		default:
+			continue
+		}
+		alt.Check = &result
+		playable = append(playable, alt)
+	}
+	return playable
+}
@@ -0,0 +1,234 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 订阅格式
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+)
+
+// Item 订阅中的一条内容
+type Item struct {
+	ID      string // 全局唯一标识，内容更新时随之变化，阅读器据此识别新条目
+	Title   string
+	Link    string
+	Summary string
+	Image   string
+	Updated time.Time
+}
+
+// Feed 订阅源
+type Feed struct {
+	Title       string
+	Link        string // 对应的网页或接口地址
+	Self        string // 订阅本身的地址
+	Description string
+	Items       []Item
+}
+
+// Updated 最近一条内容的更新时间，没有时间信息时为零值
+func (f *Feed) Updated() time.Time {
+	var latest time.Time
+	for _, it := range f.Items {
+		if it.Updated.After(latest) {
+			latest = it.Updated
+		}
+	}
+	return latest.Truncate(time.Second)
+}
+
+// ETag 由格式与各条内容的标识计算，内容不变时保持不变
+func (f *Feed) ETag(format string) string {
+	h := sha1.New()
+	h.Write([]byte(format + "\x00" + f.Title + "\x00"))
+	for _, it := range f.Items {
+		h.Write([]byte(it.ID + "\x00" + strconv.FormatInt(it.Updated.Unix(), 10) + "\x00"))
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// Render 按格式生成订阅内容，返回内容与 Content-Type
+func (f *Feed) Render(format string) ([]byte, string, error) {
+	if format == FormatAtom {
+		data, err := f.atom()
+		return data, "application/atom+xml; charset=utf-8", err
+	}
+	data, err := f.rss()
+	return data, "application/rss+xml; charset=utf-8", err
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Description string        `xml:"description,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+func (f *Feed) rss() ([]byte, error) {
+	doc := rssDoc{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			AtomLink:    atomLink{Href: f.Self, Rel: "self", Type: "application/rss+xml"},
+			Description: f.Description,
+			Generator:   "ReelNest",
+			Items:       make([]rssItem, 0, len(f.Items)),
+		},
+	}
+	if updated := f.Updated(); !updated.IsZero() {
+		doc.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	for _, it := range f.Items {
+		item := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        rssGUID{IsPermaLink: "false", Value: it.ID},
+			Description: it.Summary,
+		}
+		if !it.Updated.IsZero() {
+			item.PubDate = it.Updated.Format(time.RFC1123Z)
+		}
+		if it.Image != "" {
+			item.Enclosure = &rssEnclosure{URL: it.Image, Type: imageType(it.Image), Length: "0"}
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+	return marshal(doc)
+}
+
+type atomDoc struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Links   []atomLink `xml:"link"`
+	Summary string     `xml:"summary,omitempty"`
+}
+
+func (f *Feed) atom() ([]byte, error) {
+	updated := f.Updated()
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	doc := atomDoc{
+		ID:       f.Self,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.Self, Rel: "self", Type: "application/atom+xml"},
+			{Href: f.Link, Rel: "alternate"},
+		},
+		Generator: "ReelNest",
+		Entries:   make([]atomEntry, 0, len(f.Items)),
+	}
+	for _, it := range f.Items {
+		entryUpdated := it.Updated
+		if entryUpdated.IsZero() {
+			entryUpdated = updated
+		}
+		entry := atomEntry{
+			// Atom 要求 id 为 IRI
+			ID:      "urn:reelnest:" + it.ID,
+			Title:   it.Title,
+			Updated: entryUpdated.UTC().Format(time.RFC3339),
+			Summary: it.Summary,
+		}
+		if it.Link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: it.Link, Rel: "alternate"})
+		}
+		if it.Image != "" {
+			entry.Links = append(entry.Links, atomLink{Href: it.Image, Rel: "enclosure", Type: imageType(it.Image)})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshal(doc)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// imageType 按扩展名推断图片类型，无法判断时按 JPEG 处理
+func imageType(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return "image/jpeg"
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	case ".gif":
+		return "image/gif"
+	}
+	return "image/jpeg"
+}
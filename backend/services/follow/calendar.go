@@ -0,0 +1,106 @@
+package follow
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ReelNest/models"
+)
+
+// maxLineOctets iCalendar 每行的最大字节数(不含换行)，超出时折行
+const maxLineOctets = 75
+
+// Calendar 生成追剧更新的 iCalendar 日历，每集新剧集一个全天事件
+// detailURL 返回剧集的详情地址，为空时事件不带 URL
+func Calendar(name string, updates []models.EpisodeUpdate, detailURL func(source, id string) string) []byte {
+	var buf bytes.Buffer
+	w := &calendarWriter{buf: &buf}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//ReelNest//Follow Updates//ZH")
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	w.line("X-WR-CALNAME:" + escapeText(name))
+	w.line("X-PUBLISHED-TTL:PT1H")
+	w.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+
+	// 重新追剧后同一集可能再次记录，只保留最近的一次
+	seen := make(map[string]bool)
+	for _, u := range updates {
+		detected := time.UnixMilli(u.DetectedAt)
+		day := detected.Format("20060102")
+		next := detected.AddDate(0, 0, 1).Format("20060102")
+		link := detailURL(u.Source, u.VideoID)
+		for i, ep := range u.Episodes {
+			uid := fmt.Sprintf("%s-%d@reelnest", escapeUID(u.Source+"-"+u.VideoID), u.From+i+1)
+			if seen[uid] {
+				continue
+			}
+			seen[uid] = true
+			summary := strings.TrimSpace(u.Title + " " + ep)
+			desc := fmt.Sprintf("%s 更新至 %d 集", u.Title, u.To)
+			if link != "" {
+				desc += "\n" + link
+			}
+
+			w.line("BEGIN:VEVENT")
+			// 同一剧集的同一集在重新生成日历时保持相同的 UID
+			w.line("UID:" + uid)
+			w.line("DTSTAMP:" + stamp)
+			w.line("DTSTART;VALUE=DATE:" + day)
+			w.line("DTEND;VALUE=DATE:" + next)
+			w.line("SUMMARY:" + escapeText(summary))
+			w.line("DESCRIPTION:" + escapeText(desc))
+			if link != "" {
+				w.line("URL:" + link)
+			}
+			w.line("TRANSP:TRANSPARENT")
+			w.line("END:VEVENT")
+		}
+	}
+	w.line("END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// calendarWriter 按 RFC 5545 以 CRLF 结尾并折行写入内容行
+type calendarWriter struct {
+	buf *bytes.Buffer
+}
+
+func (w *calendarWriter) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		// 不在 UTF-8 字符中间折行
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.buf.WriteString(s[:cut])
+		w.buf.WriteString("\r\n ")
+		s = s[cut:]
+		// 续行以一个空格开头，占用一个字节
+		limit = maxLineOctets - 1
+	}
+	w.buf.WriteString(s)
+	w.buf.WriteString("\r\n")
+}
+
+// escapeText 转义 TEXT 类型的值
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// escapeUID 将 UID 中的特殊字符替换为下划线
+func escapeUID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x80 && (r == '-' || r == '.' || r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')) {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,111 @@
+package follow
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"ReelNest/models"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{`a\b`, `a\\b`},
+		{"a;b,c", `a\;b\,c`},
+		{"line1\r\nline2\nline3\r", `line1\nline2\nline3`},
+	}
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeUID(t *testing.T) {
+	if got := escapeUID("site-a_1.2/中 x@y"); got != "site-a_1.2___x_y" {
+		t.Errorf("escapeUID = %q", got)
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []string{
+		"SHORT",
+		strings.Repeat("a", maxLineOctets),
+		strings.Repeat("a", 200),
+		"SUMMARY:" + strings.Repeat("追剧更新", 30),
+		"X:" + strings.Repeat("é", 100),
+	}
+	for _, in := range tests {
+		var buf bytes.Buffer
+		w := &calendarWriter{buf: &buf}
+		w.line(in)
+		out := buf.String()
+
+		if !strings.HasSuffix(out, "\r\n") {
+			t.Errorf("line not terminated by CRLF: %q", out)
+		}
+		lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+		for i, l := range lines {
+			if len(l) > maxLineOctets {
+				t.Errorf("line %d has %d octets", i, len(l))
+			}
+			if i > 0 && !strings.HasPrefix(l, " ") {
+				t.Errorf("continuation line %d does not start with a space: %q", i, l)
+			}
+			if !utf8.ValidString(l) {
+				t.Errorf("line %d splits a UTF-8 character: %q", i, l)
+			}
+		}
+		// 展开折行后与原文一致
+		if got := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", ""); got != in {
+			t.Errorf("unfolded = %q, want %q", got, in)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	detected := time.Date(2024, 3, 5, 20, 0, 0, 0, time.Local).UnixMilli()
+	updates := []models.EpisodeUpdate{
+		{Source: "a", VideoID: "1/2", Title: "剧名, 第一季", Episodes: []string{"第3集", "第4集"}, From: 2, To: 4, DetectedAt: detected},
+		// 重新追剧后再次记录的同一集
+		{Source: "a", VideoID: "1/2", Title: "剧名, 第一季", Episodes: []string{"第4集"}, From: 3, To: 4, DetectedAt: detected},
+	}
+	out := string(Calendar("我的追剧", updates, func(source, id string) string {
+		return "http://x/detail?source=" + source + "&id=" + id
+	}))
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+
+	if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Errorf("calendar not wrapped in VCALENDAR: %q", out)
+	}
+	if n := strings.Count(out, "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("got %d events, want 2", n)
+	}
+	for _, want := range []string{
+		"X-WR-CALNAME:我的追剧\r\n",
+		"UID:a-1_2-3@reelnest\r\n",
+		"UID:a-1_2-4@reelnest\r\n",
+		"DTSTART;VALUE=DATE:20240305\r\n",
+		"DTEND;VALUE=DATE:20240306\r\n",
+		`SUMMARY:剧名\, 第一季 第3集` + "\r\n",
+		`DESCRIPTION:剧名\, 第一季 更新至 4 集\nhttp://x/detail?source=a&id=1/2` + "\r\n",
+		"URL:http://x/detail?source=a&id=1/2\r\n",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Errorf("calendar missing %q", want)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Error("calendar contains a bare LF")
+	}
+
+	empty := string(Calendar("空", nil, func(string, string) string { return "" }))
+	if strings.Contains(empty, "VEVENT") {
+		t.Errorf("empty calendar has events: %q", empty)
+	}
+}
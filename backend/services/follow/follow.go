@@ -0,0 +1,202 @@
+package follow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/maccms"
+)
+
+// WebhookEnv 追剧更新回调地址的环境变量，设置后发现新剧集时 POST 更新记录
+const WebhookEnv = "REELNEST_FOLLOW_WEBHOOK"
+
+// webhookTimeout 回调请求的超时
+const webhookTimeout = 10 * time.Second
+
+// Options 追剧检查选项
+type Options struct {
+	Interval    time.Duration // 检查间隔
+	Concurrency int           // 同时检查的剧集数
+	Webhook     string        // 发现新剧集时回调的地址，为空时不回调
+}
+
+// WebhookPayload 回调请求体
+type WebhookPayload struct {
+	User   string               `json:"user"`
+	Update models.EpisodeUpdate `json:"update"`
+}
+
+// Watcher 定期重新获取追的剧集详情，对比集数记录新剧集
+type Watcher struct {
+	store *Store
+	mac   *maccms.Client
+	hc    *client.Client
+	opts  Options
+
+	// running 同时只进行一次检查
+	running sync.Mutex
+}
+
+// NewWatcher 创建追剧检查任务
+func NewWatcher(store *Store, mac *maccms.Client, hc *client.Client, opts Options) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	return &Watcher{store: store, mac: mac, hc: hc, opts: opts}
+}
+
+// Run 定期检查追的剧集，直到 ctx 取消
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := w.Check(ctx); err != nil {
+				log.Printf("检查追剧更新失败: %v", err)
+			} else if n > 0 {
+				log.Printf("追剧检查发现 %d 条更新", n)
+			}
+		}
+	}
+}
+
+// Check 检查所有追的剧集，返回生成的更新记录数
+func (w *Watcher) Check(ctx context.Context) (int, error) {
+	w.running.Lock()
+	defer w.running.Unlock()
+
+	targets, err := w.store.Targets()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		count int
+	)
+	sem := make(chan struct{}, w.opts.Concurrency)
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t Target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			n := w.check(ctx, t)
+			mu.Lock()
+			count += n
+			mu.Unlock()
+		}(t)
+	}
+	wg.Wait()
+	return count, ctx.Err()
+}
+
+// check 检查单部剧集并为每个关注者记录结果
+func (w *Watcher) check(ctx context.Context, t Target) int {
+	f, episodes, err := w.Fetch(ctx, t.Source, t.VideoID)
+	if err != nil {
+		for _, user := range t.Users {
+			if err := w.store.RecordError(user, t.Source, t.VideoID, err); err != nil {
+				log.Printf("记录追剧检查结果失败: %v", err)
+			}
+		}
+		return 0
+	}
+
+	count := 0
+	for _, user := range t.Users {
+		event, err := w.store.Record(user, f, episodes)
+		if err != nil {
+			log.Printf("记录追剧检查结果失败: %v", err)
+			continue
+		}
+		if event != nil {
+			count++
+			w.notify(ctx, user, *event)
+		}
+	}
+	return count
+}
+
+// Fetch 获取剧集当前的信息与剧集标题，开始追剧时用于记录已有集数
+func (w *Watcher) Fetch(ctx context.Context, source, id string) (models.FollowedSeries, []string, error) {
+	site, ok := config.GetSite(source)
+	if !ok || site.Disabled || site.IsLive() {
+		return models.FollowedSeries{}, nil, fmt.Errorf("不支持的源: %s", source)
+	}
+	video, err := w.mac.Detail(ctx, site, id)
+	if err != nil {
+		return models.FollowedSeries{}, nil, err
+	}
+	info := video.Info(source, site)
+	f := models.FollowedSeries{
+		Source:   source,
+		VideoID:  id,
+		Title:    info.Title,
+		CoverUrl: info.CoverUrl,
+		Remarks:  info.Remarks,
+	}
+	return f, episodeTitles(video), nil
+}
+
+// notify 回调通知新剧集，失败只记录日志
+func (w *Watcher) notify(ctx context.Context, user string, event models.EpisodeUpdate) {
+	if w.opts.Webhook == "" {
+		return
+	}
+	body, err := json.Marshal(WebhookPayload{User: user, Update: event})
+	if err != nil {
+		return
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+	req.SetRequestURI(w.opts.Webhook)
+	req.SetMethod("POST")
+	req.Header.SetContentTypeBytes([]byte("application/json"))
+	req.SetBody(body)
+	if err := w.hc.Do(reqCtx, req, resp); err != nil {
+		log.Printf("追剧更新回调失败: %v", err)
+		return
+	}
+	if code := resp.StatusCode(); code >= 300 {
+		log.Printf("追剧更新回调失败: HTTP %d", code)
+	}
+}
+
+// episodeTitles 取剧集最多的播放线路的剧集标题，避免各线路更新进度不同造成误报
+func episodeTitles(video *maccms.Video) []string {
+	var longest []models.EpisodeInfo
+	for _, g := range video.PlayGroups() {
+		if len(g.Episodes) > len(longest) {
+			longest = g.Episodes
+		}
+	}
+	titles := make([]string, len(longest))
+	for i, ep := range longest {
+		titles[i] = ep.Title
+	}
+	return titles
+}
@@ -0,0 +1,411 @@
+package follow
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"ReelNest/models"
+)
+
+const (
+	// MaxFollows 每个用户最多追的剧集数
+	MaxFollows = 500
+	// maxEvents 每个用户保留的更新记录数，超出时删除最早的记录
+	maxEvents = 1000
+)
+
+// 存储桶名称，每个用户在其下各有一个子存储桶
+var (
+	bucketFollows = []byte("follows") // 键为 "源\x00视频ID"
+	bucketEvents  = []byte("events")  // 键为递增序号(大端)，按时间顺序排列
+	// 日历订阅令牌：令牌到用户、用户到令牌
+	bucketCalendarTokens = []byte("calendar_tokens")
+	bucketCalendarUsers  = []byte("calendar_users")
+)
+
+var (
+	// ErrNotFound 没有追这部剧
+	ErrNotFound = errors.New("没有追这部剧")
+	// ErrTooMany 追剧数量超过上限
+	ErrTooMany = errors.New("追剧数量超过上限")
+)
+
+// Target 需要检查更新的剧集及其关注者
+type Target struct {
+	Source  string
+	VideoID string
+	Users   []string
+}
+
+// Store 基于 bbolt 的追剧记录与更新记录
+type Store struct {
+	db *bolt.DB
+}
+
+// Open 打开(或创建)追剧数据库
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketFollows, bucketEvents, bucketCalendarTokens, bucketCalendarUsers} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Follow 追剧，已在追时更新剧集信息并保留关注时间
+func (s *Store) Follow(user string, f models.FollowedSeries) (*models.FollowedSeries, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(bucketFollows).CreateBucketIfNotExists([]byte(user))
+		if err != nil {
+			return err
+		}
+		k := key(f.Source, f.VideoID)
+		if data := b.Get(k); data != nil {
+			var cur models.FollowedSeries
+			if json.Unmarshal(data, &cur) == nil {
+				f.FollowedAt = cur.FollowedAt
+				f.UpdatedAt = cur.UpdatedAt
+				// 站点暂时少返回剧集时保留已记录的集数，避免之后把已看过的剧集当作更新
+				if cur.EpisodeCount > f.EpisodeCount {
+					f.EpisodeCount, f.LastEpisode = cur.EpisodeCount, cur.LastEpisode
+				}
+			}
+		} else if b.Stats().KeyN >= MaxFollows {
+			return ErrTooMany
+		}
+		if f.FollowedAt == 0 {
+			f.FollowedAt = time.Now().UnixMilli()
+		}
+		return putJSON(b, k, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Unfollow 取消追剧
+func (s *Store) Unfollow(user, source, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketFollows).Bucket([]byte(user))
+		if b == nil || b.Get(key(source, id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete(key(source, id))
+	})
+}
+
+// Follows 列出用户追的剧集，最近有更新的在前
+func (s *Store) Follows(user string) ([]models.FollowedSeries, error) {
+	list := []models.FollowedSeries{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketFollows).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var f models.FollowedSeries
+			if json.Unmarshal(v, &f) == nil {
+				list = append(list, f)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].UpdatedAt != list[j].UpdatedAt {
+			return list[i].UpdatedAt > list[j].UpdatedAt
+		}
+		return list[i].FollowedAt > list[j].FollowedAt
+	})
+	return list, err
+}
+
+// Targets 列出所有用户追的剧集，同一剧集只检查一次
+func (s *Store) Targets() ([]Target, error) {
+	index := make(map[string]int)
+	var targets []Target
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFollows).ForEachBucket(func(user []byte) error {
+			return tx.Bucket(bucketFollows).Bucket(user).ForEach(func(k, v []byte) error {
+				var f models.FollowedSeries
+				if json.Unmarshal(v, &f) != nil {
+					return nil
+				}
+				i, ok := index[string(k)]
+				if !ok {
+					i = len(targets)
+					index[string(k)] = i
+					targets = append(targets, Target{Source: f.Source, VideoID: f.VideoID})
+				}
+				targets[i].Users = append(targets[i].Users, string(user))
+				return nil
+			})
+		})
+	})
+	return targets, err
+}
+
+// Record 记录一次检查结果：集数超过已知最大集数时生成更新记录并返回，否则返回 nil
+// 已知集数只增不减，上游偶尔返回不完整或空的播放列表后，恢复正常时不会误报旧剧集
+func (s *Store) Record(user string, f models.FollowedSeries, episodes []string) (*models.EpisodeUpdate, error) {
+	var event *models.EpisodeUpdate
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketFollows).Bucket([]byte(user))
+		if b == nil {
+			return ErrNotFound
+		}
+		k := key(f.Source, f.VideoID)
+		data := b.Get(k)
+		if data == nil {
+			return ErrNotFound
+		}
+		var cur models.FollowedSeries
+		if err := json.Unmarshal(data, &cur); err != nil {
+			return err
+		}
+
+		now := time.Now().UnixMilli()
+		if len(episodes) > cur.EpisodeCount {
+			event = &models.EpisodeUpdate{
+				Source:     cur.Source,
+				VideoID:    cur.VideoID,
+				Title:      f.Title,
+				CoverUrl:   f.CoverUrl,
+				Episodes:   episodes[cur.EpisodeCount:],
+				From:       cur.EpisodeCount,
+				To:         len(episodes),
+				DetectedAt: now,
+			}
+			if err := addEvent(tx, user, event); err != nil {
+				return err
+			}
+			cur.UpdatedAt = now
+		}
+		cur.Title, cur.CoverUrl, cur.Remarks = f.Title, f.CoverUrl, f.Remarks
+		if len(episodes) > cur.EpisodeCount {
+			cur.EpisodeCount = len(episodes)
+			cur.LastEpisode = episodes[len(episodes)-1]
+		}
+		cur.CheckedAt = now
+		cur.LastError = ""
+		return putJSON(b, k, cur)
+	})
+	return event, err
+}
+
+// RecordError 记录检查失败的原因
+func (s *Store) RecordError(user, source, id string, checkErr error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketFollows).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		data := b.Get(key(source, id))
+		if data == nil {
+			return nil
+		}
+		var cur models.FollowedSeries
+		if err := json.Unmarshal(data, &cur); err != nil {
+			return err
+		}
+		cur.CheckedAt = time.Now().UnixMilli()
+		cur.LastError = checkErr.Error()
+		return putJSON(b, key(source, id), cur)
+	})
+}
+
+// Updates 列出更新记录，最新的在前；unread 为 true 时只列出未读记录
+func (s *Store) Updates(user string, unread bool, limit int) ([]models.EpisodeUpdate, int, error) {
+	list := []models.EpisodeUpdate{}
+	unreadCount := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketEvents).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e models.EpisodeUpdate
+			if json.Unmarshal(v, &e) != nil {
+				continue
+			}
+			if !e.Read {
+				unreadCount++
+			}
+			if (unread && e.Read) || (limit > 0 && len(list) >= limit) {
+				continue
+			}
+			list = append(list, e)
+		}
+		return nil
+	})
+	return list, unreadCount, err
+}
+
+// MarkRead 将更新记录标记为已读，ids 为空时全部标记，返回标记的条数
+func (s *Store) MarkRead(user string, ids []uint64) (int, error) {
+	want := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	marked := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketEvents).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		type change struct {
+			key  []byte
+			data []byte
+		}
+		var changes []change
+		err := b.ForEach(func(k, v []byte) error {
+			var e models.EpisodeUpdate
+			if json.Unmarshal(v, &e) != nil || e.Read || (len(want) > 0 && !want[e.ID]) {
+				return nil
+			}
+			e.Read = true
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, change{append([]byte(nil), k...), data})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			if err := b.Put(ch.key, ch.data); err != nil {
+				return err
+			}
+		}
+		marked = len(changes)
+		return nil
+	})
+	return marked, err
+}
+
+// CalendarToken 获取用户的日历订阅令牌，没有令牌或 rotate 为 true 时生成新令牌，旧令牌随即失效
+func (s *Store) CalendarToken(user string, rotate bool) (string, error) {
+	var token string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		users, tokens := tx.Bucket(bucketCalendarUsers), tx.Bucket(bucketCalendarTokens)
+		old := users.Get([]byte(user))
+		if old != nil && !rotate {
+			token = string(old)
+			return nil
+		}
+		if old != nil {
+			if err := tokens.Delete(old); err != nil {
+				return err
+			}
+		}
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(b)
+		if err := tokens.Put([]byte(token), []byte(user)); err != nil {
+			return err
+		}
+		return users.Put([]byte(user), []byte(token))
+	})
+	return token, err
+}
+
+// CalendarUser 查找日历订阅令牌对应的用户
+func (s *Store) CalendarUser(token string) (string, bool, error) {
+	var user string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		user = string(tx.Bucket(bucketCalendarTokens).Get([]byte(token)))
+		return nil
+	})
+	return user, user != "", err
+}
+
+// Purge 删除用户的追剧、更新记录与日历订阅令牌，用于删除用户
+func (s *Store) Purge(user string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketFollows, bucketEvents} {
+			err := tx.Bucket(name).DeleteBucket([]byte(user))
+			if err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+				return err
+			}
+		}
+		users := tx.Bucket(bucketCalendarUsers)
+		if token := users.Get([]byte(user)); token != nil {
+			if err := tx.Bucket(bucketCalendarTokens).Delete(token); err != nil {
+				return err
+			}
+		}
+		return users.Delete([]byte(user))
+	})
+}
+
+// addEvent 写入更新记录并删除超出上限的最早记录
+func addEvent(tx *bolt.Tx, user string, e *models.EpisodeUpdate) error {
+	b, err := tx.Bucket(bucketEvents).CreateBucketIfNotExists([]byte(user))
+	if err != nil {
+		return err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	// 统计信息只反映已提交的数据，需在本事务修改之前读取
+	for n := b.Stats().KeyN + 1 - maxEvents; n > 0; n-- {
+		first, _ := b.Cursor().First()
+		if first == nil {
+			break
+		}
+		if err := b.Delete(first); err != nil {
+			return err
+		}
+	}
+
+	e.ID = seq
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return putJSON(b, k, e)
+}
+
+func putJSON(b *bolt.Bucket, k []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, data)
+}
+
+func key(source, id string) []byte {
+	return []byte(source + "\x00" + id)
+}
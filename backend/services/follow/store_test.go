@@ -0,0 +1,53 @@
+package follow
+
+import (
+	"testing"
+
+	"ReelNest/internal/testutil"
+	"ReelNest/models"
+)
+
+func openStore(t *testing.T) *Store {
+	return testutil.OpenStore(t, "follow.db", Open)
+}
+
+func TestRefollowKeepsEpisodeCount(t *testing.T) {
+	s := openStore(t)
+	series := models.FollowedSeries{Source: "a", VideoID: "1", Title: "剧", EpisodeCount: 10, LastEpisode: "第10集"}
+	if _, err := s.Follow("u", series); err != nil {
+		t.Fatal(err)
+	}
+
+	// 站点暂时只返回 8 集时重新追剧，不应回退集数
+	series.EpisodeCount, series.LastEpisode = 8, "第8集"
+	f, err := s.Follow("u", series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.EpisodeCount != 10 || f.LastEpisode != "第10集" {
+		t.Errorf("refollow = %+v, want 10 episodes", f)
+	}
+	event, err := s.Record("u", series, []string{"第1集", "第2集", "第3集", "第4集", "第5集", "第6集", "第7集", "第8集", "第9集", "第10集"})
+	if err != nil || event != nil {
+		t.Errorf("seen episodes reported as new: %+v, %v", event, err)
+	}
+
+	series.EpisodeCount, series.LastEpisode = 12, "第12集"
+	if f, _ := s.Follow("u", series); f.EpisodeCount != 12 {
+		t.Errorf("refollow with more episodes = %d, want 12", f.EpisodeCount)
+	}
+}
+
+func TestCalendarUser(t *testing.T) {
+	s := openStore(t)
+	token, err := s.CalendarToken("u", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, ok, err := s.CalendarUser(token); err != nil || !ok || user != "u" {
+		t.Errorf("CalendarUser = %q, %v, %v", user, ok, err)
+	}
+	if _, ok, err := s.CalendarUser("missing"); err != nil || ok {
+		t.Errorf("unknown token = %v, %v", ok, err)
+	}
+}
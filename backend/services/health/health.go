@@ -0,0 +1,141 @@
+package health
+
+import (
+	"sync"
+	"time"
+
+	"ReelNest/models"
+)
+
+// 健康状态
+const (
+	StatusUnknown  = "unknown"
+	StatusHealthy  = "healthy"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+const (
+	// downAfter 连续失败达到该次数视为不可用
+	downAfter = 3
+	// latencyAlpha 延迟指数移动平均系数
+	latencyAlpha = 0.3
+	// slowLatency 超过该延迟视为慢站
+	slowLatency = 3 * time.Second
+)
+
+type siteStats struct {
+	successes           int
+	failures            int
+	consecutiveFailures int
+	latencyMs           float64
+	lastError           string
+	lastCheck           time.Time
+}
+
+// Tracker 根据实际请求结果统计站点健康状况
+type Tracker struct {
+	mu    sync.RWMutex
+	sites map[string]*siteStats
+}
+
+// NewTracker 创建健康统计器
+func NewTracker() *Tracker {
+	return &Tracker{sites: make(map[string]*siteStats)}
+}
+
+// Record 记录一次请求结果
+func (t *Tracker) Record(siteKey string, latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.sites[siteKey]
+	if !ok {
+		stats = &siteStats{}
+		t.sites[siteKey] = stats
+	}
+	stats.lastCheck = time.Now()
+
+	if err != nil {
+		stats.failures++
+		stats.consecutiveFailures++
+		stats.lastError = err.Error()
+		return
+	}
+
+	stats.successes++
+	stats.consecutiveFailures = 0
+	ms := float64(latency.Milliseconds())
+	if stats.latencyMs == 0 {
+		stats.latencyMs = ms
+	} else {
+		stats.latencyMs = latencyAlpha*ms + (1-latencyAlpha)*stats.latencyMs
+	}
+}
+
+// Get 获取站点健康状态
+func (t *Tracker) Get(siteKey string) models.SiteHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.snapshot(t.sites[siteKey])
+}
+
+// Score 站点健康得分 0-1，未知站点为 0.5
+func (t *Tracker) Score(siteKey string) float64 {
+	return t.Get(siteKey).Score
+}
+
+// Available 站点是否可用(未知或未连续失败)
+func (t *Tracker) Available(siteKey string) bool {
+	return t.Get(siteKey).Status != StatusDown
+}
+
+// Snapshot 获取所有已统计站点的健康状态
+func (t *Tracker) Snapshot() map[string]models.SiteHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make(map[string]models.SiteHealth, len(t.sites))
+	for key, stats := range t.sites {
+		result[key] = t.snapshot(stats)
+	}
+	return result
+}
+
+// snapshot 计算健康状态，调用方需持有读锁
+func (t *Tracker) snapshot(stats *siteStats) models.SiteHealth {
+	if stats == nil {
+		return models.SiteHealth{Status: StatusUnknown, Score: 0.5}
+	}
+
+	total := stats.successes + stats.failures
+	// 拉普拉斯平滑，避免少量样本时得分极端
+	rate := float64(stats.successes+1) / float64(total+2)
+
+	latencyFactor := 1.0
+	if stats.latencyMs > 0 {
+		latencyFactor = min(1, float64(slowLatency.Milliseconds())/stats.latencyMs)
+	}
+
+	h := models.SiteHealth{
+		Score:               rate * (0.5 + 0.5*latencyFactor),
+		AvgLatencyMs:        int(stats.latencyMs),
+		ConsecutiveFailures: stats.consecutiveFailures,
+		LastError:           stats.lastError,
+		LastCheck:           stats.lastCheck.Unix(),
+	}
+	if total > 0 {
+		h.SuccessRate = float64(stats.successes) / float64(total)
+	}
+
+	switch {
+	case stats.consecutiveFailures >= downAfter:
+		h.Status = StatusDown
+		h.Score = 0
+	case stats.consecutiveFailures > 0 || latencyFactor < 1:
+		h.Status = StatusDegraded
+	default:
+		h.Status = StatusHealthy
+	}
+	return h
+}
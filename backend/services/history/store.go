@@ -0,0 +1,353 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"ReelNest/models"
+)
+
+const (
+	// MaxEntries 每个用户保留的观看记录数，超出时删除最早的记录(保留删除标记)
+	MaxEntries = 1000
+	// finishedRatio 播放进度达到时长的该比例即视为看完
+	finishedRatio = 0.95
+	// tombstoneTTL 删除标记的保留时间，超过后其它设备不再能同步到删除
+	tombstoneTTL = 90 * 24 * time.Hour
+)
+
+// bucketUsers 每个用户一个子存储桶，键为 "源\x00视频ID"
+var bucketUsers = []byte("users")
+
+// ErrNotFound 没有该视频的观看记录
+var ErrNotFound = errors.New("没有观看记录")
+
+// Store 基于 bbolt 的观看记录
+type Store struct {
+	db *bolt.DB
+}
+
+// Open 打开(或创建)观看记录数据库
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketUsers)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Put 记录播放进度，返回保存后的记录
+func (s *Store) Put(user string, p models.WatchProgress) (models.WatchProgress, error) {
+	p.UpdatedAt = time.Now().UnixMilli()
+	p.Deleted = false
+	p.Finished = p.Finished || (p.Duration > 0 && p.Position >= p.Duration*finishedRatio)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := userBucket(tx, user)
+		if err != nil {
+			return err
+		}
+		if data := b.Get(key(p.Source, p.VideoID)); data != nil {
+			var cur models.WatchProgress
+			if json.Unmarshal(data, &cur) == nil {
+				keepInfo(&p, cur)
+			}
+		}
+		if err := put(b, &p); err != nil {
+			return err
+		}
+		return prune(b)
+	})
+	return p, err
+}
+
+// Merge 合并其它设备同步的记录，同一视频以更新时间较新的为准，返回被采用的条数
+func (s *Store) Merge(user string, entries []models.WatchProgress) (int, error) {
+	now := time.Now().UnixMilli()
+	applied := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := userBucket(tx, user)
+		if err != nil {
+			return err
+		}
+		for _, p := range entries {
+			if p.Source == "" || p.VideoID == "" || p.UpdatedAt <= 0 {
+				continue
+			}
+			// 设备时钟可能超前，不接受未来时间
+			updatedAt := p.UpdatedAt
+			p.UpdatedAt = min(p.UpdatedAt, now)
+			if data := b.Get(key(p.Source, p.VideoID)); data != nil {
+				var cur models.WatchProgress
+				if json.Unmarshal(data, &cur) == nil {
+					if cur.UpdatedAt >= updatedAt {
+						continue
+					}
+					// 截断到当前时间后可能与已有记录同一毫秒，保证采用的记录仍然更新
+					p.UpdatedAt = max(p.UpdatedAt, cur.UpdatedAt+1)
+					keepInfo(&p, cur)
+				}
+			}
+			if err := put(b, &p); err != nil {
+				return err
+			}
+			applied++
+		}
+		return prune(b)
+	})
+	return applied, err
+}
+
+// Get 获取视频的观看记录
+func (s *Store) Get(user, source, id string) (*models.WatchProgress, error) {
+	var p models.WatchProgress
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil {
+			return ErrNotFound
+		}
+		data := b.Get(key(source, id))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		if p.Deleted {
+			return ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List 按更新时间倒序分页列出观看记录，返回当前页与总数
+func (s *Store) List(user string, page, size int) ([]models.WatchProgress, int, error) {
+	all, err := s.collect(user, func(p models.WatchProgress) bool { return !p.Deleted })
+	if err != nil {
+		return nil, 0, err
+	}
+	if size <= 0 {
+		size = 20
+	}
+	// 超出总页数时直接返回空页，避免页码过大时计算起点溢出
+	if page = max(page, 1); len(all) == 0 || page-1 > (len(all)-1)/size {
+		return []models.WatchProgress{}, len(all), nil
+	}
+	start := (page - 1) * size
+	return all[start:min(start+size, len(all))], len(all), nil
+}
+
+// Continue 继续观看：未看完的视频，以及看完当前集但还有下一集的剧集
+func (s *Store) Continue(user string, limit int) ([]models.WatchProgress, error) {
+	list, err := s.collect(user, func(p models.WatchProgress) bool {
+		if p.Deleted {
+			return false
+		}
+		if p.Finished {
+			return p.Episode+1 < p.EpisodeCount
+		}
+		return p.Position > 0 || p.Episode > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// Since 列出变更序号大于 cursor 的记录(含删除标记)，用于设备间同步
+// 返回当前的变更序号，设备下次同步时提交；按服务端写入顺序判断变化，
+// 离线设备之后上传的较早记录同样会同步到其它设备
+func (s *Store) Since(user string, cursor uint64) ([]models.WatchProgress, uint64, error) {
+	list := []models.WatchProgress{}
+	var latest uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		latest = b.Sequence()
+		return b.ForEach(func(k, v []byte) error {
+			var p models.WatchProgress
+			if json.Unmarshal(v, &p) == nil && p.Seq > cursor {
+				list = append(list, p)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Seq < list[j].Seq })
+	return list, max(latest, cursor), err
+}
+
+// Delete 删除视频的观看记录，source 为空时清空该用户的全部记录
+// 删除以标记的形式保存，其它设备同步时一并删除
+func (s *Store) Delete(user, source, id string) error {
+	now := time.Now().UnixMilli()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		var marked []models.WatchProgress
+		err := b.ForEach(func(k, v []byte) error {
+			var p models.WatchProgress
+			if json.Unmarshal(v, &p) != nil || p.Deleted {
+				return nil
+			}
+			if source == "" || (p.Source == source && p.VideoID == id) {
+				marked = append(marked, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, p := range marked {
+			p = models.WatchProgress{Source: p.Source, VideoID: p.VideoID, UpdatedAt: now, Deleted: true}
+			if err := put(b, &p); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Purge 彻底删除用户的全部记录(不保留删除标记)，用于删除用户
+func (s *Store) Purge(user string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketUsers).DeleteBucket([]byte(user))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// collect 读取用户的记录，按更新时间倒序返回
+func (s *Store) collect(user string, match func(models.WatchProgress) bool) ([]models.WatchProgress, error) {
+	var list []models.WatchProgress
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var p models.WatchProgress
+			if json.Unmarshal(v, &p) == nil && match(p) {
+				list = append(list, p)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].UpdatedAt > list[j].UpdatedAt
+	})
+	return list, err
+}
+
+// keepInfo 同步的记录缺少标题等展示信息时沿用已有记录
+func keepInfo(p *models.WatchProgress, cur models.WatchProgress) {
+	if p.Deleted || cur.Deleted {
+		return
+	}
+	if p.Title == "" {
+		p.Title = cur.Title
+	}
+	if p.CoverUrl == "" {
+		p.CoverUrl = cur.CoverUrl
+	}
+	if p.EpisodeCount == 0 {
+		p.EpisodeCount = cur.EpisodeCount
+	}
+}
+
+// prune 删除过期的删除标记；记录超出上限时把最早的记录改为删除标记，
+// 其它设备同步时一并删除，不会再把这些记录合并回来
+func prune(b *bolt.Bucket) error {
+	now := time.Now()
+	expired := now.Add(-tombstoneTTL).UnixMilli()
+	var items []models.WatchProgress
+	var remove [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		var p models.WatchProgress
+		if err := json.Unmarshal(v, &p); err != nil {
+			remove = append(remove, append([]byte(nil), k...))
+			return nil
+		}
+		switch {
+		case p.Deleted && p.UpdatedAt < expired:
+			remove = append(remove, append([]byte(nil), k...))
+		case !p.Deleted:
+			items = append(items, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range remove {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	if len(items) <= MaxEntries {
+		return nil
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].UpdatedAt > items[j].UpdatedAt })
+	for _, p := range items[MaxEntries:] {
+		p = models.WatchProgress{Source: p.Source, VideoID: p.VideoID, UpdatedAt: now.UnixMilli(), Deleted: true}
+		if err := put(b, &p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put 保存记录并分配新的变更序号
+func put(b *bolt.Bucket, p *models.WatchProgress) error {
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	p.Seq = seq
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return b.Put(key(p.Source, p.VideoID), data)
+}
+
+func userBucket(tx *bolt.Tx, user string) (*bolt.Bucket, error) {
+	return tx.Bucket(bucketUsers).CreateBucketIfNotExists([]byte(user))
+}
+
+func key(source, id string) []byte {
+	return []byte(source + "\x00" + id)
+}
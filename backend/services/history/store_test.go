@@ -0,0 +1,202 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ReelNest/internal/testutil"
+	"ReelNest/models"
+)
+
+func openStore(t *testing.T) *Store {
+	return testutil.OpenStore(t, "history.db", Open)
+}
+
+// base 测试用的固定过去时间，避免依赖当前时钟
+var base = time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC).UnixMilli()
+
+func TestMerge(t *testing.T) {
+	s := openStore(t)
+	if _, err := s.Merge("u", []models.WatchProgress{{Source: "a", VideoID: "1", Title: "剧", EpisodeCount: 10, Episode: 2, UpdatedAt: base}}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UnixMilli()
+	applied, err := s.Merge("u", []models.WatchProgress{
+		{Source: "a", VideoID: "1", Episode: 1, UpdatedAt: base - 1000}, // 较旧，忽略
+		{Source: "a", VideoID: "1", Episode: 1, UpdatedAt: base},        // 同一时间，忽略
+		{Source: "b", VideoID: "2", Episode: 4, UpdatedAt: now + time.Hour.Milliseconds()},
+		{Source: "", VideoID: "3", UpdatedAt: base},  // 缺少源，忽略
+		{Source: "c", VideoID: "4", UpdatedAt: 0},    // 缺少时间，忽略
+		{Source: "a", VideoID: "5", UpdatedAt: base}, // 新记录
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 2 {
+		t.Errorf("applied = %d, want 2", applied)
+	}
+	if p, _ := s.Get("u", "a", "1"); p.Episode != 2 {
+		t.Errorf("older entry overwrote newer: %+v", p)
+	}
+	// 设备时钟超前时不接受未来时间
+	if p, _ := s.Get("u", "b", "2"); p == nil || p.UpdatedAt > time.Now().UnixMilli() {
+		t.Errorf("future timestamp kept: %+v", p)
+	}
+
+	// 较新的记录缺少展示信息时沿用已有记录
+	applied, err = s.Merge("u", []models.WatchProgress{{Source: "a", VideoID: "1", Episode: 3, UpdatedAt: base + 1}})
+	if err != nil || applied != 1 {
+		t.Fatalf("applied = %d, err = %v", applied, err)
+	}
+	if p, _ := s.Get("u", "a", "1"); p.Episode != 3 || p.Title != "剧" || p.EpisodeCount != 10 {
+		t.Errorf("merged = %+v", p)
+	}
+}
+
+func TestMergeClampedTie(t *testing.T) {
+	s := openStore(t)
+	// 已有记录时间被截断到当前时间，之后更超前的记录仍应被采用且时间递增
+	future := time.Now().Add(time.Hour).UnixMilli()
+	for episode := 1; episode <= 3; episode++ {
+		applied, err := s.Merge("u", []models.WatchProgress{{Source: "a", VideoID: "1", Episode: episode, UpdatedAt: future + int64(episode)}})
+		if err != nil || applied != 1 {
+			t.Fatalf("episode %d: applied = %d, err = %v", episode, applied, err)
+		}
+	}
+	if p, _ := s.Get("u", "a", "1"); p.Episode != 3 {
+		t.Errorf("merged = %+v", p)
+	}
+}
+
+func TestPruneKeepsTombstones(t *testing.T) {
+	s := openStore(t)
+	entries := make([]models.WatchProgress, MaxEntries+2)
+	for i := range entries {
+		entries[i] = models.WatchProgress{Source: "a", VideoID: fmt.Sprint(i), UpdatedAt: base + int64(i)}
+	}
+	if _, err := s.Merge("u", entries); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := s.List("u", 1, 1); total != MaxEntries {
+		t.Errorf("total = %d, want %d", total, MaxEntries)
+	}
+
+	// 超出上限的最早记录以删除标记同步给其它设备
+	list, _, _ := s.Since("u", 0)
+	var deleted []string
+	for _, p := range list {
+		if p.Deleted {
+			deleted = append(deleted, p.VideoID)
+		}
+	}
+	sort.Strings(deleted)
+	if strings.Join(deleted, ",") != "0,1" {
+		t.Errorf("tombstones = %v, want [0 1]", deleted)
+	}
+
+	// 其它设备再次上传被裁剪的记录时不会合并回来
+	if applied, _ := s.Merge("u", entries[:1]); applied != 0 {
+		t.Errorf("pruned entry merged back")
+	}
+}
+
+func TestSince(t *testing.T) {
+	s := openStore(t)
+
+	list, cursor, err := s.Since("u", 0)
+	if err != nil || len(list) != 0 || cursor != 0 {
+		t.Fatalf("empty user: %v, %d, %v", list, cursor, err)
+	}
+
+	s.Put("u", models.WatchProgress{Source: "a", VideoID: "1"})
+	s.Put("u", models.WatchProgress{Source: "a", VideoID: "2"})
+	list, cursor, _ = s.Since("u", 0)
+	if len(list) != 2 || cursor != 2 || list[0].VideoID != "1" || list[1].VideoID != "2" {
+		t.Fatalf("first sync: %+v, cursor %d", list, cursor)
+	}
+
+	// 离线设备之后上传的较早记录按写入顺序同步，不受设备时间影响
+	old := time.Now().Add(-24 * time.Hour).UnixMilli()
+	if _, err := s.Merge("u", []models.WatchProgress{{Source: "b", VideoID: "9", UpdatedAt: old}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("u", "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	list, next, _ := s.Since("u", cursor)
+	if len(list) != 2 || list[0].VideoID != "9" || list[1].VideoID != "1" || !list[1].Deleted {
+		t.Fatalf("incremental sync: %+v", list)
+	}
+	if next != cursor+2 {
+		t.Errorf("cursor = %d, want %d", next, cursor+2)
+	}
+
+	// 没有新变化时返回空列表与不变的序号；设备提交的序号超前时原样返回
+	if list, c, _ := s.Since("u", next); len(list) != 0 || c != next {
+		t.Errorf("no changes: %+v, %d", list, c)
+	}
+	if _, c, _ := s.Since("u", next+100); c != next+100 {
+		t.Errorf("cursor ahead = %d", c)
+	}
+	if _, c, _ := s.Since("other", 7); c != 7 {
+		t.Errorf("unknown user cursor = %d", c)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	s := openStore(t)
+	for i, id := range []string{"1", "2", "3", "4", "5"} {
+		s.Merge("u", []models.WatchProgress{{Source: "a", VideoID: id, UpdatedAt: base + int64(i)}})
+	}
+	s.Delete("u", "a", "5")
+
+	tests := []struct {
+		page, size int
+		want       []string
+	}{
+		{1, 2, []string{"4", "3"}},
+		{2, 2, []string{"2", "1"}},
+		{3, 2, nil},
+		{0, 3, []string{"4", "3", "2"}},
+		{-5, 10, []string{"4", "3", "2", "1"}},
+		{1, 0, []string{"4", "3", "2", "1"}},
+		{math.MaxInt, 20, nil},
+		{math.MaxInt / 20, 20, nil},
+		{2, math.MaxInt, nil},
+	}
+	for _, tt := range tests {
+		list, total, err := s.List("u", tt.page, tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != 4 {
+			t.Errorf("List(%d, %d) total = %d, want 4", tt.page, tt.size, total)
+		}
+		var ids []string
+		for _, p := range list {
+			ids = append(ids, p.VideoID)
+		}
+		if len(ids) != len(tt.want) || (len(ids) > 0 && ids[0] != tt.want[0]) || (len(ids) > 0 && ids[len(ids)-1] != tt.want[len(tt.want)-1]) {
+			t.Errorf("List(%d, %d) = %v, want %v", tt.page, tt.size, ids, tt.want)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	s := openStore(t)
+	s.Put("u", models.WatchProgress{Source: "a", VideoID: "1"})
+	if err := s.Purge("u"); err != nil {
+		t.Fatal(err)
+	}
+	if list, cursor, _ := s.Since("u", 0); len(list) != 0 || cursor != 0 {
+		t.Errorf("after purge: %+v, %d", list, cursor)
+	}
+	if err := s.Purge("missing"); err != nil {
+		t.Errorf("purge missing user: %v", err)
+	}
+}
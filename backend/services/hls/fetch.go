@@ -0,0 +1,72 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/utils"
+)
+
+const (
+	// maxPlaylistBytes 播放列表最大字节数
+	maxPlaylistBytes = 4 << 20
+	// maxNesting 主播放列表最大嵌套层数
+	maxNesting = 3
+)
+
+// StatusError 上游返回非 200 状态码
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("状态码: %d", e.Code)
+}
+
+// Fetch 下载并解析单个播放列表，跟随重定向，相对地址以最终地址为基准解析
+func Fetch(ctx context.Context, hc *client.Client, playlistURL string) (*Playlist, error) {
+	result, err := utils.Fetch(ctx, hc, playlistURL, "", maxPlaylistBytes)
+	if err != nil {
+		return nil, err
+	}
+	if result.StatusCode != 200 {
+		return nil, &StatusError{Code: result.StatusCode}
+	}
+	return Parse(result.Body, result.URL)
+}
+
+// Resolved 跟随主播放列表后的结果
+type Resolved struct {
+	Master   *Playlist // 主播放列表，直接为媒体播放列表时为 nil
+	Media    *Playlist
+	MediaURL string
+	Variant  *Variant // 选中的档位
+}
+
+// Resolve 下载播放列表，遇到主播放列表时跟随最高码率档位直到媒体播放列表
+func Resolve(ctx context.Context, hc *client.Client, playlistURL string) (*Resolved, error) {
+	res := &Resolved{MediaURL: playlistURL}
+	for depth := 0; depth <= maxNesting; depth++ {
+		pl, err := Fetch(ctx, hc, res.MediaURL)
+		if err != nil {
+			return nil, err
+		}
+		if !pl.Master {
+			res.Media = pl
+			return res, nil
+		}
+
+		variant, ok := pl.BestVariant()
+		if !ok {
+			return nil, fmt.Errorf("主播放列表没有可用档位")
+		}
+		if res.Master == nil {
+			res.Master = pl
+			res.Variant = &variant
+		}
+		res.MediaURL = variant.URI
+	}
+	return nil, fmt.Errorf("播放列表嵌套超过 %d 层", maxNesting)
+}
@@ -0,0 +1,172 @@
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNotPlaylist 内容不是 m3u8 播放列表
+var ErrNotPlaylist = errors.New("不是有效的 m3u8 播放列表")
+
+// Variant 主播放列表中的一个码率档位
+type Variant struct {
+	URI              string  `json:"uri"`
+	Bandwidth        int     `json:"bandwidth,omitempty"`
+	AverageBandwidth int     `json:"average_bandwidth,omitempty"`
+	Resolution       string  `json:"resolution,omitempty"`
+	Width            int     `json:"width,omitempty"`
+	Height           int     `json:"height,omitempty"`
+	Codecs           string  `json:"codecs,omitempty"`
+	FrameRate        float64 `json:"frame_rate,omitempty"`
+}
+
+// Segment 媒体播放列表中的一个分片
+type Segment struct {
+	URI      string
+	Duration float64
+}
+
+// Playlist 解析后的 m3u8 播放列表
+type Playlist struct {
+	Master         bool
+	Variants       []Variant
+	Segments       []Segment
+	TargetDuration float64
+	Duration       float64
+	Ended          bool
+}
+
+// Parse 解析 m3u8 内容，相对地址基于 baseURL 转为绝对地址
+func Parse(data []byte, baseURL string) (*Playlist, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("#EXTM3U")) {
+		return nil, ErrNotPlaylist
+	}
+
+	base, _ := url.Parse(baseURL)
+	pl := &Playlist{}
+
+	var pendingVariant *Variant
+	var pendingDuration float64
+	hasDuration := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attrs := ParseAttributes(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			pendingVariant = variantFromAttributes(attrs)
+			pl.Master = true
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value := strings.TrimPrefix(line, "#EXTINF:")
+			if i := strings.Index(value, ","); i >= 0 {
+				value = value[:i]
+			}
+			pendingDuration, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			hasDuration = true
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			pl.TargetDuration, _ = strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64)
+		case line == "#EXT-X-ENDLIST":
+			pl.Ended = true
+		case strings.HasPrefix(line, "#"):
+			// 其它标签暂不处理
+		default:
+			uri := resolve(base, line)
+			if pendingVariant != nil {
+				pendingVariant.URI = uri
+				pl.Variants = append(pl.Variants, *pendingVariant)
+				pendingVariant = nil
+			} else if hasDuration {
+				pl.Segments = append(pl.Segments, Segment{URI: uri, Duration: pendingDuration})
+				pl.Duration += pendingDuration
+				hasDuration = false
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+// BestVariant 返回带宽最高的档位
+func (p *Playlist) BestVariant() (Variant, bool) {
+	if len(p.Variants) == 0 {
+		return Variant{}, false
+	}
+	best := p.Variants[0]
+	for _, v := range p.Variants[1:] {
+		if v.Bandwidth > best.Bandwidth {
+			best = v
+		}
+	}
+	return best, true
+}
+
+// variantFromAttributes 从 EXT-X-STREAM-INF 属性构建档位
+func variantFromAttributes(attrs map[string]string) *Variant {
+	v := &Variant{
+		Codecs:     attrs["CODECS"],
+		Resolution: attrs["RESOLUTION"],
+	}
+	v.Bandwidth, _ = strconv.Atoi(attrs["BANDWIDTH"])
+	v.AverageBandwidth, _ = strconv.Atoi(attrs["AVERAGE-BANDWIDTH"])
+	v.FrameRate, _ = strconv.ParseFloat(attrs["FRAME-RATE"], 64)
+	if w, h, ok := strings.Cut(strings.ToLower(v.Resolution), "x"); ok {
+		v.Width, _ = strconv.Atoi(w)
+		v.Height, _ = strconv.Atoi(h)
+	}
+	return v
+}
+
+// ParseAttributes 解析 m3u8 属性列表，支持带引号且含逗号的值
+func ParseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for len(s) > 0 {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if comma := strings.IndexByte(s, ','); comma >= 0 {
+			value, s = s[:comma], s[comma:]
+		} else {
+			value, s = s, ""
+		}
+		attrs[strings.ToUpper(key)] = strings.TrimSpace(value)
+		s = strings.TrimPrefix(s, ",")
+	}
+	return attrs
+}
+
+// resolve 将相对地址转换为绝对地址
+func resolve(base *url.URL, ref string) string {
+	if base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,211 @@
+package hls
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/models"
+	"ReelNest/utils"
+)
+
+const (
+	// probeTimeout 单个播放地址的探测超时
+	probeTimeout = 15 * time.Second
+	// sampleBytes 测速时最多下载的字节数
+	sampleBytes = 4 << 20
+	// sampleDuration 测速时最长下载时间
+	sampleDuration = 5 * time.Second
+	// maxProbeCacheEntries 探测结果缓存上限
+	maxProbeCacheEntries = 5000
+)
+
+// 探测状态
+const (
+	ProbeOK    = "ok"
+	ProbeError = "error"
+)
+
+// Prober HLS 质量探测器
+type Prober struct {
+	hc      *client.Client
+	workers int
+	cache   *utils.TTLCache[models.QualityInfo]
+}
+
+// NewProber 创建探测器
+func NewProber(hc *client.Client, workers int, ttl time.Duration) *Prober {
+	if workers <= 0 {
+		workers = 4
+	}
+	return &Prober{
+		hc:      hc,
+		workers: workers,
+		cache:   utils.NewTTLCache[models.QualityInfo](ttl, maxProbeCacheEntries),
+	}
+}
+
+// ProbeEpisodes 并发探测剧集列表，结果写入各剧集的 Quality 字段
+// 已缓存的结果全部写入，未缓存的最多探测前 limit 集
+func (p *Prober) ProbeEpisodes(ctx context.Context, episodes []models.EpisodeInfo, limit int) {
+	var pending []int
+	for i := range episodes {
+		if quality, ok := p.cache.Get(episodes[i].Url); ok {
+			episodes[i].Quality = &quality
+		} else if len(pending) < limit {
+			pending = append(pending, i)
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < p.workers && w < len(pending); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				quality := p.Probe(ctx, episodes[i].Url)
+				episodes[i].Quality = &quality
+			}
+		}()
+	}
+
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// Probe 探测单个播放地址的码率档位、分辨率和服务器下载速度
+func (p *Prober) Probe(ctx context.Context, playURL string) models.QualityInfo {
+	if quality, ok := p.cache.Get(playURL); ok {
+		return quality
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	quality := p.probe(reqCtx, playURL)
+	if ctx.Err() == nil {
+		p.cache.Set(playURL, quality)
+	}
+	return quality
+}
+
+func (p *Prober) probe(ctx context.Context, playURL string) models.QualityInfo {
+	resolved, err := Resolve(ctx, p.hc, playURL)
+	if err != nil {
+		return models.QualityInfo{Status: ProbeError, Error: err.Error()}
+	}
+
+	quality := models.QualityInfo{
+		Status:   ProbeOK,
+		Segments: len(resolved.Media.Segments),
+		Duration: resolved.Media.Duration,
+	}
+
+	if resolved.Master != nil {
+		for _, v := range resolved.Master.Variants {
+			quality.Variants = append(quality.Variants, models.StreamVariant{
+				Resolution: v.Resolution,
+				Width:      v.Width,
+				Height:     v.Height,
+				Bandwidth:  v.Bandwidth,
+				Codecs:     v.Codecs,
+				FrameRate:  v.FrameRate,
+			})
+		}
+		selected := resolved.Variant
+		quality.Resolution = selected.Resolution
+		quality.Width = selected.Width
+		quality.Height = selected.Height
+		quality.Bandwidth = selected.Bandwidth
+		quality.Codecs = selected.Codecs
+	}
+	quality.Label = Label(quality.Height)
+
+	if len(resolved.Media.Segments) == 0 {
+		return quality
+	}
+
+	// 下载一个分片测速，整片下载完成时顺便估算码率
+	segment := resolved.Media.Segments[0]
+	n, elapsed, complete, err := p.sample(ctx, segment.URI)
+	if err != nil {
+		quality.Error = "分片测速失败: " + err.Error()
+		return quality
+	}
+	if elapsed > 0 {
+		quality.ThroughputKbps = int(float64(n) * 8 / elapsed.Seconds() / 1000)
+	}
+	if quality.Bandwidth == 0 && complete && segment.Duration > 0 {
+		quality.Bandwidth = int(float64(n) * 8 / segment.Duration)
+	}
+	return quality
+}
+
+// sample 下载分片开头部分，返回字节数、耗时以及是否完整下载
+func (p *Prober) sample(ctx context.Context, segmentURL string) (int64, time.Duration, bool, error) {
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(segmentURL)
+	req.SetMethod("GET")
+	utils.AddBrowserHeaders(req, "")
+
+	start := time.Now()
+	if _, err := utils.Do(ctx, p.hc, req, resp, nil); err != nil {
+		return 0, 0, false, err
+	}
+	defer resp.CloseBodyStream()
+
+	if resp.StatusCode() != 200 {
+		return 0, 0, false, &StatusError{Code: resp.StatusCode()}
+	}
+
+	body := resp.BodyStream()
+	if !resp.IsBodyStream() {
+		return int64(len(resp.Body())), time.Since(start), true, nil
+	}
+
+	buf := make([]byte, 32*1024)
+	var total int64
+	for total < sampleBytes && time.Since(start) < sampleDuration {
+		n, err := body.Read(buf)
+		total += int64(n)
+		if errors.Is(err, io.EOF) {
+			return total, time.Since(start), true, nil
+		}
+		if err != nil {
+			return total, time.Since(start), false, err
+		}
+	}
+	return total, time.Since(start), false, nil
+}
+
+// Label 根据高度返回清晰度标签
+func Label(height int) string {
+	switch {
+	case height >= 2160:
+		return "4K"
+	case height >= 1440:
+		return "2K"
+	case height >= 1080:
+		return "1080P"
+	case height >= 720:
+		return "720P"
+	case height >= 480:
+		return "480P"
+	case height > 0:
+		return "SD"
+	default:
+		return ""
+	}
+}
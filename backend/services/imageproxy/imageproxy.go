@@ -0,0 +1,253 @@
+package imageproxy
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // 注册 gif 解码器
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册 webp 解码器，部分站点封面为 webp
+)
+
+const (
+	// MaxSourceBytes 源图片最大字节数
+	MaxSourceBytes = 8 << 20
+	// MaxDimension 允许请求的最大宽高
+	MaxDimension = 1920
+	// DefaultQuality 默认JPEG质量
+	DefaultQuality = 85
+	// MaxSourcePixels 源图片最大像素数，防止解码超大图片耗尽内存
+	MaxSourcePixels = 40_000_000
+)
+
+// Options 图片处理参数
+type Options struct {
+	Width   int
+	Height  int
+	Format  string // jpeg、png，空表示保持原格式
+	Quality int
+}
+
+// IsPassthrough 是否无需重新编码
+func (o Options) IsPassthrough() bool {
+	return o.Width == 0 && o.Height == 0 && o.Format == ""
+}
+
+// Key 生成缓存键
+func (o Options) Key(rawURL string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d|%s|%d", rawURL, o.Width, o.Height, o.Format, o.Quality)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Normalize 规范化并校验参数
+func (o Options) Normalize() (Options, error) {
+	if o.Width < 0 || o.Height < 0 || o.Width > MaxDimension || o.Height > MaxDimension {
+		return o, fmt.Errorf("宽高需在 0-%d 之间", MaxDimension)
+	}
+	switch strings.ToLower(o.Format) {
+	case "":
+	case "jpg", "jpeg":
+		o.Format = "jpeg"
+	case "png":
+		o.Format = "png"
+	default:
+		return o, fmt.Errorf("不支持的图片格式: %s", o.Format)
+	}
+	if o.Quality <= 0 || o.Quality > 100 {
+		o.Quality = DefaultQuality
+	}
+	return o, nil
+}
+
+// Transform 按参数缩放并重新编码图片，返回数据和 Content-Type
+func Transform(data []byte, opt Options) ([]byte, string, error) {
+	cfg, srcFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("无法识别图片: %w", err)
+	}
+	if cfg.Width*cfg.Height > MaxSourcePixels {
+		return nil, "", fmt.Errorf("图片尺寸过大: %dx%d", cfg.Width, cfg.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("解码图片失败: %w", err)
+	}
+
+	dst := resize(src, opt.Width, opt.Height)
+
+	format := opt.Format
+	if format == "" {
+		// webp/gif 无法用标准库编码，统一转为 jpeg
+		format = srcFormat
+		if format != "png" {
+			format = "jpeg"
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		err = png.Encode(&buf, dst)
+	default:
+		err = jpeg.Encode(&buf, flatten(dst), &jpeg.Options{Quality: opt.Quality})
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("编码图片失败: %w", err)
+	}
+	return buf.Bytes(), "image/" + format, nil
+}
+
+// resize 等比缩放图片，只指定一边时按比例计算另一边，不放大
+func resize(src image.Image, width, height int) image.Image {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 || (width == 0 && height == 0) {
+		return src
+	}
+
+	switch {
+	case width == 0:
+		width = sw * height / sh
+	case height == 0:
+		height = sh * width / sw
+	default:
+		// 同时指定宽高时按较小比例适配，保持宽高比
+		if sw*height > sh*width {
+			height = sh * width / sw
+		} else {
+			width = sw * height / sh
+		}
+	}
+	if width >= sw || height >= sh || width <= 0 || height <= 0 {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+// flatten 将透明背景铺白，避免 jpeg 编码后变黑
+func flatten(src image.Image) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, b, src, b.Min, draw.Over)
+	return dst
+}
+
+// ContentTypeOf 根据数据内容识别图片类型
+func ContentTypeOf(data []byte) (string, bool) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", false
+	}
+	return "image/" + format, true
+}
+
+var (
+	placeholderCache = make(map[int][]byte)
+	placeholderLock  sync.Mutex
+)
+
+// Placeholder 生成指定宽度的灰色占位图(2:3 海报比例)
+func Placeholder(width int) []byte {
+	if width <= 0 {
+		width = 300
+	}
+
+	placeholderLock.Lock()
+	defer placeholderLock.Unlock()
+	if data, ok := placeholderCache[width]; ok {
+		return data
+	}
+
+	height := width * 3 / 2
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Gray{Y: 0xdd}, color.Gray{Y: 0xbb}})
+	// 中间画一条横带，便于与真实封面区分
+	for y := height * 9 / 20; y < height*11/20; y++ {
+		for x := 0; x < width; x++ {
+			img.SetColorIndex(x, y, 1)
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	placeholderCache[width] = buf.Bytes()
+	return buf.Bytes()
+}
+
+// Cache 图片磁盘缓存
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewCache 创建磁盘缓存，ttl<=0 表示永不过期
+func NewCache(dir string, ttl time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建图片缓存目录失败: %w", err)
+	}
+	return &Cache{dir: dir, ttl: ttl}, nil
+}
+
+// path 缓存文件路径，按键前两位分目录避免单目录文件过多
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Get 读取缓存，返回数据和 Content-Type
+func (c *Cache) Get(key string) ([]byte, string, bool) {
+	p := c.path(key)
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, "", false
+	}
+	if c.ttl > 0 && time.Since(info.ModTime()) > c.ttl {
+		_ = os.Remove(p)
+		return nil, "", false
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, "", false
+	}
+	contentType, ok := ContentTypeOf(data)
+	if !ok {
+		return nil, "", false
+	}
+	return data, contentType, true
+}
+
+// Put 写入缓存，先写临时文件再重命名保证原子性
+func (c *Cache) Put(key string, data []byte) error {
+	p := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
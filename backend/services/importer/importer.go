@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/services/maccms"
+)
+
+const (
+	// probeTimeout 单个站点探测超时
+	probeTimeout = 8 * time.Second
+	// probeWorkers 并发探测的站点数
+	probeWorkers = 8
+)
+
+// 导入结果状态
+const (
+	StatusAdded     = "added"
+	StatusDuplicate = "duplicate"
+	StatusInvalid   = "invalid"
+)
+
+// Options 导入选项
+type Options struct {
+	Probe  bool // 探测站点接口，不可用的站点以禁用状态导入
+	DryRun bool // 只返回导入结果，不写入配置
+}
+
+// Entry 单个站点的导入结果
+type Entry struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	Api       string `json:"api"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+	Probed    bool   `json:"probed,omitempty"`
+	Reachable bool   `json:"reachable,omitempty"`
+	Disabled  bool   `json:"disabled,omitempty"`
+}
+
+// Report 导入报告
+type Report struct {
+	Format     string  `json:"format"`
+	Added      int     `json:"added"`
+	Duplicates int     `json:"duplicates"`
+	Invalid    int     `json:"invalid"`
+	DryRun     bool    `json:"dry_run"`
+	Entries    []Entry `json:"entries"`
+}
+
+// Importer 站点列表导入器
+type Importer struct {
+	mac *maccms.Client
+}
+
+// NewImporter 创建站点列表导入器
+func NewImporter(mac *maccms.Client) *Importer {
+	return &Importer{mac: mac}
+}
+
+// Import 解析站点列表并合并到配置文件，按接口主机名去重
+func (im *Importer) Import(ctx context.Context, data []byte, opts Options) (*Report, error) {
+	candidates, format, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := config.GetAllSites()
+	hosts := make(map[string]string, len(existing))
+	for key, site := range existing {
+		hosts[Host(site.Api)] = key
+	}
+
+	report := &Report{Format: format, DryRun: opts.DryRun, Entries: make([]Entry, 0, len(candidates))}
+	added := make(map[string]config.Site)
+	var addedKeys []string
+	for _, c := range candidates {
+		entry := Entry{Name: c.Site.Name, Api: c.Site.Api}
+
+		api, err := NormalizeAPI(c.Site.Api)
+		if err != nil {
+			entry.Status, entry.Reason = StatusInvalid, err.Error()
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+		entry.Api = api
+
+		host := Host(api)
+		if key, ok := hosts[host]; ok {
+			entry.Key = key
+			entry.Status, entry.Reason = StatusDuplicate, "与站点 "+key+" 接口主机相同"
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+
+		key := uniqueKey(siteKey(c.Key, api), existing, added)
+		site := c.Site
+		site.Api = api
+		if site.Name == "" {
+			site.Name = key
+		}
+		hosts[host] = key
+		added[key] = site
+		addedKeys = append(addedKeys, key)
+
+		entry.Key, entry.Name, entry.Status = key, site.Name, StatusAdded
+		report.Entries = append(report.Entries, entry)
+	}
+
+	if opts.Probe {
+		reachable := im.probe(ctx, added)
+		for i := range report.Entries {
+			e := &report.Entries[i]
+			if e.Status != StatusAdded {
+				continue
+			}
+			e.Probed = true
+			if err := reachable[e.Key]; err != nil {
+				e.Reason = err.Error()
+				e.Disabled = true
+				site := added[e.Key]
+				site.Disabled = true
+				added[e.Key] = site
+			} else {
+				e.Reachable = true
+			}
+		}
+	}
+
+	for _, e := range report.Entries {
+		switch e.Status {
+		case StatusAdded:
+			report.Added++
+		case StatusDuplicate:
+			report.Duplicates++
+		case StatusInvalid:
+			report.Invalid++
+		}
+	}
+
+	if !opts.DryRun && len(addedKeys) > 0 {
+		if err := config.MergeSites(added); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// probe 并发请求各站点分类接口，返回每个站点的错误(可用时为 nil)
+func (im *Importer) probe(ctx context.Context, sites map[string]config.Site) map[string]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, probeWorkers)
+		results = make(map[string]error, len(sites))
+	)
+	for key, site := range sites {
+		wg.Add(1)
+		go func(key string, site config.Site) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			reqCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+			_, err := im.mac.Categories(reqCtx, site)
+
+			mu.Lock()
+			results[key] = err
+			mu.Unlock()
+		}(key, site)
+	}
+	wg.Wait()
+	return results
+}
+
+// uniqueKey 站点标识冲突时追加数字后缀
+func uniqueKey(key string, existing, added map[string]config.Site) string {
+	candidate := key
+	for i := 2; ; i++ {
+		_, inExisting := existing[candidate]
+		_, inAdded := added[candidate]
+		if !inExisting && !inAdded {
+			return candidate
+		}
+		candidate = key + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,325 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ReelNest/config"
+)
+
+// 站点列表格式
+const (
+	FormatLibreTV = "libretv" // LibreTV API_SITES 或本项目 api_sites.json
+	FormatTVBox   = "tvbox"   // TVBox JSON 配置
+)
+
+// ErrUnknownFormat 无法识别的站点列表格式
+var ErrUnknownFormat = errors.New("无法识别的站点列表格式")
+
+// apiSuffixRegex 站点地址中的 MacCMS 接口路径，导入时去掉，由客户端统一拼接
+var apiSuffixRegex = regexp.MustCompile(`(?i)/?api\.php/provide/vod(/at/(xml|json))?/?$`)
+
+// Candidate 待导入的站点
+type Candidate struct {
+	Key  string
+	Site config.Site
+}
+
+// libreSite LibreTV 站点写法
+type libreSite struct {
+	API    string `json:"api"`
+	Name   string `json:"name"`
+	Detail string `json:"detail"`
+	Adult  bool   `json:"adult"`
+}
+
+// tvboxConfig TVBox 配置中与站点相关的部分
+type tvboxConfig struct {
+	Sites []struct {
+		Key  string          `json:"key"`
+		Name string          `json:"name"`
+		Type json.RawMessage `json:"type"`
+		API  string          `json:"api"`
+	} `json:"sites"`
+}
+
+// Parse 识别格式并解析站点列表，保持原有顺序
+func Parse(data []byte) ([]Candidate, string, error) {
+	text := string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+
+	// LibreTV 的 config.js 中以 API_SITES = {...} 定义
+	if i := strings.Index(text, "API_SITES"); i >= 0 {
+		obj, ok := extractObject(text[i:])
+		if !ok {
+			return nil, "", fmt.Errorf("%w: 未找到 API_SITES 对象", ErrUnknownFormat)
+		}
+		text = obj
+	}
+
+	raw, err := jsToJSON(text)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, "", ErrUnknownFormat
+	}
+	if _, ok := probe["sites"]; ok {
+		var cfg tvboxConfig
+		if err := json.Unmarshal(raw, &cfg); err == nil {
+			return parseTVBox(cfg), FormatTVBox, nil
+		}
+	}
+
+	keys, err := objectKeys(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	var candidates []Candidate
+	for _, key := range keys {
+		var s libreSite
+		if err := json.Unmarshal(probe[key], &s); err != nil || s.API == "" {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Key: key,
+			Site: config.Site{
+				Api:    s.API,
+				Name:   s.Name,
+				Detail: s.Detail,
+				Adult:  s.Adult,
+			},
+		})
+	}
+	if len(candidates) == 0 {
+		return nil, "", ErrUnknownFormat
+	}
+	return candidates, FormatLibreTV, nil
+}
+
+// parseTVBox 只导入 MacCMS 接口站点(type 0/1)，爬虫类站点无法使用
+func parseTVBox(cfg tvboxConfig) []Candidate {
+	var candidates []Candidate
+	for _, s := range cfg.Sites {
+		typ := strings.Trim(string(s.Type), `"`)
+		if typ != "0" && typ != "1" {
+			continue
+		}
+		if !strings.HasPrefix(s.API, "http") {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Key:  s.Key,
+			Site: config.Site{Api: s.API, Name: s.Name},
+		})
+	}
+	return candidates
+}
+
+// NormalizeAPI 规范化站点地址：去掉查询参数与 MacCMS 接口路径
+func NormalizeAPI(api string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(api))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("无效的站点地址: %s", api)
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.Path = apiSuffixRegex.ReplaceAllString(u.Path, "")
+	u.Path = strings.TrimRight(u.Path, "/")
+	return u.String(), nil
+}
+
+// Host 站点地址的主机名，用于判断重复站点
+func Host(api string) string {
+	u, err := url.Parse(strings.TrimSpace(api))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+}
+
+// siteKey 生成站点标识，只保留小写字母与数字
+// 虚拟源以 config.KeySep 分隔站点标识与视频 ID，因此站点标识中不能含该分隔符
+func siteKey(key, api string) string {
+	clean := func(s string) string {
+		var b strings.Builder
+		for _, r := range strings.ToLower(s) {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				b.WriteRune(r)
+			}
+		}
+		return b.String()
+	}
+	if k := clean(key); k != "" {
+		return k
+	}
+	// 中文等标识退回到主机名的第一段
+	host := Host(api)
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		host = host[:i]
+	}
+	if k := clean(host); k != "" {
+		return k
+	}
+	return "site"
+}
+
+// extractObject 提取文本中第一个完整的 {...} 对象
+func extractObject(text string) (string, bool) {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", false
+	}
+	depth := 0
+	var quote byte
+	for i := start; i < len(text); i++ {
+		ch := text[i]
+		if quote != 0 {
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '"', '\'', '`':
+			quote = ch
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsToJSON 将 JS 对象字面量转换为 JSON：
+// 支持注释、单引号字符串、未加引号的键与末尾逗号
+func jsToJSON(text string) ([]byte, error) {
+	var out bytes.Buffer
+	src := []rune(text)
+	n := len(src)
+
+	for i := 0; i < n; i++ {
+		ch := src[i]
+		switch {
+		case ch == '/' && i+1 < n && src[i+1] == '/':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < n && src[i+1] == '*':
+			i += 2
+			for i+1 < n && !(src[i] == '*' && src[i+1] == '/') {
+				i++
+			}
+			i++
+		case ch == '"' || ch == '\'' || ch == '`':
+			var s strings.Builder
+			i++
+			for ; i < n && src[i] != ch; i++ {
+				if src[i] == '\\' && i+1 < n {
+					i++
+					switch src[i] {
+					case 'n':
+						s.WriteRune('\n')
+					case 't':
+						s.WriteRune('\t')
+					case 'r':
+						s.WriteRune('\r')
+					case 'u':
+						if i+4 < n {
+							if code, err := strconv.ParseUint(string(src[i+1:i+5]), 16, 32); err == nil {
+								s.WriteRune(rune(code))
+								i += 4
+								continue
+							}
+						}
+						s.WriteRune('u')
+					default:
+						s.WriteRune(src[i])
+					}
+					continue
+				}
+				s.WriteRune(src[i])
+			}
+			if i >= n {
+				return nil, fmt.Errorf("%w: 字符串未闭合", ErrUnknownFormat)
+			}
+			quoted, _ := json.Marshal(s.String())
+			out.Write(quoted)
+		case ch == '}' || ch == ']':
+			trimTrailingComma(&out)
+			out.WriteRune(ch)
+		case ch == '_' || ch == '$' || unicode.IsLetter(ch):
+			j := i
+			for j < n && (src[j] == '_' || src[j] == '$' || unicode.IsLetter(src[j]) || unicode.IsDigit(src[j])) {
+				j++
+			}
+			word := string(src[i:j])
+			k := j
+			for k < n && unicode.IsSpace(src[k]) {
+				k++
+			}
+			switch {
+			case k < n && src[k] == ':':
+				quoted, _ := json.Marshal(word)
+				out.Write(quoted)
+			case word == "true" || word == "false" || word == "null":
+				out.WriteString(word)
+			default:
+				out.WriteString("null")
+			}
+			i = j - 1
+		default:
+			out.WriteRune(ch)
+		}
+	}
+	// 去掉末尾的分号等
+	return bytes.TrimRight(bytes.TrimSpace(out.Bytes()), ";"), nil
+}
+
+// trimTrailingComma 去掉输出末尾(忽略空白)的逗号
+func trimTrailingComma(out *bytes.Buffer) {
+	b := out.Bytes()
+	i := len(b) - 1
+	for i >= 0 && (b[i] == ' ' || b[i] == '\n' || b[i] == '\r' || b[i] == '\t') {
+		i--
+	}
+	if i >= 0 && b[i] == ',' {
+		out.Truncate(i)
+	}
+}
+
+// objectKeys 按出现顺序返回 JSON 对象的顶层键
+func objectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, ErrUnknownFormat
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		keys = append(keys, key)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
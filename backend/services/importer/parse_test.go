@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestJSToJSON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"json", `{"a": 1}`, `{"a": 1}`},
+		{"unquoted keys", `{a: 1, $b_2: true}`, `{"a": 1, "$b_2": true}`},
+		{"single quotes", `{'a': 'it\'s'}`, `{"a": "it's"}`},
+		{"template string", "{a: `x`}", `{"a": "x"}`},
+		{"comments", "{\n// line\na: 1, /* block */ b: 2\n}", "{\n\"a\": 1,  \"b\": 2\n}"},
+		{"trailing commas", `{a: [1, 2, ], b: {c: 3,},}`, `{"a": [1, 2], "b": {"c": 3}}`},
+		{"escapes", `{a: '中\n'}`, `{"a": "中\n"}`},
+		{"slash in string", `{a: 'http://x.com/api'}`, `{"a": "http://x.com/api"}`},
+		{"identifier value", `{a: someVar, b: null};`, `{"a": null, "b": null}`},
+	}
+	for _, tt := range tests {
+		got, err := jsToJSON(tt.in)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+		if !json.Valid(got) {
+			t.Errorf("%s: invalid JSON %s", tt.name, got)
+		}
+	}
+
+	if _, err := jsToJSON(`{a: 'open}`); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("unterminated string: err = %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		format string
+		keys   []string
+		apis   []string
+	}{
+		{
+			name: "libretv config.js",
+			in: `const PROXY_URL = '/proxy/';
+const API_SITES = {
+    heimuer: { api: 'https://json.heimuer.xyz', name: '黑木耳', detail: 'https://heimuer.tv' },
+    // 注释掉的站点
+    ckzy: { api: "https://www.ckzy1.com", name: "CK资源", adult: true, },
+};
+const OTHER = { x: 1 };`,
+			format: FormatLibreTV,
+			keys:   []string{"heimuer", "ckzy"},
+			apis:   []string{"https://json.heimuer.xyz", "https://www.ckzy1.com"},
+		},
+		{
+			name:   "api_sites.json keeps order",
+			in:     "\xef\xbb\xbf" + `{"zz": {"api": "https://z.com", "name": "Z"}, "aa": {"api": "https://a.com", "name": "A"}, "bad": {"name": "no api"}}`,
+			format: FormatLibreTV,
+			keys:   []string{"zz", "aa"},
+			apis:   []string{"https://z.com", "https://a.com"},
+		},
+		{
+			name: "tvbox",
+			in: `{"spider": "x.jar", "sites": [
+				{"key": "a", "name": "A", "type": 1, "api": "https://a.com/api.php/provide/vod"},
+				{"key": "b", "name": "B", "type": "0", "api": "http://b.com/xml"},
+				{"key": "c", "name": "C", "type": 3, "api": "csp_Spider"},
+				{"key": "d", "name": "D", "type": 1, "api": "csp_NotHTTP"}
+			]}`,
+			format: FormatTVBox,
+			keys:   []string{"a", "b"},
+			apis:   []string{"https://a.com/api.php/provide/vod", "http://b.com/xml"},
+		},
+	}
+	for _, tt := range tests {
+		got, format, err := Parse([]byte(tt.in))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if format != tt.format {
+			t.Errorf("%s: format = %q, want %q", tt.name, format, tt.format)
+		}
+		var keys, apis []string
+		for _, c := range got {
+			keys = append(keys, c.Key)
+			apis = append(apis, c.Site.Api)
+		}
+		if !reflect.DeepEqual(keys, tt.keys) || !reflect.DeepEqual(apis, tt.apis) {
+			t.Errorf("%s: got keys %v apis %v, want %v %v", tt.name, keys, apis, tt.keys, tt.apis)
+		}
+	}
+
+	for _, in := range []string{``, `[1, 2]`, `{"a": 1}`, `API_SITES = `} {
+		if _, _, err := Parse([]byte(in)); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("Parse(%q) err = %v, want ErrUnknownFormat", in, err)
+		}
+	}
+}
+
+func TestNormalizeAPI(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"https://a.com/api.php/provide/vod/", "https://a.com", true},
+		{"https://a.com/api.php/provide/vod/at/json?ac=list", "https://a.com", true},
+		{" http://a.com/sub/ ", "http://a.com/sub", true},
+		{"ftp://a.com", "", false},
+		{"a.com", "", false},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeAPI(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeAPI(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestSiteKey(t *testing.T) {
+	tests := []struct {
+		key, api, want string
+	}{
+		{"Hei_Mu-Er", "https://x.com", "heimuer"},
+		{"黑木耳", "https://www.heimuer.tv", "heimuer"},
+		{"", "https://json.abc.com", "json"},
+		{"中文", "", "site"},
+	}
+	for _, tt := range tests {
+		if got := siteKey(tt.key, tt.api); got != tt.want {
+			t.Errorf("siteKey(%q, %q) = %q, want %q", tt.key, tt.api, got, tt.want)
+		}
+	}
+}
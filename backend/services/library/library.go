@@ -0,0 +1,145 @@
+package library
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/services/maccms"
+	"ReelNest/utils"
+)
+
+// pageSize 列表每页条数，与常见 MacCMS 站点一致
+const pageSize = 20
+
+// FilePath 本地视频与海报的访问地址前缀，由本服务读取文件
+const FilePath = "/api/library/file"
+
+// entry 已扫描的站点片库
+type entry struct {
+	lib       *library
+	scannedAt time.Time
+}
+
+// Library 本地片库数据来源：扫描本地目录生成片库，按 MacCMS 接口参数查询
+type Library struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+	// scanning 每个站点同时只进行一次扫描
+	scanning map[string]*sync.Mutex
+}
+
+// NewLibrary 创建本地片库数据来源，ttl 为重新扫描目录的间隔
+func NewLibrary(ttl time.Duration) *Library {
+	return &Library{
+		ttl:      ttl,
+		entries:  make(map[string]entry),
+		scanning: make(map[string]*sync.Mutex),
+	}
+}
+
+// Fetch 实现 maccms.Backend，支持 ac=list 与 ac=videolist/detail 的 ids、wd、t、h、pg 参数
+func (l *Library) Fetch(ctx context.Context, site config.Site, params url.Values) (*maccms.Response, error) {
+	key, ok := siteKey(site)
+	if !ok {
+		return nil, maccms.ErrNotFound
+	}
+	lib, err := l.library(ctx, key, site)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Get("ac") == "list" {
+		return &maccms.Response{Code: 1, Msg: "数据列表", Page: 1, Limit: pageSize, Class: classes}, nil
+	}
+	return maccms.Query(lib.videos, params, pageSize), nil
+}
+
+// Resolve 检查 "序号/相对路径" 并返回所在目录与以 "/" 分隔的相对路径，只允许访问站点目录中的视频与图片
+func (l *Library) Resolve(site config.Site, p string) (string, string, bool) {
+	root, rel, ok := relPath(p)
+	if !ok || !(utils.IsVideoFile(rel) || utils.IsImageFile(rel)) {
+		return "", "", false
+	}
+	roots := Roots(site)
+	if root >= len(roots) {
+		return "", "", false
+	}
+	info, err := os.Stat(filepath.Join(roots[root], filepath.FromSlash(rel)))
+	if err != nil || info.IsDir() {
+		return "", "", false
+	}
+	return roots[root], rel, true
+}
+
+// Roots 站点配置的本地目录，多个目录以系统路径列表分隔符分隔，相对路径基于配置文件目录
+func Roots(site config.Site) []string {
+	var roots []string
+	for _, dir := range filepath.SplitList(site.Api) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			roots = append(roots, config.ResolvePath(dir))
+		}
+	}
+	return roots
+}
+
+// library 获取站点片库，过期时重新扫描，扫描失败时继续使用旧片库
+func (l *Library) library(ctx context.Context, key string, site config.Site) (*library, error) {
+	l.mu.Lock()
+	cached, ok := l.entries[key]
+	lock := l.scanning[key]
+	if lock == nil {
+		lock = &sync.Mutex{}
+		l.scanning[key] = lock
+	}
+	l.mu.Unlock()
+	if ok && time.Since(cached.scannedAt) < l.ttl {
+		return cached.lib, nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// 等待期间其它请求可能已完成扫描
+	l.mu.Lock()
+	cached, ok = l.entries[key]
+	l.mu.Unlock()
+	if ok && time.Since(cached.scannedAt) < l.ttl {
+		return cached.lib, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	lib, err := scan(key, site)
+	if err != nil {
+		if ok {
+			log.Printf("扫描本地片库 %s 失败，继续使用旧片库: %v", key, err)
+			return cached.lib, nil
+		}
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.entries[key] = entry{lib: lib, scannedAt: time.Now()}
+	l.mu.Unlock()
+	return lib, nil
+}
+
+// siteKey 查找本地片库站点的标识，用于生成播放地址
+func siteKey(site config.Site) (string, bool) {
+	for key, s := range config.GetAllSites() {
+		if s.Type == config.SiteTypeLocal && s.Api == site.Api {
+			return key, true
+		}
+	}
+	return "", false
+}
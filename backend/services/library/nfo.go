@@ -0,0 +1,78 @@
+package library
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"strings"
+)
+
+// maxNFOBytes NFO 文件大小上限
+const maxNFOBytes = 1 << 20
+
+// NFO Kodi/Jellyfin 格式的元数据(tvshow.nfo、movie.nfo 或与视频同名的 .nfo)
+type NFO struct {
+	Title         string   `xml:"title"`
+	OriginalTitle string   `xml:"originaltitle"`
+	Year          string   `xml:"year"`
+	Premiered     string   `xml:"premiered"`
+	Plot          string   `xml:"plot"`
+	Outline       string   `xml:"outline"`
+	Rating        string   `xml:"rating"`
+	Genres        []string `xml:"genre"`
+	Countries     []string `xml:"country"`
+	Directors     []string `xml:"director"`
+	Actors        []struct {
+		Name string `xml:"name"`
+	} `xml:"actor"`
+}
+
+// readNFO 读取并解析 NFO 文件，文件不存在或无法解析时返回 nil
+func readNFO(path string) *NFO {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > maxNFOBytes {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	// 部分刮削工具会在 XML 之后追加网址，只解析第一个元素
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	var nfo NFO
+	if err := dec.Decode(&nfo); err != nil {
+		return nil
+	}
+	return &nfo
+}
+
+// year 年份，缺少时取首播日期的年份
+func (n *NFO) year() string {
+	if y := strings.TrimSpace(n.Year); y != "" {
+		return y
+	}
+	if len(n.Premiered) >= 4 {
+		return n.Premiered[:4]
+	}
+	return ""
+}
+
+// plot 简介，缺少时取一句话简介
+func (n *NFO) plot() string {
+	if p := strings.TrimSpace(n.Plot); p != "" {
+		return p
+	}
+	return strings.TrimSpace(n.Outline)
+}
+
+// actors 演员名称
+func (n *NFO) actors() []string {
+	names := make([]string, 0, len(n.Actors))
+	for _, a := range n.Actors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,377 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+	"ReelNest/utils"
+)
+
+const (
+	// maxFiles 单次扫描最多收录的视频文件数
+	maxFiles = 50000
+	// playFrom 本地视频的播放线路名称
+	playFrom = "local"
+)
+
+// 片库分类
+const (
+	classMovie  = "1"
+	classSeries = "2"
+)
+
+var classes = []maccms.Class{
+	{TypeID: classMovie, TypeName: "电影"},
+	{TypeID: classSeries, TypeName: "剧集"},
+}
+
+var (
+	// sxeRegex 英文剧集编号，如 "Show.S01E02"、"show s1 e2"
+	sxeRegex = regexp.MustCompile(`(?i)\bs(\d{1,2})[\s._-]*e(\d{1,3})`)
+	// cnEpisodeRegex 中文剧集编号，如 "庆余年 第02集"
+	cnEpisodeRegex = regexp.MustCompile(`第\s*(\d{1,4})\s*[集话話期]`)
+	// bareEpisodeRegex 只有集数的文件名，如 "02"、"EP02"、"E02"
+	bareEpisodeRegex = regexp.MustCompile(`(?i)^(?:ep?)?\s*(\d{1,4})$`)
+	// seasonDirRegex 季目录名称，如 "Season 2"、"S02"、"第2季"
+	seasonDirRegex = regexp.MustCompile(`(?i)^(?:season\s*(\d+)|s(\d{1,2})|第\s*(\d+)\s*季)$`)
+	// yearRegex 名称中的年份，如 "流浪地球 (2019)"、"Movie.2019.1080p"
+	yearRegex = regexp.MustCompile(`^(.+?)[\s._-]*[\(\[（【]?((?:19|20)\d{2})(?:[\)\]）】\s._-]|$)`)
+)
+
+// posterNames 作品目录中的海报文件名(不含扩展名)
+var posterNames = []string{"poster", "folder", "cover"}
+
+// errTooManyFiles 视频文件数量超过扫描上限
+var errTooManyFiles = errors.New("视频文件过多，停止扫描")
+
+// file 片库中的视频文件
+type file struct {
+	root    int
+	rel     string // 相对所在目录的路径，以 "/" 分隔
+	abs     string
+	modTime time.Time
+}
+
+// episode 剧集文件
+type episode struct {
+	num  int
+	file file
+}
+
+// work 作品：一部电影或一季剧集
+type work struct {
+	key      string
+	name     string
+	year     string
+	season   int
+	series   bool
+	dir      string // 作品元数据与海报所在目录
+	root     int
+	episodes []episode
+}
+
+// library 一次扫描得到的片库
+type library struct {
+	videos []maccms.Video
+}
+
+// scanner 扫描本地目录，按文件名中的季集编号归并为作品
+type scanner struct {
+	key   string
+	adult bool
+	roots []string
+	files int
+	works map[string]*work
+	order []string
+}
+
+// scan 扫描本地目录生成片库
+func scan(key string, site config.Site) (*library, error) {
+	roots := Roots(site)
+	s := &scanner{key: key, adult: site.Adult, roots: roots, works: make(map[string]*work)}
+	for i, root := range roots {
+		if err := s.walk(i, root); err != nil {
+			return nil, err
+		}
+	}
+
+	lib := &library{videos: make([]maccms.Video, 0, len(s.order))}
+	for _, k := range s.order {
+		lib.videos = append(lib.videos, s.video(s.works[k]))
+	}
+	sort.SliceStable(lib.videos, func(i, j int) bool {
+		return lib.videos[i].VodTime > lib.videos[j].VodTime
+	})
+	return lib, nil
+}
+
+// walk 遍历单个目录，跳过隐藏目录与 NAS 生成的缩略图目录
+func (s *scanner) walk(root int, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "@")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !utils.IsVideoFile(name) || strings.HasPrefix(name, ".") {
+			return nil
+		}
+		if s.files++; s.files > maxFiles {
+			return errTooManyFiles
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		f := file{root: root, rel: filepath.ToSlash(rel), abs: p}
+		if info, err := d.Info(); err == nil {
+			f.modTime = info.ModTime()
+		}
+		s.add(f)
+		return nil
+	})
+}
+
+// add 识别文件的季集编号并归入作品，无法识别的视为电影
+func (s *scanner) add(f file) {
+	dir := filepath.Dir(f.abs)
+	stem := strings.TrimSuffix(filepath.Base(f.abs), filepath.Ext(f.abs))
+
+	season, num, show := 0, 0, ""
+	if m := sxeRegex.FindStringSubmatchIndex(stem); m != nil {
+		season, _ = strconv.Atoi(stem[m[2]:m[3]])
+		num, _ = strconv.Atoi(stem[m[4]:m[5]])
+		show = cleanName(stem[:m[0]])
+	} else if m := cnEpisodeRegex.FindStringSubmatchIndex(stem); m != nil {
+		num, _ = strconv.Atoi(stem[m[2]:m[3]])
+		show = cleanName(stem[:m[0]])
+	} else if m := bareEpisodeRegex.FindStringSubmatch(strings.TrimSpace(stem)); m != nil {
+		num, _ = strconv.Atoi(m[1])
+	} else {
+		s.addMovie(f, dir, stem)
+		return
+	}
+
+	// 季目录中的剧集以上一级目录作为作品目录；根目录本身是季目录时不越出根目录，
+	// 只借用上一级目录名作为剧名
+	showDir, nameDir := dir, dir
+	if n := seasonNumber(filepath.Base(dir)); n > 0 {
+		nameDir = filepath.Dir(dir)
+		if dir != filepath.Clean(s.roots[f.root]) {
+			showDir = nameDir
+		}
+		if season == 0 {
+			season = n
+		}
+	}
+	if season == 0 {
+		season = 1
+	}
+	dirName, year := splitYear(filepath.Base(nameDir))
+	if show == "" {
+		show = dirName
+	} else {
+		show, _ = splitYear(show)
+	}
+
+	k := fmt.Sprintf("series|%s|%d", matcher.Normalize(show), season)
+	w, ok := s.works[k]
+	if !ok {
+		w = &work{key: k, name: show, year: year, season: season, series: true, dir: showDir, root: f.root}
+		s.works[k] = w
+		s.order = append(s.order, k)
+	}
+	w.episodes = append(w.episodes, episode{num: num, file: f})
+}
+
+// addMovie 单个视频文件作为一部电影
+func (s *scanner) addMovie(f file, dir, stem string) {
+	name, year := splitYear(stem)
+	k := "movie|" + strconv.Itoa(f.root) + "|" + f.rel
+	s.works[k] = &work{key: k, name: name, year: year, dir: dir, root: f.root, episodes: []episode{{file: f}}}
+	s.order = append(s.order, k)
+}
+
+// video 将作品转换为 MacCMS 视频条目，NFO 元数据优先于文件名
+func (s *scanner) video(w *work) maccms.Video {
+	sort.SliceStable(w.episodes, func(i, j int) bool {
+		if w.episodes[i].num != w.episodes[j].num {
+			return w.episodes[i].num < w.episodes[j].num
+		}
+		return w.episodes[i].file.rel < w.episodes[j].file.rel
+	})
+
+	v := maccms.Video{
+		VodID:       maccms.FlexString(videoID(w.key)),
+		VodName:     w.name,
+		VodYear:     maccms.FlexString(w.year),
+		VodPlayFrom: playFrom,
+		TypeID:      classMovie,
+		TypeName:    "电影",
+		VodRemarks:  "完结",
+	}
+
+	var nfo *NFO
+	var posterFile string
+	if w.series {
+		v.TypeID, v.TypeName = classSeries, "剧集"
+		v.VodRemarks = fmt.Sprintf("共%d集", len(w.episodes))
+		nfo = readNFO(filepath.Join(w.dir, "tvshow.nfo"))
+		posterFile = findPoster(w.dir, "")
+	} else {
+		stem := strings.TrimSuffix(w.episodes[0].file.abs, filepath.Ext(w.episodes[0].file.abs))
+		if nfo = readNFO(stem + ".nfo"); nfo == nil {
+			nfo = readNFO(filepath.Join(w.dir, "movie.nfo"))
+		}
+		posterFile = findPoster(w.dir, filepath.Base(stem))
+	}
+	if nfo != nil {
+		if title := strings.TrimSpace(nfo.Title); title != "" {
+			v.VodName = title
+		}
+		if y := nfo.year(); y != "" {
+			v.VodYear = maccms.FlexString(y)
+		}
+		v.VodSub = strings.TrimSpace(nfo.OriginalTitle)
+		v.VodContent = nfo.plot()
+		v.VodClass = strings.Join(nfo.Genres, ",")
+		v.VodArea = strings.Join(nfo.Countries, ",")
+		v.VodDirector = strings.Join(nfo.Directors, ",")
+		v.VodActor = strings.Join(nfo.actors(), ",")
+		v.VodScore = maccms.FlexString(strings.TrimSpace(nfo.Rating))
+	}
+	if w.series && w.season > 1 {
+		v.VodName = fmt.Sprintf("%s 第%d季", v.VodName, w.season)
+	}
+	if posterFile != "" {
+		// 海报只从根目录内提供
+		if rel, err := filepath.Rel(s.roots[w.root], posterFile); err == nil && filepath.IsLocal(rel) {
+			v.VodPic = s.fileURL(w.root, filepath.ToSlash(rel))
+		}
+	}
+
+	links := make([]string, 0, len(w.episodes))
+	var latest time.Time
+	for i, ep := range w.episodes {
+		title := "正片"
+		if w.series {
+			n := ep.num
+			if n == 0 {
+				n = i + 1
+			}
+			title = fmt.Sprintf("第%d集", n)
+		}
+		links = append(links, title+"$"+s.fileURL(ep.file.root, ep.file.rel))
+		if ep.file.modTime.After(latest) {
+			latest = ep.file.modTime
+		}
+	}
+	v.VodPlayURL = strings.Join(links, "#")
+	if !latest.IsZero() {
+		v.VodTime = latest.Local().Format(maccms.TimeLayout)
+	}
+	return v
+}
+
+// fileURL 生成经本服务读取的文件地址(相对地址)，路径以所在目录序号开头
+// 成人站点带上 adult=1 以通过成人内容校验
+func (s *scanner) fileURL(root int, rel string) string {
+	q := url.Values{"site": {s.key}, "path": {strconv.Itoa(root) + "/" + rel}}
+	if s.adult {
+		q.Set("adult", "1")
+	}
+	return FilePath + "?" + q.Encode()
+}
+
+// findPoster 查找海报：优先与视频同名的图片，其次目录中的 poster/folder/cover
+func findPoster(dir, stem string) string {
+	var names []string
+	if stem != "" {
+		names = append(names, stem+"-poster", stem)
+	}
+	names = append(names, posterNames...)
+	for _, name := range names {
+		for _, ext := range []string{".jpg", ".jpeg", ".png", ".webp"} {
+			p := filepath.Join(dir, name+ext)
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// seasonNumber 解析季目录名称中的季数，不是季目录时返回 0
+func seasonNumber(name string) int {
+	m := seasonDirRegex.FindStringSubmatch(strings.TrimSpace(name))
+	if m == nil {
+		return 0
+	}
+	for _, g := range m[1:] {
+		if n, err := strconv.Atoi(g); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// splitYear 拆分名称与年份，年份之后的清晰度等标签一并去掉
+func splitYear(name string) (string, string) {
+	if m := yearRegex.FindStringSubmatch(name); m != nil {
+		return cleanName(m[1]), m[2]
+	}
+	return cleanName(name), ""
+}
+
+// cleanName 将文件名中的分隔符替换为空格
+func cleanName(name string) string {
+	name = strings.NewReplacer(".", " ", "_", " ").Replace(name)
+	return strings.Trim(strings.Join(strings.Fields(name), " "), " -[]【】")
+}
+
+// videoID 由作品键生成稳定的视频 ID
+func videoID(key string) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// relPath 检查 "序号/相对路径" 形式的路径并返回目录序号与清理后的相对路径
+func relPath(p string) (int, string, bool) {
+	idx, rest, ok := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+	if !ok {
+		return 0, "", false
+	}
+	root, err := strconv.Atoi(idx)
+	if err != nil || root < 0 {
+		return 0, "", false
+	}
+	rest = path.Clean("/" + rest)
+	if rest == "/" {
+		return 0, "", false
+	}
+	return root, rest[1:], true
+}
@@ -0,0 +1,198 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/models"
+	"ReelNest/services/hls"
+	"ReelNest/utils"
+)
+
+// 检测状态
+const (
+	StatusOK        = "ok"
+	StatusEmpty     = "empty"
+	StatusNotFound  = "not_found"
+	StatusHTTPError = "http_error"
+	StatusInvalid   = "invalid"
+	StatusTimeout   = "timeout"
+	StatusError     = "error"
+	// StatusUnsupported 非 http(s) 链接(如 rtmp、udp)无法检测
+	StatusUnsupported = "unsupported"
+)
+
+// 单个链接检测超时
+const checkTimeout = 10 * time.Second
+
+// maxCacheEntries 缓存条目上限
+const maxCacheEntries = 10000
+
+// Checker 剧集链接检测器，使用固定大小的工作池并发检测
+type Checker struct {
+	hc      *client.Client
+	workers int
+	cache   *utils.TTLCache[models.EpisodeCheck]
+}
+
+// NewChecker 创建检测器
+func NewChecker(hc *client.Client, workers int, ttl time.Duration) *Checker {
+	if workers <= 0 {
+		workers = 8
+	}
+	return &Checker{
+		hc:      hc,
+		workers: workers,
+		cache:   utils.NewTTLCache[models.EpisodeCheck](ttl, maxCacheEntries),
+	}
+}
+
+// Check 并发检测剧集列表，结果顺序与输入一致
+func (c *Checker) Check(ctx context.Context, episodes []models.EpisodeInfo) []models.EpisodeCheck {
+	results := make([]models.EpisodeCheck, len(episodes))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers && w < len(episodes); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := c.CheckURL(ctx, episodes[i].Url)
+				result.Title = episodes[i].Title
+				results[i] = result
+			}
+		}()
+	}
+
+	for i := range episodes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// CheckURL 检测单个链接，优先使用缓存结果
+func (c *Checker) CheckURL(ctx context.Context, playURL string) models.EpisodeCheck {
+	if result, ok := c.cache.Get(playURL); ok {
+		result.Cached = true
+		return result
+	}
+
+	lower := strings.ToLower(playURL)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return models.EpisodeCheck{Url: playURL, Status: StatusUnsupported, Error: "不支持的协议", CheckedAt: time.Now().Unix()}
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var result models.EpisodeCheck
+	if isPlaylistURL(playURL) {
+		result = c.checkPlaylist(reqCtx, playURL)
+	} else {
+		result = c.checkFile(reqCtx, playURL)
+	}
+	result.Url = playURL
+	result.CheckedAt = time.Now().Unix()
+
+	// 请求方取消时不缓存，避免把未完成的检测当作失败结果
+	if ctx.Err() == nil {
+		c.cache.Set(playURL, result)
+	}
+	return result
+}
+
+// checkPlaylist 下载并解析播放列表，统计分片数和总时长
+func (c *Checker) checkPlaylist(ctx context.Context, playURL string) models.EpisodeCheck {
+	resolved, err := hls.Resolve(ctx, c.hc, playURL)
+	if err != nil {
+		return errorResult(err)
+	}
+
+	media := resolved.Media
+	result := models.EpisodeCheck{
+		StatusCode: 200,
+		Segments:   len(media.Segments),
+		Duration:   media.Duration,
+		Status:     StatusOK,
+	}
+	if len(media.Segments) == 0 {
+		result.Status = StatusEmpty
+	}
+	return result
+}
+
+// checkFile 对直链文件只请求开头少量字节，确认可访问
+func (c *Checker) checkFile(ctx context.Context, fileURL string) models.EpisodeCheck {
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(fileURL)
+	req.SetMethod("GET")
+	utils.AddBrowserHeaders(req, "")
+	req.Header.Set("Range", "bytes=0-1023")
+
+	if _, err := utils.Do(ctx, c.hc, req, resp, nil); err != nil {
+		return errorResult(err)
+	}
+
+	code := resp.StatusCode()
+	if code != 200 && code != 206 {
+		return errorResult(&hls.StatusError{Code: code})
+	}
+
+	// 返回网页通常意味着链接是分享页而非视频
+	contentType := strings.ToLower(string(resp.Header.ContentType()))
+	if strings.HasPrefix(contentType, "text/html") {
+		return models.EpisodeCheck{StatusCode: code, Status: StatusInvalid, Error: "链接返回网页而非视频"}
+	}
+	return models.EpisodeCheck{StatusCode: code, Status: StatusOK}
+}
+
+// errorResult 将错误映射为检测结果
+func errorResult(err error) models.EpisodeCheck {
+	var statusErr *hls.StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		status := StatusHTTPError
+		if statusErr.Code == 404 || statusErr.Code == 410 {
+			status = StatusNotFound
+		}
+		return models.EpisodeCheck{Status: status, StatusCode: statusErr.Code, Error: err.Error()}
+	case errors.Is(err, hls.ErrNotPlaylist):
+		return models.EpisodeCheck{Status: StatusInvalid, StatusCode: 200, Error: err.Error()}
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.EpisodeCheck{Status: StatusTimeout, Error: "请求超时"}
+	default:
+		return models.EpisodeCheck{Status: StatusError, Error: err.Error()}
+	}
+}
+
+// isPlaylistURL 判断链接是否为 m3u8 播放列表
+func isPlaylistURL(playURL string) bool {
+	path := strings.ToLower(playURL)
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	return strings.HasSuffix(path, ".m3u8") || !hasMediaExtension(path)
+}
+
+// hasMediaExtension 判断是否为常见视频文件扩展名
+func hasMediaExtension(path string) bool {
+	for _, ext := range []string{".mp4", ".mkv", ".flv", ".avi", ".mov", ".webm", ".ts"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/models"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	// 播放列表重定向到 CDN 的另一个目录，档位与分片为相对地址
+	mux.HandleFunc("/play/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/cdn/v1/master.m3u8", http.StatusFound)
+	})
+	mux.HandleFunc("/cdn/v1/master.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=1280x720\nhd/index.m3u8\n")
+	})
+	mux.HandleFunc("/cdn/v1/hd/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "#EXTM3U\n#EXTINF:10,\nseg0.ts\n#EXTINF:10,\nseg1.ts\n#EXT-X-ENDLIST\n")
+	})
+	mux.HandleFunc("/file.mp4", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/store/file.mp4", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/store/file.mp4", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(make([]byte, 1024))
+	})
+	mux.HandleFunc("/loop.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop.m3u8", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckFollowsRedirects(t *testing.T) {
+	srv := newServer(t)
+	hc, err := client.NewClient(client.WithResponseBodyStream(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewChecker(hc, 2, time.Minute)
+
+	tests := []struct {
+		path     string
+		status   string
+		segments int
+	}{
+		{"/play/index.m3u8", StatusOK, 2},
+		{"/file.mp4", StatusOK, 0},
+		{"/missing.m3u8", StatusNotFound, 0},
+		{"/loop.m3u8", StatusError, 0},
+	}
+	episodes := make([]models.EpisodeInfo, len(tests))
+	for i, tt := range tests {
+		episodes[i] = models.EpisodeInfo{Title: tt.path, Url: srv.URL + tt.path}
+	}
+	for i, got := range c.Check(context.Background(), episodes) {
+		tt := tests[i]
+		if got.Status != tt.status || got.Segments != tt.segments {
+			t.Errorf("%s: status %q segments %d (%s), want %q %d", tt.path, got.Status, got.Segments, got.Error, tt.status, tt.segments)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/matcher"
+	"ReelNest/utils"
+)
+
+const (
+	// loadTimeout 拉取远程频道列表的超时
+	loadTimeout = 15 * time.Second
+	// maxListBytes 频道列表大小上限
+	maxListBytes = 16 << 20
+)
+
+// entry 已加载的频道列表
+type entry struct {
+	playlist *Playlist
+	loadedAt time.Time
+}
+
+// Manager 直播源管理：按需加载频道列表并缓存，刷新失败时继续使用旧列表
+type Manager struct {
+	hc  *client.Client
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+// NewManager 创建直播源管理器，ttl 为频道列表的刷新间隔
+func NewManager(hc *client.Client, ttl time.Duration) *Manager {
+	return &Manager{
+		hc:      hc,
+		ttl:     ttl,
+		entries: make(map[string]entry),
+	}
+}
+
+// Playlist 获取直播源的频道列表，频道带上来源信息
+func (m *Manager) Playlist(ctx context.Context, key string, site config.Site) (*Playlist, error) {
+	m.mu.Lock()
+	cached, ok := m.entries[key]
+	m.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < m.ttl {
+		return cached.playlist, nil
+	}
+
+	pl, err := m.load(ctx, site)
+	if err != nil {
+		if ok {
+			log.Printf("刷新直播源 %s 失败，继续使用旧列表: %v", key, err)
+			return cached.playlist, nil
+		}
+		return nil, err
+	}
+	for i := range pl.Channels {
+		pl.Channels[i].SourceCode = key
+		pl.Channels[i].SourceName = site.Name
+	}
+
+	m.mu.Lock()
+	m.entries[key] = entry{playlist: pl, loadedAt: time.Now()}
+	m.mu.Unlock()
+	return pl, nil
+}
+
+// load 从地址或本地文件读取频道列表，相对路径以配置文件所在目录为准
+func (m *Manager) load(ctx context.Context, site config.Site) (*Playlist, error) {
+	var data []byte
+	if strings.HasPrefix(site.Api, "http://") || strings.HasPrefix(site.Api, "https://") {
+		reqCtx, cancel := context.WithTimeout(ctx, loadTimeout)
+		defer cancel()
+		result, err := utils.Fetch(reqCtx, m.hc, site.Api, "", maxListBytes)
+		if err != nil {
+			return nil, err
+		}
+		if result.StatusCode != 200 {
+			return nil, fmt.Errorf("频道列表请求失败: HTTP %d", result.StatusCode)
+		}
+		data = result.Body
+	} else {
+		b, err := os.ReadFile(config.ResolvePath(strings.TrimPrefix(site.Api, "file://")))
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	}
+	return Parse(data)
+}
+
+// Channels 获取直播源的全部频道
+func (m *Manager) Channels(ctx context.Context, key string, site config.Site) ([]models.LiveChannel, error) {
+	pl, err := m.Playlist(ctx, key, site)
+	if err != nil {
+		return nil, err
+	}
+	return pl.Channels, nil
+}
+
+// Channel 按 ID 查找频道
+func (m *Manager) Channel(ctx context.Context, key string, site config.Site, id string) (models.LiveChannel, bool, error) {
+	channels, err := m.Channels(ctx, key, site)
+	if err != nil {
+		return models.LiveChannel{}, false, err
+	}
+	for _, ch := range channels {
+		if ch.ID == id {
+			return ch, true, nil
+		}
+	}
+	return models.LiveChannel{}, false, nil
+}
+
+// All 合并多个直播源的频道，按源标识排序，单个源加载失败时跳过该源
+func (m *Manager) All(ctx context.Context, sites map[string]config.Site) []models.LiveChannel {
+	var list []models.LiveChannel
+	for _, key := range sortedKeys(sites) {
+		channels, err := m.Channels(ctx, key, sites[key])
+		if err != nil {
+			log.Printf("加载直播源 %s 失败: %v", key, err)
+			continue
+		}
+		list = append(list, channels...)
+	}
+	return list
+}
+
+// Search 在多个直播源中按名称搜索频道
+func (m *Manager) Search(ctx context.Context, sites map[string]config.Site, keyword string) []models.LiveChannel {
+	kw := matcher.Normalize(keyword)
+	if kw == "" {
+		return nil
+	}
+
+	var list []models.LiveChannel
+	for _, ch := range m.All(ctx, sites) {
+		if strings.Contains(matcher.Normalize(ch.Name), kw) ||
+			(ch.TvgName != "" && strings.Contains(matcher.Normalize(ch.TvgName), kw)) {
+			list = append(list, ch)
+		}
+	}
+	return list
+}
+
+// Groups 按分组整理频道，保持频道列表中的出现顺序
+func Groups(channels []models.LiveChannel) []models.LiveGroup {
+	var groups []models.LiveGroup
+	index := make(map[string]int)
+	for _, ch := range channels {
+		i, ok := index[ch.Group]
+		if !ok {
+			i = len(groups)
+			index[ch.Group] = i
+			groups = append(groups, models.LiveGroup{Name: ch.Group})
+		}
+		groups[i].Channels = append(groups[i].Channels, ch)
+	}
+	return groups
+}
+
+// FilterGroup 筛选指定分组的频道
+func FilterGroup(channels []models.LiveChannel, group string) []models.LiveChannel {
+	var list []models.LiveChannel
+	for _, ch := range channels {
+		if ch.Group == group {
+			list = append(list, ch)
+		}
+	}
+	return list
+}
+
+// EPGSources 收集所有直播源的节目单地址：优先使用站点配置的 epg，
+// 否则使用频道列表头部声明的 x-tvg-url，多个地址以逗号分隔
+func (m *Manager) EPGSources(ctx context.Context) []string {
+	sites := config.GetLiveSites(true)
+	seen := make(map[string]bool)
+	var sources []string
+	for _, key := range sortedKeys(sites) {
+		site := sites[key]
+		epg := site.Epg
+		if epg == "" {
+			pl, err := m.Playlist(ctx, key, site)
+			if err != nil {
+				continue
+			}
+			epg = pl.EPG
+		}
+		for _, u := range strings.Split(epg, ",") {
+			if u = strings.TrimSpace(u); u != "" && !seen[u] {
+				seen[u] = true
+				sources = append(sources, u)
+			}
+		}
+	}
+	return sources
+}
+
+func sortedKeys(sites map[string]config.Site) []string {
+	keys := make([]string, 0, len(sites))
+	for key := range sites {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+package live
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	"ReelNest/models"
+)
+
+// defaultGroup 未指定分组的频道归入此组
+const defaultGroup = "未分组"
+
+// ErrEmptyPlaylist 频道列表中没有可用频道
+var ErrEmptyPlaylist = errors.New("频道列表为空")
+
+// attrRegex #EXTINF / #EXTM3U 行中的 key="value" 属性
+var attrRegex = regexp.MustCompile(`([\w-]+)="([^"]*)"`)
+
+// Playlist 解析后的频道列表
+type Playlist struct {
+	// EPG 列表头部 x-tvg-url 声明的节目单地址
+	EPG      string
+	Channels []models.LiveChannel
+}
+
+// Parse 解析 M3U 或 TXT(分组,#genre# 格式)频道列表
+// 同一分组中的同名频道合并为一个频道的多个播放地址
+func Parse(data []byte) (*Playlist, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var pl *Playlist
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("#EXTM3U")) || bytes.Contains(data, []byte("#EXTINF")) {
+		pl = parseM3U(data)
+	} else {
+		pl = parseTXT(data)
+	}
+	if len(pl.Channels) == 0 {
+		return nil, ErrEmptyPlaylist
+	}
+	return pl, nil
+}
+
+// parseM3U 解析扩展 M3U：#EXTINF 行携带 group-title、tvg-logo 等属性，下一行为播放地址
+func parseM3U(data []byte) *Playlist {
+	pl := &Playlist{}
+	b := newBuilder()
+
+	var pending *models.LiveChannel
+	scanner := newScanner(data)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTM3U"):
+			attrs := parseAttrs(line)
+			pl.EPG = firstNonEmpty(attrs["x-tvg-url"], attrs["url-tvg"])
+		case strings.HasPrefix(line, "#EXTINF"):
+			attrs := parseAttrs(line)
+			// 名称在属性之后的第一个逗号后，属性值中可能含有逗号
+			name := ""
+			rest := line[strings.LastIndex(line, `"`)+1:]
+			if i := strings.IndexByte(rest, ','); i >= 0 {
+				name = strings.TrimSpace(rest[i+1:])
+			}
+			pending = &models.LiveChannel{
+				Name:    firstNonEmpty(name, attrs["tvg-name"]),
+				Group:   attrs["group-title"],
+				Logo:    attrs["tvg-logo"],
+				TvgID:   attrs["tvg-id"],
+				TvgName: attrs["tvg-name"],
+			}
+		case strings.HasPrefix(line, "#EXTGRP:"):
+			if pending != nil && pending.Group == "" {
+				pending.Group = strings.TrimSpace(strings.TrimPrefix(line, "#EXTGRP:"))
+			}
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			if pending == nil {
+				continue
+			}
+			b.add(*pending, line)
+			pending = nil
+		}
+	}
+	pl.Channels = b.channels
+	return pl
+}
+
+// parseTXT 解析 TXT 频道列表："分组,#genre#" 开始新分组，"名称,地址" 为频道
+// 地址中可用 "#" 分隔多个备用地址
+func parseTXT(data []byte) *Playlist {
+	b := newBuilder()
+	group := ""
+
+	scanner := newScanner(data)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		name, rest, ok := strings.Cut(line, ",")
+		if !ok {
+			continue
+		}
+		name, rest = strings.TrimSpace(name), strings.TrimSpace(rest)
+		if rest == "#genre#" {
+			group = name
+			continue
+		}
+		for _, u := range strings.Split(rest, "#") {
+			if u = strings.TrimSpace(u); u != "" {
+				b.add(models.LiveChannel{Name: name, Group: group}, u)
+			}
+		}
+	}
+	return &Playlist{Channels: b.channels}
+}
+
+// builder 按分组与名称合并频道，保持首次出现的顺序
+type builder struct {
+	channels []models.LiveChannel
+	index    map[string]int
+}
+
+func newBuilder() *builder {
+	return &builder{index: make(map[string]int)}
+}
+
+func (b *builder) add(ch models.LiveChannel, url string) {
+	if ch.Name == "" || !strings.Contains(url, "://") {
+		return
+	}
+	if ch.Group == "" {
+		ch.Group = defaultGroup
+	}
+	key := ch.Group + "\x00" + ch.Name
+	if i, ok := b.index[key]; ok {
+		existing := &b.channels[i]
+		for _, u := range existing.URLs {
+			if u == url {
+				return
+			}
+		}
+		existing.URLs = append(existing.URLs, url)
+		if existing.Logo == "" {
+			existing.Logo = ch.Logo
+		}
+		if existing.TvgID == "" {
+			existing.TvgID = ch.TvgID
+		}
+		return
+	}
+	ch.ID = channelID(ch.Group, ch.Name)
+	ch.URLs = []string{url}
+	b.index[key] = len(b.channels)
+	b.channels = append(b.channels, ch)
+}
+
+// channelID 由分组与名称生成稳定的频道 ID，列表刷新后 ID 不变
+func channelID(group, name string) string {
+	h := fnv.New64a()
+	h.Write([]byte(group))
+	h.Write([]byte{0})
+	h.Write([]byte(name))
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// parseAttrs 提取行内的 key="value" 属性，键统一小写
+func parseAttrs(line string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrRegex.FindAllStringSubmatch(line, -1) {
+		attrs[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+	}
+	return attrs
+}
+
+func newScanner(data []byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return scanner
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Encode 将频道列表输出为扩展 M3U，多个播放地址各占一条
+func Encode(epg string, channels []models.LiveChannel) []byte {
+	var b bytes.Buffer
+	b.WriteString("#EXTM3U")
+	if epg != "" {
+		fmt.Fprintf(&b, ` x-tvg-url="%s"`, epg)
+	}
+	b.WriteByte('\n')
+	for _, ch := range channels {
+		for _, u := range ch.URLs {
+			b.WriteString("#EXTINF:-1")
+			writeAttr(&b, "tvg-id", ch.TvgID)
+			writeAttr(&b, "tvg-name", ch.TvgName)
+			writeAttr(&b, "tvg-logo", ch.Logo)
+			writeAttr(&b, "group-title", ch.Group)
+			fmt.Fprintf(&b, ",%s\n%s\n", ch.Name, u)
+		}
+	}
+	return b.Bytes()
+}
+
+func writeAttr(b *bytes.Buffer, key, value string) {
+	if value != "" {
+		fmt.Fprintf(b, ` %s="%s"`, key, strings.ReplaceAll(value, `"`, "'"))
+	}
+}
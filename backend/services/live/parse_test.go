@@ -0,0 +1,122 @@
+package live
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseM3U(t *testing.T) {
+	data := "\xef\xbb\xbf" + `#EXTM3U x-tvg-url="http://epg.example/e.xml"
+#EXTINF:-1 tvg-id="cctv1" tvg-name="CCTV1" tvg-logo="http://logo/1.png" group-title="央视,高清",CCTV-1 综合
+http://a.example/cctv1.m3u8
+#EXTINF:-1 tvg-id="cctv1" group-title="央视,高清",CCTV-1 综合
+http://b.example/cctv1.m3u8
+#EXTINF:-1 group-title="央视,高清",CCTV-1 综合
+http://a.example/cctv1.m3u8
+#EXTINF:-1,本地台
+#EXTGRP:地方
+#EXTVLCOPT:http-user-agent=x
+http://c.example/local.m3u8
+#EXTINF:-1,无地址
+not-a-url
+http://orphan.example/x.m3u8
+`
+	pl, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.EPG != "http://epg.example/e.xml" {
+		t.Errorf("EPG = %q", pl.EPG)
+	}
+	if len(pl.Channels) != 2 {
+		t.Fatalf("got %d channels: %+v", len(pl.Channels), pl.Channels)
+	}
+
+	cctv := pl.Channels[0]
+	if cctv.Name != "CCTV-1 综合" || cctv.Group != "央视,高清" || cctv.TvgID != "cctv1" || cctv.Logo != "http://logo/1.png" {
+		t.Errorf("cctv = %+v", cctv)
+	}
+	// 同组同名频道合并，重复地址只保留一次
+	if want := []string{"http://a.example/cctv1.m3u8", "http://b.example/cctv1.m3u8"}; !reflect.DeepEqual(cctv.URLs, want) {
+		t.Errorf("cctv urls = %v, want %v", cctv.URLs, want)
+	}
+	if local := pl.Channels[1]; local.Name != "本地台" || local.Group != "地方" {
+		t.Errorf("local = %+v", local)
+	}
+	if cctv.ID == "" || cctv.ID != channelID("央视,高清", "CCTV-1 综合") {
+		t.Errorf("id = %q, want stable id", cctv.ID)
+	}
+}
+
+func TestParseTXT(t *testing.T) {
+	data := `央视,#genre#
+CCTV1,http://a.example/1.m3u8#http://b.example/1.m3u8
+CCTV1,http://c.example/1.m3u8
+// 注释
+无效行
+卫视,#genre#
+湖南卫视, http://a.example/hn.m3u8
+CCTV1,http://d.example/1.m3u8
+`
+	pl, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.EPG != "" {
+		t.Errorf("EPG = %q", pl.EPG)
+	}
+
+	type got struct {
+		group, name string
+		urls        []string
+	}
+	var list []got
+	for _, ch := range pl.Channels {
+		list = append(list, got{ch.Group, ch.Name, ch.URLs})
+	}
+	want := []got{
+		{"央视", "CCTV1", []string{"http://a.example/1.m3u8", "http://b.example/1.m3u8", "http://c.example/1.m3u8"}},
+		{"卫视", "湖南卫视", []string{"http://a.example/hn.m3u8"}},
+		{"卫视", "CCTV1", []string{"http://d.example/1.m3u8"}},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("channels = %+v, want %+v", list, want)
+	}
+}
+
+func TestParseDefaultGroupAndEmpty(t *testing.T) {
+	pl, err := Parse([]byte("频道,http://a.example/x.m3u8\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl.Channels[0].Group != defaultGroup {
+		t.Errorf("group = %q, want %q", pl.Channels[0].Group, defaultGroup)
+	}
+
+	for _, data := range []string{"", "#EXTM3U\n", "分组,#genre#\n名称,不是地址\n"} {
+		if _, err := Parse([]byte(data)); !errors.Is(err, ErrEmptyPlaylist) {
+			t.Errorf("Parse(%q) err = %v, want ErrEmptyPlaylist", data, err)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	src := `#EXTM3U x-tvg-url="http://epg.example/e.xml"
+#EXTINF:-1 tvg-id="hn" tvg-logo="http://logo/hn.png" group-title="卫视",湖南卫视
+http://a.example/hn.m3u8
+#EXTINF:-1 group-title="卫视",湖南卫视
+http://b.example/hn.m3u8
+`
+	pl, err := Parse([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Parse(Encode(pl.EPG, pl.Channels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, pl) {
+		t.Errorf("round trip = %+v, want %+v", again, pl)
+	}
+}
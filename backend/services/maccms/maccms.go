@@ -0,0 +1,212 @@
+package maccms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+
+	"ReelNest/config"
+	"ReelNest/utils"
+)
+
+// APIPath MacCMS 采集接口路径
+const APIPath = "api.php/provide/vod/"
+
+// 单次接口响应最大字节数
+const maxResponseBytes = 16 << 20
+
+// ErrNotFound 上游未返回对应视频
+var ErrNotFound = errors.New("未找到视频")
+
+// FlexString 兼容数字与字符串两种写法的字段(如 vod_id、type_id)
+type FlexString string
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (f *FlexString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*f = FlexString(s)
+		return nil
+	}
+	if string(data) == "null" {
+		*f = ""
+		return nil
+	}
+	*f = FlexString(data)
+	return nil
+}
+
+// FlexInt 兼容数字与数字字符串两种写法的整数字段(如 page、total)
+type FlexInt int
+
+// UnmarshalJSON 实现 json.Unmarshaler，无法解析的值视为0
+func (f *FlexInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		*f = 0
+		return nil
+	}
+	*f = FlexInt(n)
+	return nil
+}
+
+// Video MacCMS 视频条目
+type Video struct {
+	VodID       FlexString `json:"vod_id"`
+	VodName     string     `json:"vod_name"`
+	VodSub      string     `json:"vod_sub"`
+	VodPic      string     `json:"vod_pic"`
+	VodYear     FlexString `json:"vod_year"`
+	VodArea     string     `json:"vod_area"`
+	VodLang     string     `json:"vod_lang"`
+	VodDirector string     `json:"vod_director"`
+	VodActor    string     `json:"vod_actor"`
+	VodClass    string     `json:"vod_class"`
+	VodContent  string     `json:"vod_content"`
+	VodBlurb    string     `json:"vod_blurb"`
+	VodRemarks  string     `json:"vod_remarks"`
+	VodScore    FlexString `json:"vod_score"`
+	VodDuration string     `json:"vod_duration"`
+	VodTime     string     `json:"vod_time"`
+	VodPlayFrom string     `json:"vod_play_from"`
+	VodPlayURL  string     `json:"vod_play_url"`
+	TypeID      FlexString `json:"type_id"`
+	TypeName    string     `json:"type_name"`
+}
+
+// Class MacCMS 分类
+type Class struct {
+	TypeID   FlexString `json:"type_id"`
+	TypePID  FlexString `json:"type_pid"`
+	TypeName string     `json:"type_name"`
+}
+
+// Response MacCMS 接口响应
+type Response struct {
+	Code      FlexInt `json:"code"`
+	Msg       string  `json:"msg"`
+	Page      FlexInt `json:"page"`
+	PageCount FlexInt `json:"pagecount"`
+	Limit     FlexInt `json:"limit"`
+	Total     FlexInt `json:"total"`
+	List      []Video `json:"list"`
+	Class     []Class `json:"class,omitempty"`
+}
+
+// Backend 非 MacCMS 站点的数据来源，按 MacCMS 接口参数返回同样结构的响应
+// 使搜索、浏览、详情与目录采集无需区分站点类型
+type Backend interface {
+	Fetch(ctx context.Context, site config.Site, params url.Values) (*Response, error)
+}
+
+// Client MacCMS 接口客户端
+type Client struct {
+	hc       *client.Client
+	backends map[string]Backend
+}
+
+// NewClient 创建 MacCMS 客户端
+func NewClient(hc *client.Client) *Client {
+	return &Client{hc: hc, backends: make(map[string]Backend)}
+}
+
+// Register 为指定站点类型注册数据来源，需在开始处理请求前调用
+func (c *Client) Register(siteType string, b Backend) {
+	c.backends[siteType] = b
+}
+
+// BuildURL 构建站点接口地址
+func BuildURL(apiBase string, params url.Values) string {
+	if !strings.HasSuffix(apiBase, "/") {
+		apiBase += "/"
+	}
+	return apiBase + APIPath + "?" + params.Encode()
+}
+
+// Fetch 请求站点接口并解析响应
+func (c *Client) Fetch(ctx context.Context, site config.Site, params url.Values) (*Response, error) {
+	if b, ok := c.backends[site.Type]; ok {
+		return b.Fetch(ctx, site, params)
+	}
+
+	timeout := time.Duration(config.Get().Timeout) * time.Second
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := utils.Fetch(reqCtx, c.hc, BuildURL(site.Api, params), "", maxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+	if result.StatusCode != 200 {
+		return nil, fmt.Errorf("接口请求失败，状态码: %d", result.StatusCode)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(result.Body, &resp); err != nil {
+		return nil, fmt.Errorf("解析接口响应失败: %w", err)
+	}
+	return &resp, nil
+}
+
+// Detail 获取单个视频详情
+func (c *Client) Detail(ctx context.Context, site config.Site, id string) (*Video, error) {
+	resp, err := c.Fetch(ctx, site, url.Values{"ac": {"videolist"}, "ids": {id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.List) == 0 {
+		return nil, ErrNotFound
+	}
+	return &resp.List[0], nil
+}
+
+// Search 按关键词搜索，使用 videolist 以便直接拿到播放列表
+func (c *Client) Search(ctx context.Context, site config.Site, keyword string, page int) (*Response, error) {
+	params := url.Values{"ac": {"videolist"}, "wd": {keyword}}
+	if page > 1 {
+		params.Set("pg", strconv.Itoa(page))
+	}
+	return c.Fetch(ctx, site, params)
+}
+
+// Categories 获取站点分类列表
+func (c *Client) Categories(ctx context.Context, site config.Site) ([]Class, error) {
+	resp, err := c.Fetch(ctx, site, url.Values{"ac": {"list"}})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Class, nil
+}
+
+// ListQuery 视频列表查询条件
+type ListQuery struct {
+	TypeID string // 分类 t
+	Page   int    // 页码 pg
+	Hours  int    // 最近 N 小时更新 h
+}
+
+// List 按分类、更新时间分页获取视频列表(含播放地址)
+func (c *Client) List(ctx context.Context, site config.Site, q ListQuery) (*Response, error) {
+	params := url.Values{"ac": {"videolist"}}
+	if q.TypeID != "" {
+		params.Set("t", q.TypeID)
+	}
+	if q.Page > 1 {
+		params.Set("pg", strconv.Itoa(q.Page))
+	}
+	if q.Hours > 0 {
+		params.Set("h", strconv.Itoa(q.Hours))
+	}
+	return c.Fetch(ctx, site, params)
+}
@@ -0,0 +1,204 @@
+package maccms
+
+import (
+	"fmt"
+	"strings"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/taxonomy"
+	"ReelNest/utils"
+)
+
+// Info 将 MacCMS 条目转换为统一的视频信息
+func (v *Video) Info(siteKey string, site config.Site) models.VideoInfo {
+	desc := v.VodContent
+	if desc == "" {
+		desc = v.VodBlurb
+	}
+
+	canonical := taxonomy.Classify(siteKey, string(v.TypeID), v.TypeName, v.VodClass)
+
+	return models.VideoInfo{
+		ID:         string(v.VodID),
+		Title:      strings.TrimSpace(v.VodName),
+		SubTitle:   v.VodSub,
+		Desc:       utils.CleanHTML(desc),
+		SourceName: site.Name,
+		SourceCode: siteKey,
+		CoverUrl:   strings.ReplaceAll(v.VodPic, "\\/", "/"),
+		Year:       string(v.VodYear),
+		Area:       v.VodArea,
+		Directors:  utils.SplitToArray(v.VodDirector),
+		Actors:     utils.SplitToArray(v.VodActor),
+		Type:       v.TypeName,
+		Categories: utils.SplitToArray(v.VodClass),
+		Remarks:    v.VodRemarks,
+		Duration:   v.VodDuration,
+		Score:      string(v.VodScore),
+		UpdatedAt:  v.VodTime,
+
+		CanonicalType:  canonical.Type,
+		CanonicalGenre: canonical.Genre,
+	}
+}
+
+// Episodes 解析播放列表，优先选择 m3u8 播放组
+func (v *Video) Episodes() []models.EpisodeInfo {
+	groups := v.PlayGroups()
+	if len(groups) == 0 {
+		return []models.EpisodeInfo{}
+	}
+
+	for _, group := range groups {
+		if strings.Contains(strings.ToLower(group.From), "m3u8") {
+			return group.Episodes
+		}
+	}
+	for _, group := range groups {
+		if len(group.Episodes) > 0 && strings.Contains(strings.ToLower(group.Episodes[0].Url), ".m3u8") {
+			return group.Episodes
+		}
+	}
+	return groups[0].Episodes
+}
+
+// EpisodesFrom 优先选择名称包含 line 的播放线路(不区分大小写)，没有匹配时同 Episodes
+func (v *Video) EpisodesFrom(line string) []models.EpisodeInfo {
+	if line = strings.ToLower(strings.TrimSpace(line)); line != "" {
+		for _, group := range v.PlayGroups() {
+			if len(group.Episodes) > 0 && strings.Contains(strings.ToLower(group.From), line) {
+				return group.Episodes
+			}
+		}
+	}
+	return v.Episodes()
+}
+
+// PlayGroup 一组播放线路
+type PlayGroup struct {
+	From     string
+	Episodes []models.EpisodeInfo
+}
+
+// PlayGroups 按 $$$ 拆分所有播放线路
+func (v *Video) PlayGroups() []PlayGroup {
+	if strings.TrimSpace(v.VodPlayURL) == "" {
+		return nil
+	}
+
+	playURL := strings.ReplaceAll(v.VodPlayURL, "\\/", "/")
+	urlGroups := strings.Split(playURL, "$$$")
+	fromGroups := strings.Split(v.VodPlayFrom, "$$$")
+
+	groups := make([]PlayGroup, 0, len(urlGroups))
+	for i, raw := range urlGroups {
+		group := PlayGroup{Episodes: parseEpisodeList(raw)}
+		if i < len(fromGroups) {
+			group.From = fromGroups[i]
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// parseEpisodeList 解析 "第1集$url#第2集$url" 格式的剧集列表
+func parseEpisodeList(raw string) []models.EpisodeInfo {
+	episodes := make([]models.EpisodeInfo, 0)
+	for _, link := range strings.Split(raw, "#") {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+
+		title, playURL, found := strings.Cut(link, "$")
+		if !found {
+			// 部分站点只提供地址，没有标题
+			title, playURL = "", title
+		}
+		playURL = strings.TrimSpace(playURL)
+		if playURL == "" {
+			continue
+		}
+		if title == "" {
+			title = fmt.Sprintf("第%d集", len(episodes)+1)
+		}
+		episodes = append(episodes, models.EpisodeInfo{
+			Title: strings.TrimSpace(title),
+			Url:   playURL,
+		})
+	}
+	return episodes
+}
+
+// ClassifyClasses 将站点分类映射为统一分类，无法识别的子分类沿用父分类的大类
+func ClassifyClasses(siteKey string, classes []Class) map[string]taxonomy.Result {
+	byID := make(map[string]Class, len(classes))
+	for _, cls := range classes {
+		byID[string(cls.TypeID)] = cls
+	}
+
+	results := make(map[string]taxonomy.Result, len(classes))
+	var classify func(id string, depth int) taxonomy.Result
+	classify = func(id string, depth int) taxonomy.Result {
+		if result, ok := results[id]; ok {
+			return result
+		}
+		cls := byID[id]
+		result := taxonomy.Classify(siteKey, id, cls.TypeName)
+		parent := string(cls.TypePID)
+		// depth 限制防止 type_pid 成环
+		if result.Type == taxonomy.Other && depth < 5 && parent != "" && parent != "0" {
+			if _, ok := byID[parent]; ok {
+				result = taxonomy.Result{Type: classify(parent, depth+1).Type}
+			}
+		}
+		results[id] = result
+		return result
+	}
+
+	for id := range byID {
+		classify(id, 0)
+	}
+	return results
+}
+
+// CategoryTree 将扁平的分类列表按 type_pid 组装为树，并附带统一分类
+func CategoryTree(siteKey string, classes []Class) []models.Category {
+	canonical := ClassifyClasses(siteKey, classes)
+	children := make(map[string][]models.Category)
+	ids := make(map[string]bool, len(classes))
+	for _, cls := range classes {
+		ids[string(cls.TypeID)] = true
+	}
+
+	roots := make([]models.Category, 0)
+	for _, cls := range classes {
+		result := canonical[string(cls.TypeID)]
+		category := models.Category{
+			ID:             string(cls.TypeID),
+			Name:           strings.TrimSpace(cls.TypeName),
+			CanonicalType:  result.Type,
+			CanonicalGenre: result.Genre,
+		}
+		parent := string(cls.TypePID)
+		if parent != "" && parent != "0" && ids[parent] {
+			category.ParentID = parent
+			children[parent] = append(children[parent], category)
+			continue
+		}
+		roots = append(roots, category)
+	}
+
+	var attach func(category *models.Category)
+	attach = func(category *models.Category) {
+		category.Children = children[category.ID]
+		for i := range category.Children {
+			attach(&category.Children[i])
+		}
+	}
+	for i := range roots {
+		attach(&roots[i])
+	}
+	return roots
+}
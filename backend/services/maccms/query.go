@@ -0,0 +1,70 @@
+package maccms
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ReelNest/services/matcher"
+)
+
+// TimeLayout vod_time 的时间格式
+const TimeLayout = "2006-01-02 15:04:05"
+
+// Query 按 MacCMS 接口参数(ids、t、wd、h、pg)筛选并分页，供本地生成目录的数据来源使用
+// videos 应已按更新时间倒序排列
+func Query(videos []Video, params url.Values, pageSize int) *Response {
+	var ids map[string]bool
+	if raw := params.Get("ids"); raw != "" {
+		ids = make(map[string]bool)
+		for _, id := range strings.Split(raw, ",") {
+			ids[strings.TrimSpace(id)] = true
+		}
+	}
+	typeID := params.Get("t")
+	keyword := matcher.Normalize(params.Get("wd"))
+	var since string
+	if h, _ := strconv.Atoi(params.Get("h")); h > 0 {
+		since = time.Now().Add(-time.Duration(h) * time.Hour).Format(TimeLayout)
+	}
+
+	matched := make([]Video, 0, len(videos))
+	for _, v := range videos {
+		if ids != nil && !ids[string(v.VodID)] {
+			continue
+		}
+		if typeID != "" && string(v.TypeID) != typeID {
+			continue
+		}
+		if keyword != "" && !strings.Contains(matcher.Normalize(v.VodName), keyword) {
+			continue
+		}
+		if since != "" && v.VodTime < since {
+			continue
+		}
+		matched = append(matched, v)
+	}
+
+	page, _ := strconv.Atoi(params.Get("pg"))
+	if page < 1 {
+		page = 1
+	}
+	total := len(matched)
+	pageCount := (total + pageSize - 1) / pageSize
+	// 超出总页数时返回空页，避免页码过大时计算起点溢出
+	start, end := total, total
+	if page <= pageCount {
+		start = (page - 1) * pageSize
+		end = min(start+pageSize, total)
+	}
+	return &Response{
+		Code:      1,
+		Msg:       "数据列表",
+		Page:      FlexInt(page),
+		PageCount: FlexInt(pageCount),
+		Limit:     FlexInt(pageSize),
+		Total:     FlexInt(total),
+		List:      append([]Video{}, matched[start:end]...),
+	}
+}
@@ -0,0 +1,58 @@
+package maccms
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).Format(TimeLayout)
+	videos := []Video{
+		{VodID: "5", VodName: "慶餘年 第二季", TypeID: "2", VodTime: recent},
+		{VodID: "4", VodName: "狂飙", TypeID: "2", VodTime: "2024-01-04 00:00:00"},
+		{VodID: "3", VodName: "庆余年", TypeID: "2", VodTime: "2024-01-03 00:00:00"},
+		{VodID: "2", VodName: "流浪地球", TypeID: "1", VodTime: "2024-01-02 00:00:00"},
+		{VodID: "1", VodName: "Hero", TypeID: "1", VodTime: "2024-01-01 00:00:00"},
+	}
+
+	tests := []struct {
+		params    string
+		want      string
+		total     int
+		pageCount int
+	}{
+		{"", "5,4", 5, 3},
+		{"pg=3", "1", 5, 3},
+		{"pg=4", "", 5, 3},
+		{"pg=-1", "5,4", 5, 3},
+		{"pg=" + strconv.Itoa(math.MaxInt), "", 5, 3},
+		{"pg=" + strconv.Itoa(math.MaxInt/2), "", 5, 3},
+		{"ids=3, 1,9", "3,1", 2, 1},
+		{"t=1", "2,1", 2, 1},
+		{"wd=庆余年", "5,3", 2, 1},
+		{"wd=hero", "1", 1, 1},
+		{"h=24", "5", 1, 1},
+		{"wd=不存在", "", 0, 0},
+	}
+	for _, tt := range tests {
+		params, _ := url.ParseQuery(tt.params)
+		resp := Query(videos, params, 2)
+		got := ""
+		for i, v := range resp.List {
+			if i > 0 {
+				got += ","
+			}
+			got += string(v.VodID)
+		}
+		if got != tt.want || int(resp.Total) != tt.total || int(resp.PageCount) != tt.pageCount {
+			t.Errorf("Query(%q) = %s total %d pages %d, want %s total %d pages %d",
+				tt.params, got, resp.Total, resp.PageCount, tt.want, tt.total, tt.pageCount)
+		}
+		if resp.List == nil || resp.Limit != 2 {
+			t.Errorf("Query(%q) list = %v, limit = %d", tt.params, resp.List, resp.Limit)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package matcher
+
+import (
+	"strings"
+
+	"ReelNest/models"
+	"ReelNest/services/taxonomy"
+)
+
+// Item 待分组的单条结果
+type Item struct {
+	Info         models.VideoInfo
+	EpisodeCount int
+	HasM3U8      bool
+}
+
+// group 分组中间状态
+type group struct {
+	key   string
+	year  string
+	typ   string
+	items []Item
+}
+
+// Group 按 标题+年份+统一分类 将多源结果合并为作品，保持首次出现的顺序
+// 年份或分类缺失(或为 other)的条目视为通配，并入同标题的第一个分组
+func Group(items []Item) []models.Work {
+	var groups []*group
+	byKey := make(map[string][]*group)
+
+	for _, item := range items {
+		key := Key(item.Info.Title)
+		if key == "" {
+			continue
+		}
+		year := strings.TrimSpace(item.Info.Year)
+		if year == "0" {
+			year = ""
+		}
+		typ := item.Info.CanonicalType
+		if typ == taxonomy.Other {
+			typ = ""
+		}
+
+		var target *group
+		for _, g := range byKey[key] {
+			if compatible(g.year, year) && compatible(g.typ, typ) {
+				target = g
+				break
+			}
+		}
+		if target == nil {
+			target = &group{key: key}
+			groups = append(groups, target)
+			byKey[key] = append(byKey[key], target)
+		}
+
+		// 分组逐步补全年份与分类，之后的条目按补全后的值比较
+		if target.year == "" {
+			target.year = year
+		}
+		if target.typ == "" {
+			target.typ = typ
+		}
+		target.items = append(target.items, item)
+	}
+
+	works := make([]models.Work, 0, len(groups))
+	for _, g := range groups {
+		works = append(works, g.work())
+	}
+	return works
+}
+
+// compatible 空值与任意值兼容
+func compatible(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// work 生成作品，选取信息最完整的条目作为代表
+func (g *group) work() models.Work {
+	best := 0
+	for i := range g.items {
+		if completeness(g.items[i]) > completeness(g.items[best]) {
+			best = i
+		}
+	}
+
+	info := g.items[best].Info
+	if info.Year == "" {
+		info.Year = g.year
+	}
+
+	sources := make([]models.SourceHit, 0, len(g.items))
+	seen := make(map[string]bool, len(g.items))
+	for _, item := range g.items {
+		// 同一站点同一视频只保留一次
+		id := item.Info.SourceCode + "\x00" + item.Info.ID
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		sources = append(sources, models.SourceHit{
+			SourceCode:   item.Info.SourceCode,
+			SourceName:   item.Info.SourceName,
+			VideoID:      item.Info.ID,
+			Title:        item.Info.Title,
+			Remarks:      item.Info.Remarks,
+			UpdatedAt:    item.Info.UpdatedAt,
+			EpisodeCount: item.EpisodeCount,
+			HasM3U8:      item.HasM3U8,
+		})
+	}
+
+	key := g.key
+	if g.year != "" {
+		key += "|" + g.year
+	}
+	if g.typ != "" {
+		key += "|" + g.typ
+	}
+	return models.Work{Key: key, Info: info, Sources: sources}
+}
+
+// completeness 条目信息完整度，用于挑选代表条目
+func completeness(item Item) int {
+	score := 0
+	info := item.Info
+	if info.CoverUrl != "" {
+		score += 4
+	}
+	if info.Desc != "" {
+		score += 2
+	}
+	if info.Year != "" {
+		score++
+	}
+	if len(info.Actors) > 0 {
+		score++
+	}
+	if item.HasM3U8 {
+		score += 2
+	}
+	return score
+}
@@ -0,0 +1,112 @@
+package matcher
+
+import (
+	"testing"
+
+	"ReelNest/models"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"庆余年", "庆余年"},
+		{"慶餘年", "庆余年"},
+		{"庆余年（2019）", "庆余年"},
+		{"The Office", "theoffice"},
+		{"ＡＢＣ　１２３", "abc123"},
+		{"【高清】狂飙·特别版", "狂飙特别版"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in     string
+		base   string
+		season int
+	}{
+		{"庆余年", "庆余年", 1},
+		{"庆余年第二季", "庆余年", 2},
+		{"庆余年 第2季", "庆余年", 2},
+		{"庆余年2", "庆余年", 2},
+		{"庆余年 2", "庆余年", 2},
+		{"Friends Season 3", "friends", 3},
+		{"Friends S04", "friends", 4},
+		{"长安十二时辰", "长安十二时辰", 1},
+		{"2046", "2046", 1},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.in)
+		if got.Base != tt.base || got.Season != tt.season {
+			t.Errorf("Parse(%q) = %+v, want {%q %d}", tt.in, got, tt.base, tt.season)
+		}
+	}
+
+	if Key("庆余年") != Key("庆余年 第一季") {
+		t.Error("第一季与未标注季数应为同一作品")
+	}
+	if Key("庆余年") == Key("庆余年第二季") {
+		t.Error("不同季应为不同作品")
+	}
+}
+
+func TestEpisodeNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"第1集", 1},
+		{"第 12 话", 12},
+		{"EP05", 5},
+		{"ep.7", 7},
+		{"E08", 8},
+		{"３", 3},
+		{"正片", 0},
+		{"HD国语", 0},
+	}
+	for _, tt := range tests {
+		if got := EpisodeNumber(tt.in); got != tt.want {
+			t.Errorf("EpisodeNumber(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	item := func(source, id, title, year, typ, cover string) Item {
+		return Item{Info: models.VideoInfo{
+			ID: id, Title: title, SourceCode: source, Year: year, CanonicalType: typ, CoverUrl: cover,
+		}}
+	}
+	works := Group([]Item{
+		item("a", "1", "庆余年", "2019", "series", ""),
+		item("b", "9", "慶餘年", "", "", "cover.jpg"),
+		item("a", "1", "庆余年", "2019", "series", ""), // 同站点同视频重复出现
+		item("c", "5", "庆余年", "2024", "series", ""),
+		item("a", "2", "庆余年第二季", "2024", "series", ""),
+		item("d", "0", "！！", "", "", ""), // 规范化后为空，丢弃
+	})
+
+	if len(works) != 3 {
+		t.Fatalf("got %d works, want 3: %+v", len(works), works)
+	}
+	first := works[0]
+	if first.Key != "庆余年|2019|series" {
+		t.Errorf("first key = %q", first.Key)
+	}
+	if len(first.Sources) != 2 {
+		t.Errorf("first sources = %+v, want a/1 and b/9", first.Sources)
+	}
+	// 信息最完整的条目作为代表，缺失的年份由分组补全
+	if first.Info.SourceCode != "b" || first.Info.Year != "2019" {
+		t.Errorf("first info = %+v", first.Info)
+	}
+	if works[1].Key != "庆余年|2024|series" || works[2].Key != "庆余年#s2|2024|series" {
+		t.Errorf("keys = %q, %q", works[1].Key, works[2].Key)
+	}
+}
@@ -0,0 +1,135 @@
+package matcher
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ReelNest/services/zhconv"
+)
+
+// 预编译正则表达式以提高性能
+var (
+	episodeNumRegex  = regexp.MustCompile(`(?i)(?:第\s*(\d+)\s*[集话話期回])|(?:ep?\.?\s*(\d+))|^\s*(\d+)\s*$`)
+	bracketRegex     = regexp.MustCompile(`[\(（\[【][^\)）\]】]*[\)）\]】]`)
+	seasonRegex      = regexp.MustCompile(`(?i)(?:第\s*([0-9零一二三四五六七八九十两]+)\s*[季部]|season\s*(\d+)|\bs(\d{1,2})\b)`)
+	trailingNumRegex = regexp.MustCompile(`^(\D{2,}?)\s*([2-9]|1\d)$`)
+)
+
+// Title 解析后的标题
+type Title struct {
+	Base   string // 规范化后的主标题
+	Season int    // 季数，未标注时为 1
+}
+
+// Key 作品匹配键，第一季与未标注季数视为同一作品
+func (t Title) Key() string {
+	if t.Season <= 1 {
+		return t.Base
+	}
+	return t.Base + "#s" + strconv.Itoa(t.Season)
+}
+
+// Parse 解析标题：提取季数标记后再规范化主标题
+func Parse(title string) Title {
+	title = zhconv.ToSimplified(strings.Map(toHalfWidth, title))
+	season := 1
+
+	if m := seasonRegex.FindStringSubmatchIndex(title); m != nil {
+		for g := 1; g <= 3; g++ {
+			if m[2*g] >= 0 {
+				if n := parseNumber(title[m[2*g]:m[2*g+1]]); n > 0 {
+					season = n
+				}
+				break
+			}
+		}
+		title = title[:m[0]] + title[m[1]:]
+	} else if m := trailingNumRegex.FindStringSubmatch(strings.TrimSpace(bracketRegex.ReplaceAllString(title, ""))); m != nil {
+		// "庆余年2" 这类直接以数字结尾的续作
+		season, _ = strconv.Atoi(m[2])
+		title = m[1]
+	}
+
+	return Title{Base: Normalize(title), Season: season}
+}
+
+// Key 返回标题的作品匹配键
+func Key(title string) string {
+	return Parse(title).Key()
+}
+
+// Normalize 规范化标题用于比较：全角转半角、繁转简、去除标点空白和括号注释、统一小写
+func Normalize(title string) string {
+	title = zhconv.ToSimplified(bracketRegex.ReplaceAllString(title, ""))
+
+	var b strings.Builder
+	for _, r := range title {
+		r = toHalfWidth(r)
+		if unicode.IsPunct(r) || unicode.IsSpace(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// toHalfWidth 全角字符转半角
+func toHalfWidth(r rune) rune {
+	switch {
+	case r == 0x3000:
+		return ' '
+	case r >= 0xFF01 && r <= 0xFF5E:
+		return r - 0xFEE0
+	default:
+		return r
+	}
+}
+
+// chineseDigits 中文数字
+var chineseDigits = map[rune]int{
+	'零': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// parseNumber 解析阿拉伯数字或 99 以内的中文数字
+func parseNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+
+	total, current := 0, 0
+	for _, r := range s {
+		if r == '十' {
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+			continue
+		}
+		d, ok := chineseDigits[r]
+		if !ok {
+			return 0
+		}
+		current = d
+	}
+	return total + current
+}
+
+// EpisodeNumber 从剧集标题中解析集数，无法识别时返回 0
+func EpisodeNumber(title string) int {
+	title = strings.Map(toHalfWidth, title)
+	m := episodeNumRegex.FindStringSubmatch(title)
+	if m == nil {
+		return 0
+	}
+	for _, group := range m[1:] {
+		if group != "" {
+			n, _ := strconv.Atoi(group)
+			return n
+		}
+	}
+	return 0
+}
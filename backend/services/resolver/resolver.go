@@ -0,0 +1,327 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/models"
+	"ReelNest/utils"
+)
+
+const (
+	// MaxHops 最多跟随的跳转次数
+	MaxHops = 6
+	// maxPageBytes 单个网页最大读取字节数
+	maxPageBytes = 2 << 20
+	// resolveTimeout 整个解析过程的超时
+	resolveTimeout = 20 * time.Second
+	// maxCacheEntries 解析结果缓存上限
+	maxCacheEntries = 5000
+)
+
+// 媒体类型
+const (
+	TypeM3U8 = "m3u8"
+	TypeMP4  = "mp4"
+)
+
+// 预编译正则表达式以提高性能
+var (
+	playerRegex = regexp.MustCompile(`var\s+player_\w+\s*=\s*(\{[\s\S]*?\})\s*(?:;|</script>|\n)`)
+	iframeRegex = regexp.MustCompile(`(?i)<iframe[^>]+src\s*=\s*["']([^"']+)["']`)
+	mediaRegex  = regexp.MustCompile(`https?:(?:\\?/){2}[^"'\s<>]+?\.(?:m3u8|mp4)(?:\?[^"'\s<>]*)?`)
+)
+
+// ErrHopLimit 超过最大跳转次数仍未找到直链
+var ErrHopLimit = errors.New("超过最大跳转次数，未找到可播放地址")
+
+// ErrNoTarget 网页中没有可跟随的地址
+var ErrNoTarget = errors.New("网页中未找到播放地址或跳转")
+
+// playerConfig MacCMS 播放页中的 player_aaaa 配置
+type playerConfig struct {
+	URL     string          `json:"url"`
+	Encrypt json.RawMessage `json:"encrypt"`
+}
+
+// Resolver 分享页/播放页直链解析器
+type Resolver struct {
+	hc    *client.Client
+	cache *utils.TTLCache[models.ResolvedStream]
+}
+
+// NewResolver 创建解析器
+func NewResolver(hc *client.Client, ttl time.Duration) *Resolver {
+	return &Resolver{
+		hc:    hc,
+		cache: utils.NewTTLCache[models.ResolvedStream](ttl, maxCacheEntries),
+	}
+}
+
+// Resolve 跟随页面跳转、iframe 和播放器配置，直到找到 m3u8 或 mp4 直链
+func (r *Resolver) Resolve(ctx context.Context, pageURL, referer string) (models.ResolvedStream, error) {
+	cacheKey := pageURL + "\x00" + referer
+	if stream, ok := r.cache.Get(cacheKey); ok {
+		return stream, nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	stream, err := r.resolve(reqCtx, pageURL, referer)
+	if err != nil {
+		return stream, err
+	}
+	r.cache.Set(cacheKey, stream)
+	return stream, nil
+}
+
+func (r *Resolver) resolve(ctx context.Context, current, referer string) (models.ResolvedStream, error) {
+	userAgent := ""
+	hops := make([]string, 0, MaxHops)
+
+	for len(hops) < MaxHops {
+		hops = append(hops, current)
+
+		if mediaType := MediaType(current); mediaType != "" {
+			return buildStream(current, mediaType, referer, userAgent, hops), nil
+		}
+
+		page, err := r.fetch(ctx, current, referer, &userAgent)
+		if err != nil {
+			return models.ResolvedStream{Hops: hops}, fmt.Errorf("请求 %s 失败: %w", current, err)
+		}
+		if page.mediaType != "" {
+			return buildStream(current, page.mediaType, referer, userAgent, hops), nil
+		}
+		// HTTP 重定向同样计为一跳，Referer 保持不变
+		if page.location != "" {
+			next, err := resolveRef(current, page.location)
+			if err != nil {
+				return models.ResolvedStream{Hops: hops}, err
+			}
+			current = next
+			continue
+		}
+
+		next, ok := extractTarget(page.body)
+		if !ok {
+			return models.ResolvedStream{Hops: hops}, ErrNoTarget
+		}
+		next, err = resolveRef(current, next)
+		if err != nil {
+			return models.ResolvedStream{Hops: hops}, err
+		}
+		referer, current = current, next
+	}
+	return models.ResolvedStream{Hops: hops}, ErrHopLimit
+}
+
+// page 一次请求的结果
+type page struct {
+	body      string
+	mediaType string
+	// location HTTP 重定向的目标
+	location string
+}
+
+// fetch 请求页面，响应本身为媒体时只识别类型不读取全文，重定向时只返回 Location
+func (r *Resolver) fetch(ctx context.Context, pageURL, referer string, userAgent *string) (*page, error) {
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(pageURL)
+	req.SetMethod("GET")
+	utils.AddBrowserHeaders(req, referer)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	// 同一次解析保持相同的 User-Agent，返回给播放器时才能复现
+	if *userAgent == "" {
+		*userAgent = req.Header.Get("User-Agent")
+	} else {
+		req.Header.Set("User-Agent", *userAgent)
+	}
+
+	if err := r.hc.Do(ctx, req, resp); err != nil {
+		return nil, err
+	}
+	defer resp.CloseBodyStream()
+
+	if location := string(resp.Header.Peek("Location")); utils.IsRedirect(resp.StatusCode()) && location != "" {
+		return &page{location: location}, nil
+	}
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("状态码: %d", resp.StatusCode())
+	}
+
+	contentType := strings.ToLower(string(resp.Header.ContentType()))
+	switch {
+	case strings.Contains(contentType, "mpegurl"):
+		return &page{mediaType: TypeM3U8}, nil
+	case strings.HasPrefix(contentType, "video/"):
+		return &page{mediaType: TypeMP4}, nil
+	}
+
+	var reader io.Reader = bytes.NewReader(resp.Body())
+	if resp.IsBodyStream() {
+		reader = resp.BodyStream()
+	}
+	body, err := io.ReadAll(io.LimitReader(reader, maxPageBytes))
+	if err != nil {
+		return nil, err
+	}
+	if encoding := string(resp.Header.Peek("Content-Encoding")); encoding != "" {
+		decompressed, err := utils.DecompressBodyLimit(body, encoding, maxPageBytes)
+		if errors.Is(err, utils.ErrBodyTooLarge) {
+			return nil, err
+		}
+		if err == nil {
+			body = decompressed
+		}
+	}
+
+	// 部分服务器以 text/plain 返回 m3u8
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("#EXTM3U")) {
+		return &page{mediaType: TypeM3U8}, nil
+	}
+	return &page{body: string(body)}, nil
+}
+
+// extractTarget 按优先级从网页中提取下一跳地址：播放器配置、媒体直链、脚本跳转、iframe
+func extractTarget(html string) (string, bool) {
+	if m := playerRegex.FindStringSubmatch(html); m != nil {
+		if target, ok := parsePlayerConfig(m[1]); ok {
+			return target, true
+		}
+	}
+	if m := mediaRegex.FindString(html); m != "" {
+		return strings.ReplaceAll(m, `\/`, "/"), true
+	}
+	if target, ok := utils.ExtractRedirect(html); ok {
+		return target, true
+	}
+	if m := iframeRegex.FindStringSubmatch(html); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// parsePlayerConfig 解析 player_aaaa 配置中的播放地址，处理 encrypt 编码
+func parsePlayerConfig(raw string) (string, bool) {
+	var cfg playerConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil || cfg.URL == "" {
+		return "", false
+	}
+
+	target := cfg.URL
+	switch strings.Trim(string(cfg.Encrypt), `"`) {
+	case "1":
+		target = jsUnescape(target)
+	case "2":
+		decoded, err := base64.StdEncoding.DecodeString(target)
+		if err != nil {
+			return "", false
+		}
+		target = jsUnescape(string(decoded))
+	}
+
+	// 不是地址的值通常是需要第三方解析的标识，无法继续
+	if !strings.HasPrefix(target, "http") && !strings.HasPrefix(target, "/") {
+		return "", false
+	}
+	return target, true
+}
+
+// jsUnescape 实现 JavaScript unescape，支持 %XX 和 %uXXXX
+func jsUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+5 < len(s) && s[i+1] == 'u' {
+			if n, err := strconv.ParseUint(s[i+2:i+6], 16, 32); err == nil {
+				b.WriteRune(rune(n))
+				i += 5
+				continue
+			}
+		}
+		if i+2 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// MediaType 根据地址扩展名判断媒体类型，非媒体返回空字符串
+func MediaType(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	path := strings.ToLower(u.Path)
+	switch {
+	case strings.HasSuffix(path, ".m3u8"):
+		return TypeM3U8
+	case strings.HasSuffix(path, ".mp4"):
+		return TypeMP4
+	default:
+		return ""
+	}
+}
+
+// resolveRef 将相对地址解析为绝对地址，仅允许 http/https
+func resolveRef(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", fmt.Errorf("无效的跳转地址: %s", ref)
+	}
+	target := baseURL.ResolveReference(refURL)
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return "", fmt.Errorf("不支持的跳转协议: %s", target.Scheme)
+	}
+	return target.String(), nil
+}
+
+// buildStream 构建解析结果，附带播放器需要携带的请求头
+func buildStream(target, mediaType, referer, userAgent string, hops []string) models.ResolvedStream {
+	headers := make(map[string]string)
+	if userAgent != "" {
+		headers["User-Agent"] = userAgent
+	}
+	if referer != "" {
+		headers["Referer"] = referer
+		if u, err := url.Parse(referer); err == nil && u.Host != "" {
+			headers["Origin"] = u.Scheme + "://" + u.Host
+		}
+	}
+	return models.ResolvedStream{
+		Url:     target,
+		Type:    mediaType,
+		Headers: headers,
+		Hops:    hops,
+	}
+}
@@ -0,0 +1,53 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+)
+
+func TestResolveFollowsRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	// 分享短链 302 到播放页，播放页的播放器配置指向直链
+	mux.HandleFunc("/s/abc", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/play/1", http.StatusFound)
+	})
+	mux.HandleFunc("/play/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<script>var player_aaaa={"url":"\/media\/index.m3u8","encrypt":0};</script>`)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	hc, err := client.NewClient(client.WithResponseBodyStream(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewResolver(hc, time.Minute)
+
+	stream, err := r.Resolve(context.Background(), srv.URL+"/s/abc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.Url != srv.URL+"/media/index.m3u8" || stream.Type != TypeM3U8 {
+		t.Errorf("stream = %+v", stream)
+	}
+	want := []string{srv.URL + "/s/abc", srv.URL + "/play/1", srv.URL + "/media/index.m3u8"}
+	if strings.Join(stream.Hops, " ") != strings.Join(want, " ") {
+		t.Errorf("hops = %v, want %v", stream.Hops, want)
+	}
+
+	stream, err = r.Resolve(context.Background(), srv.URL+"/loop", "")
+	if !errors.Is(err, ErrHopLimit) || len(stream.Hops) != MaxHops {
+		t.Errorf("redirect loop = %d hops, %v", len(stream.Hops), err)
+	}
+}
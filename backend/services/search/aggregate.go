@@ -0,0 +1,208 @@
+package search
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/services/catalog"
+	"ReelNest/services/health"
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+	"ReelNest/utils"
+)
+
+const (
+	// siteTimeout 单个站点搜索超时，避免个别慢站拖慢整体
+	siteTimeout = 8 * time.Second
+	// maxCacheEntries 搜索结果缓存上限
+	maxCacheEntries = 2000
+	// localSearchLimit 本地目录单站返回的最大条数
+	localSearchLimit = 50
+)
+
+// Hit 单条搜索命中
+type Hit struct {
+	SiteKey string
+	Site    config.Site
+	Video   maccms.Video
+}
+
+// Item 转换为标题匹配器的输入
+func (h Hit) Item() matcher.Item {
+	episodes := h.Video.Episodes()
+	item := matcher.Item{
+		Info:         h.Video.Info(h.SiteKey, h.Site),
+		EpisodeCount: len(episodes),
+	}
+	for _, ep := range episodes {
+		if strings.Contains(strings.ToLower(ep.Url), ".m3u8") {
+			item.HasM3U8 = true
+			break
+		}
+	}
+	return item
+}
+
+// SiteResult 单个站点的搜索结果
+type SiteResult struct {
+	SiteKey string
+	Hits    []Hit
+	Err     error
+	Latency time.Duration
+}
+
+// Aggregator 多站点聚合搜索
+type Aggregator struct {
+	mac     *maccms.Client
+	tracker *health.Tracker
+	store   *catalog.Store
+	cache   *utils.TTLCache[[]maccms.Video]
+}
+
+// NewAggregator 创建聚合搜索器，ttl 为单站结果缓存时间，搜索结果同时计入站点健康统计
+// store 非空时，已完成同步的站点直接查询本地目录
+func NewAggregator(mac *maccms.Client, tracker *health.Tracker, store *catalog.Store, ttl time.Duration) *Aggregator {
+	return &Aggregator{
+		mac:     mac,
+		tracker: tracker,
+		store:   store,
+		cache:   utils.NewTTLCache[[]maccms.Video](ttl, maxCacheEntries),
+	}
+}
+
+// Tracker 返回站点健康统计器
+func (a *Aggregator) Tracker() *health.Tracker {
+	return a.tracker
+}
+
+// Search 并发搜索所有给定站点，结果按站点标识排序
+func (a *Aggregator) Search(ctx context.Context, keyword string, sites map[string]config.Site) []SiteResult {
+	keys := make([]string, 0, len(sites))
+	for key := range sites {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := make([]SiteResult, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			results[i] = a.searchSite(ctx, key, sites[key], keyword)
+		}(i, key)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// SearchAll 并发搜索多个搜索词并按站点合并结果，同一站点同一视频只保留一次
+func (a *Aggregator) SearchAll(ctx context.Context, keywords []string, sites map[string]config.Site) []SiteResult {
+	if len(keywords) == 1 {
+		return a.Search(ctx, keywords[0], sites)
+	}
+
+	perKeyword := make([][]SiteResult, len(keywords))
+	var wg sync.WaitGroup
+	for i, keyword := range keywords {
+		wg.Add(1)
+		go func(i int, keyword string) {
+			defer wg.Done()
+			perKeyword[i] = a.Search(ctx, keyword, sites)
+		}(i, keyword)
+	}
+	wg.Wait()
+
+	// 各搜索词的结果均按站点标识排序，下标一一对应
+	merged := make([]SiteResult, len(perKeyword[0]))
+	for i := range merged {
+		merged[i].SiteKey = perKeyword[0][i].SiteKey
+		seen := make(map[string]bool)
+		failed := 0
+		for _, results := range perKeyword {
+			r := results[i]
+			merged[i].Latency = max(merged[i].Latency, r.Latency)
+			if r.Err != nil {
+				failed++
+				merged[i].Err = r.Err
+				continue
+			}
+			for _, hit := range r.Hits {
+				id := string(hit.Video.VodID)
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				merged[i].Hits = append(merged[i].Hits, hit)
+			}
+		}
+		// 部分搜索词成功时不视为站点失败
+		if failed < len(perKeyword) {
+			merged[i].Err = nil
+		}
+	}
+	return merged
+}
+
+// searchSite 搜索单个站点，优先使用本地目录，其次缓存，最后实时请求
+func (a *Aggregator) searchSite(ctx context.Context, key string, site config.Site, keyword string) SiteResult {
+	result := SiteResult{SiteKey: key}
+	cacheKey := key + "\x00" + keyword
+
+	videos, ok := a.searchLocal(key, site, keyword)
+	if !ok {
+		videos, ok = a.cache.Get(cacheKey)
+	}
+	if !ok {
+		reqCtx, cancel := context.WithTimeout(ctx, siteTimeout)
+		defer cancel()
+
+		start := time.Now()
+		resp, err := a.mac.Search(reqCtx, site, keyword, 1)
+		result.Latency = time.Since(start)
+		// 调用方主动取消不计入站点失败
+		if ctx.Err() == nil {
+			a.tracker.Record(key, result.Latency, err)
+		}
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		videos = resp.List
+		a.cache.Set(cacheKey, videos)
+	}
+
+	for _, v := range videos {
+		result.Hits = append(result.Hits, Hit{SiteKey: key, Site: site, Video: v})
+	}
+	return result
+}
+
+// searchLocal 在本地目录中搜索，站点未完成同步、目录过期、查询失败或没有结果时返回 false
+// 没有结果时改为实时请求，避免漏掉同步之后新增的视频
+func (a *Aggregator) searchLocal(key string, site config.Site, keyword string) ([]maccms.Video, bool) {
+	if a.store == nil || !catalog.Crawlable(site) || !a.store.Synced(key) {
+		return nil, false
+	}
+	videos, err := a.store.Search(key, keyword, localSearchLimit)
+	if err != nil {
+		log.Printf("本地搜索站点 %s 失败: %v", key, err)
+		return nil, false
+	}
+	return videos, len(videos) > 0
+}
+
+// Hits 合并所有站点的命中结果
+func Hits(results []SiteResult) []Hit {
+	var hits []Hit
+	for _, r := range results {
+		hits = append(hits, r.Hits...)
+	}
+	return hits
+}
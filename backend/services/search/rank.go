@@ -0,0 +1,178 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"ReelNest/models"
+	"ReelNest/services/health"
+	"ReelNest/services/matcher"
+	"ReelNest/services/taxonomy"
+)
+
+// 排序权重
+const (
+	weightExact      = 100
+	weightSameBase   = 80 // 主标题相同，季数不同
+	weightPrefix     = 60
+	weightContains   = 40
+	weightHealth     = 20
+	weightRecentWeek = 15
+	weightRecentMon  = 10
+	weightRecentYear = 5
+	weightCover      = 5
+	weightEpisodes   = 5
+	weightPerSource  = 2
+	maxSourceBonus   = 5
+)
+
+// vodTimeLayout MacCMS vod_time 格式
+const vodTimeLayout = "2006-01-02 15:04:05"
+
+// Filter 搜索结果筛选条件
+type Filter struct {
+	Year      string
+	Area      string
+	Canonical string
+	HasM3U8   bool
+}
+
+// Apply 按条件筛选作品，HasM3U8 时同时去掉不含 m3u8 的来源
+func (f Filter) Apply(works []models.Work) []models.Work {
+	result := make([]models.Work, 0, len(works))
+	for _, w := range works {
+		if f.Year != "" && w.Info.Year != f.Year {
+			continue
+		}
+		if f.Area != "" && !strings.Contains(w.Info.Area, f.Area) {
+			continue
+		}
+		if !(taxonomy.Result{Type: w.Info.CanonicalType, Genre: w.Info.CanonicalGenre}).Matches(f.Canonical) {
+			continue
+		}
+		if f.HasM3U8 {
+			sources := make([]models.SourceHit, 0, len(w.Sources))
+			for _, s := range w.Sources {
+				if s.HasM3U8 {
+					sources = append(sources, s)
+				}
+			}
+			if len(sources) == 0 {
+				continue
+			}
+			w.Sources = sources
+		}
+		result = append(result, w)
+	}
+	return result
+}
+
+// Rank 计算相关度得分并排序，来源按健康度排序
+// queries 为原搜索词及其扩展词，标题得分取各搜索词中的最高分
+func Rank(queries []string, works []models.Work, tracker *health.Tracker) []models.Work {
+	parsed := make([]matcher.Title, 0, len(queries))
+	for _, q := range queries {
+		parsed = append(parsed, matcher.Parse(q))
+	}
+	now := time.Now()
+
+	for i := range works {
+		w := &works[i]
+		if tracker != nil {
+			sort.SliceStable(w.Sources, func(a, b int) bool {
+				return tracker.Score(w.Sources[a].SourceCode) > tracker.Score(w.Sources[b].SourceCode)
+			})
+		}
+		t := matcher.Parse(w.Info.Title)
+		best := 0.0
+		for _, q := range parsed {
+			best = max(best, titleScore(q, t))
+		}
+		w.Score = best + qualityScore(w, tracker, now)
+	}
+
+	sort.SliceStable(works, func(i, j int) bool {
+		return works[i].Score > works[j].Score
+	})
+	return works
+}
+
+// titleScore 标题匹配得分
+func titleScore(q, t matcher.Title) float64 {
+	query, base := q.Base, t.Base
+	switch {
+	case query == "":
+		return 0
+	case t.Key() == q.Key():
+		return weightExact
+	case base == query:
+		return weightSameBase
+	case strings.HasPrefix(base, query):
+		return weightPrefix
+	case strings.Contains(base, query):
+		return weightContains
+	default:
+		return 0
+	}
+}
+
+// qualityScore 来源健康、更新时间与信息完整度得分
+func qualityScore(w *models.Work, tracker *health.Tracker, now time.Time) float64 {
+	score := 0.0
+
+	bestHealth, maxEpisodes := 0.0, 0
+	var latest time.Time
+	for _, s := range w.Sources {
+		if tracker != nil {
+			bestHealth = max(bestHealth, tracker.Score(s.SourceCode))
+		}
+		maxEpisodes = max(maxEpisodes, s.EpisodeCount)
+		if t, err := time.ParseInLocation(vodTimeLayout, s.UpdatedAt, time.Local); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	score += bestHealth * weightHealth
+
+	if !latest.IsZero() {
+		switch age := now.Sub(latest); {
+		case age <= 7*24*time.Hour:
+			score += weightRecentWeek
+		case age <= 30*24*time.Hour:
+			score += weightRecentMon
+		case age <= 365*24*time.Hour:
+			score += weightRecentYear
+		}
+	}
+
+	if w.Info.CoverUrl != "" {
+		score += weightCover
+	}
+	if maxEpisodes > 0 {
+		score += weightEpisodes
+	}
+	score += float64(min(len(w.Sources), maxSourceBonus) * weightPerSource)
+	return score
+}
+
+// Paginate 对合并后的结果分页，返回当前页和总页数
+func Paginate(works []models.Work, page, size int) ([]models.Work, int) {
+	if size <= 0 {
+		size = 20
+	}
+	// 不用 len+size-1 计算总页数，避免 size 过大时溢出
+	pageCount := 0
+	if len(works) > 0 {
+		pageCount = (len(works)-1)/size + 1
+	}
+	// 超出总页数时直接返回空页，避免页码过大时计算起点溢出
+	if page < 1 {
+		page = 1
+	}
+	if page > pageCount {
+		return []models.Work{}, pageCount
+	}
+	start := (page - 1) * size
+	end := min(start+size, len(works))
+	return works[start:end], pageCount
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"math"
+	"testing"
+
+	"ReelNest/models"
+)
+
+func TestPaginate(t *testing.T) {
+	works := make([]models.Work, 5)
+	for i := range works {
+		works[i].Key = string(rune('a' + i))
+	}
+
+	tests := []struct {
+		page, size int
+		first      string
+		n, count   int
+	}{
+		{1, 2, "a", 2, 3},
+		{3, 2, "e", 1, 3},
+		{4, 2, "", 0, 3},
+		{0, 2, "a", 2, 3},
+		{-1, 0, "a", 5, 1},
+		{math.MaxInt, 20, "", 0, 1},
+		{math.MaxInt / 20, 20, "", 0, 1},
+		{1, math.MaxInt, "a", 5, 1},
+		{2, math.MaxInt, "", 0, 1},
+	}
+	for _, tt := range tests {
+		got, count := Paginate(works, tt.page, tt.size)
+		if len(got) != tt.n || count != tt.count || (tt.n > 0 && got[0].Key != tt.first) {
+			t.Errorf("Paginate(%d, %d) = %d items from %v, %d pages; want %d from %q, %d pages",
+				tt.page, tt.size, len(got), got, count, tt.n, tt.first, tt.count)
+		}
+		if got == nil {
+			t.Errorf("Paginate(%d, %d) returned nil", tt.page, tt.size)
+		}
+	}
+
+	if got, count := Paginate(nil, 1, 20); len(got) != 0 || count != 0 {
+		t.Errorf("empty = %v, %d", got, count)
+	}
+}
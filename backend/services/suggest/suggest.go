@@ -0,0 +1,361 @@
+package suggest
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ReelNest/services/expand"
+	"ReelNest/services/matcher"
+	"ReelNest/services/zhconv"
+)
+
+const (
+	// maxEntries 索引容量上限
+	maxEntries = 100000
+	// evictBatch 达到上限时一次淘汰的候选词数，避免每加入一个词都重新排序
+	evictBatch = maxEntries / 10
+	// maxVisit 单次查询最多遍历的节点数，保证长尾前缀也能快速返回
+	maxVisit = 5000
+	// queryWeight 一次搜索相当于多少次标题出现
+	queryWeight = 3
+)
+
+// entry 候选词
+type entry struct {
+	title   string
+	seen    int      // 出现在搜索结果或详情中的次数
+	queries int      // 被搜索的次数
+	used    int64    // 最近一次出现或被搜索的时间(Unix 秒)
+	keys    []string // 挂在前缀树上的键，淘汰时按此删除
+}
+
+func (e *entry) score() int {
+	return e.seen + e.queries*queryWeight
+}
+
+// savedEntry 候选词的持久化格式
+type savedEntry struct {
+	Title   string `json:"title"`
+	Seen    int    `json:"seen,omitempty"`
+	Queries int    `json:"queries,omitempty"`
+	Used    int64  `json:"used,omitempty"`
+}
+
+// node 前缀树节点
+type node struct {
+	children map[rune]*node
+	entries  []int // 以该节点结尾的候选词下标
+}
+
+// Index 搜索联想索引：标题的中文、全拼、拼音首字母均可作为前缀
+// 达到容量上限时淘汰得分最低、最久未用的候选词，可持久化到磁盘
+type Index struct {
+	mu      sync.RWMutex
+	path    string
+	root    *node
+	entries []*entry       // 已淘汰的位置为 nil，记录在 free 中供复用
+	free    []int          // 空闲下标
+	byKey   map[string]int // 规范化标题 -> 候选词下标
+	dirty   bool
+}
+
+// NewIndex 创建搜索联想索引，path 非空时从该文件加载已有索引
+func NewIndex(path string) (*Index, error) {
+	x := &Index{
+		path:  path,
+		root:  &node{},
+		byKey: make(map[string]int),
+	}
+	if path == "" {
+		return x, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []savedEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		if e := x.entry(s.Title); e != nil {
+			e.seen, e.queries, e.used = s.Seen, s.Queries, s.Used
+		}
+	}
+	x.dirty = false
+	return x, nil
+}
+
+// Len 索引中的候选词数
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.byKey)
+}
+
+// Seed 加入目录中的标题作为候选词，不计入出现次数
+func (x *Index) Seed(titles ...string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, title := range titles {
+		x.entry(title)
+	}
+}
+
+// AddTitles 记录在搜索结果或详情中出现的标题
+func (x *Index) AddTitles(titles ...string) {
+	now := time.Now().Unix()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, title := range titles {
+		if e := x.entry(title); e != nil {
+			e.seen++
+			e.used = now
+			x.dirty = true
+		}
+	}
+}
+
+// RecordQuery 记录一次有结果的搜索，拼音搜索词不作为候选词
+func (x *Index) RecordQuery(query string) {
+	if expand.IsPinyin(query) {
+		return
+	}
+	now := time.Now().Unix()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if e := x.entry(query); e != nil {
+		e.queries++
+		e.used = now
+		x.dirty = true
+	}
+}
+
+// entry 获取或创建候选词，调用方需持有写锁
+func (x *Index) entry(title string) *entry {
+	title = zhconv.ToSimplified(strings.TrimSpace(title))
+	key := matcher.Normalize(title)
+	if key == "" {
+		return nil
+	}
+	if i, ok := x.byKey[key]; ok {
+		return x.entries[i]
+	}
+	if len(x.byKey) >= maxEntries {
+		x.evict()
+	}
+
+	e := &entry{title: title, used: time.Now().Unix()}
+	full, initials := expand.Romanize(title)
+	for _, k := range []string{key, full, initials} {
+		if k != "" {
+			e.keys = append(e.keys, k)
+		}
+	}
+
+	var i int
+	if n := len(x.free); n > 0 {
+		i, x.free = x.free[n-1], x.free[:n-1]
+		x.entries[i] = e
+	} else {
+		i = len(x.entries)
+		x.entries = append(x.entries, e)
+	}
+	x.byKey[key] = i
+	for _, k := range e.keys {
+		x.insert(k, i)
+	}
+	x.dirty = true
+	return e
+}
+
+// evict 淘汰得分最低的一批候选词，同分时先淘汰最久未用的，调用方需持有写锁
+func (x *Index) evict() {
+	live := make([]int, 0, len(x.byKey))
+	for _, i := range x.byKey {
+		live = append(live, i)
+	}
+	sort.Slice(live, func(a, b int) bool {
+		ea, eb := x.entries[live[a]], x.entries[live[b]]
+		if ea.score() != eb.score() {
+			return ea.score() < eb.score()
+		}
+		return ea.used < eb.used
+	})
+	for _, i := range live[:min(evictBatch, len(live))] {
+		e := x.entries[i]
+		for _, k := range e.keys {
+			x.remove(k, i)
+		}
+		delete(x.byKey, e.keys[0])
+		x.entries[i] = nil
+		x.free = append(x.free, i)
+	}
+}
+
+// insert 将候选词挂到 key 对应的节点，调用方需持有写锁
+func (x *Index) insert(key string, i int) {
+	n := x.root
+	for _, r := range key {
+		if n.children == nil {
+			n.children = make(map[rune]*node)
+		}
+		child, ok := n.children[r]
+		if !ok {
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	for _, existing := range n.entries {
+		if existing == i {
+			return
+		}
+	}
+	n.entries = append(n.entries, i)
+}
+
+// remove 从 key 对应的节点摘除候选词，并删除因此变空的节点，调用方需持有写锁
+func (x *Index) remove(key string, i int) {
+	runes := []rune(key)
+	path := make([]*node, 0, len(runes)+1)
+	n := x.root
+	path = append(path, n)
+	for _, r := range runes {
+		if n = n.children[r]; n == nil {
+			return
+		}
+		path = append(path, n)
+	}
+	for j, existing := range n.entries {
+		if existing == i {
+			n.entries = append(n.entries[:j], n.entries[j+1:]...)
+			break
+		}
+	}
+	for d := len(runes); d > 0; d-- {
+		cur := path[d]
+		if len(cur.entries) > 0 || len(cur.children) > 0 {
+			break
+		}
+		delete(path[d-1].children, runes[d-1])
+	}
+}
+
+// Suggest 返回以 prefix 开头的候选标题，按搜索与出现次数排序
+func (x *Index) Suggest(prefix string, limit int) []string {
+	key := matcher.Normalize(prefix)
+	if key == "" || limit <= 0 {
+		return []string{}
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	n := x.root
+	for _, r := range key {
+		if n = n.children[r]; n == nil {
+			return []string{}
+		}
+	}
+
+	// 广度优先遍历，较短的词先被收集
+	seen := make(map[int]bool)
+	var found []*entry
+	queue := []*node{n}
+	for visited := 0; len(queue) > 0 && visited < maxVisit; visited++ {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, i := range cur.entries {
+			if !seen[i] {
+				seen[i] = true
+				found = append(found, x.entries[i])
+			}
+		}
+		for _, child := range cur.children {
+			queue = append(queue, child)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.score() != b.score() {
+			return a.score() > b.score()
+		}
+		if la, lb := len([]rune(a.title)), len([]rune(b.title)); la != lb {
+			return la < lb
+		}
+		return a.title < b.title
+	})
+
+	result := make([]string, 0, min(limit, len(found)))
+	for _, e := range found[:min(limit, len(found))] {
+		result = append(result, e.title)
+	}
+	return result
+}
+
+// Save 将索引写回磁盘，无变更时跳过；写入失败时保留变更标记，下次重试
+func (x *Index) Save() error {
+	if x.path == "" {
+		return nil
+	}
+
+	x.mu.Lock()
+	if !x.dirty {
+		x.mu.Unlock()
+		return nil
+	}
+	list := make([]savedEntry, 0, len(x.byKey))
+	for _, e := range x.entries {
+		if e != nil {
+			list = append(list, savedEntry{Title: e.title, Seen: e.seen, Queries: e.queries, Used: e.used})
+		}
+	}
+	x.dirty = false
+	x.mu.Unlock()
+
+	if err := writeFile(x.path, list); err != nil {
+		x.mu.Lock()
+		x.dirty = true
+		x.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeFile 先写临时文件再重命名，保证原子性
+func writeFile(path string, list []savedEntry) error {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
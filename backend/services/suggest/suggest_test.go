@@ -0,0 +1,66 @@
+package suggest
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	x, _ := NewIndex("")
+	x.Seed("庆余年", "青云志")
+	x.AddTitles("庆余年第二季", "庆余年第二季")
+	x.RecordQuery("庆余年")
+	x.RecordQuery("qingyunian") // 拼音搜索词不作为候选词
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"庆余", []string{"庆余年", "庆余年第二季"}},
+		{"慶餘", []string{"庆余年", "庆余年第二季"}},
+		{"qing", []string{"庆余年", "庆余年第二季", "青云志"}},
+		{"qyn", []string{"庆余年", "庆余年第二季"}},
+		{"qingyunian", []string{"庆余年", "庆余年第二季"}},
+		{"不存在", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := x.Suggest(tt.prefix, 5); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+	if got := x.Suggest("q", 1); len(got) != 1 {
+		t.Errorf("limit not applied: %v", got)
+	}
+	if x.Len() != 3 {
+		t.Errorf("Len = %d, want 3", x.Len())
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suggest.json")
+	x, err := NewIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Seed("青云志")
+	x.AddTitles("庆余年")
+	x.RecordQuery("狂飙")
+	if err := x.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 3 {
+		t.Fatalf("loaded Len = %d, want 3", loaded.Len())
+	}
+	// 得分随索引一起保存：搜索过的词排在只出现过的词之前
+	loaded.Seed("狂飙突进")
+	if got := loaded.Suggest("kuang", 5); !reflect.DeepEqual(got, []string{"狂飙", "狂飙突进"}) {
+		t.Errorf("loaded order = %v", got)
+	}
+}
@@ -0,0 +1,110 @@
+package taxonomy
+
+type typeRule struct {
+	typ      string
+	keywords []string
+}
+
+type genreRule struct {
+	id       string
+	label    string
+	keywords []string
+}
+
+// typeKeywords 内置大类关键词，按顺序匹配
+// 顺序很重要: "动画片"应归为动漫、"剧情片"应归为电影，因此短剧/纪录/综艺/动漫/电影先于连续剧
+var typeKeywords = []typeRule{
+	{ShortDrama, []string{"短剧", "微剧", "爽剧", "爽文"}},
+	{Documentary, []string{"纪录", "记录片", "纪实"}},
+	{Variety, []string{"综艺", "真人秀", "晚会", "脱口秀", "选秀"}},
+	{Anime, []string{"动漫", "动画", "番剧", "新番", "国漫", "日漫", "卡通"}},
+	{Movie, []string{"电影", "片", "影"}},
+	{Series, []string{"剧", "连续", "电视"}},
+}
+
+// genreKeywords 各大类的子类关键词
+var genreKeywords = map[string][]genreRule{
+	Movie: {
+		{"action", "动作", []string{"动作", "武侠", "功夫"}},
+		{"comedy", "喜剧", []string{"喜剧"}},
+		{"romance", "爱情", []string{"爱情"}},
+		{"scifi", "科幻", []string{"科幻"}},
+		{"horror", "恐怖", []string{"恐怖", "惊悚"}},
+		{"thriller", "悬疑", []string{"悬疑", "推理"}},
+		{"crime", "犯罪", []string{"犯罪", "警匪"}},
+		{"war", "战争", []string{"战争", "军事"}},
+		{"fantasy", "奇幻", []string{"奇幻", "魔幻"}},
+		{"drama", "剧情", []string{"剧情", "伦理"}},
+	},
+	Series: {
+		{"mainland", "国产剧", []string{"国产", "大陆", "内地", "国剧"}},
+		{"hk", "港剧", []string{"香港", "港剧", "港"}},
+		{"tw", "台剧", []string{"台湾", "台剧", "台"}},
+		{"kr", "韩剧", []string{"韩国", "韩剧", "韩"}},
+		{"jp", "日剧", []string{"日本", "日剧", "日"}},
+		{"western", "欧美剧", []string{"欧美", "美剧", "英剧", "美国", "英国"}},
+		{"th", "泰剧", []string{"泰国", "泰剧", "泰"}},
+		{"overseas", "海外剧", []string{"海外"}},
+	},
+	Anime: {
+		{"cn", "国产动漫", []string{"国产", "国漫", "大陆", "中国"}},
+		{"jp", "日本动漫", []string{"日本", "日漫", "日韩", "番"}},
+		{"western", "欧美动漫", []string{"欧美", "美国"}},
+		{"movie", "动画电影", []string{"动画片", "动画电影", "剧场版"}},
+	},
+	Variety: {
+		{"mainland", "大陆综艺", []string{"大陆", "内地", "国产"}},
+		{"hk_tw", "港台综艺", []string{"港台", "香港", "台湾"}},
+		{"kr", "韩国综艺", []string{"韩国", "日韩", "韩"}},
+		{"jp", "日本综艺", []string{"日本"}},
+		{"western", "欧美综艺", []string{"欧美"}},
+	},
+	Documentary: {
+		{"nature", "自然", []string{"自然", "动物", "地理"}},
+		{"history", "历史", []string{"历史", "人文"}},
+		{"science", "科技", []string{"科技", "科学", "探索"}},
+	},
+	ShortDrama: {
+		{"romance", "甜宠", []string{"甜宠", "言情", "恋爱"}},
+		{"revenge", "逆袭", []string{"逆袭", "复仇", "战神"}},
+		{"costume", "古装", []string{"古装", "穿越"}},
+	},
+}
+
+// typeLabels 大类中文名称
+var typeLabels = map[string]string{
+	Movie:       "电影",
+	Series:      "连续剧",
+	Anime:       "动漫",
+	Variety:     "综艺",
+	Documentary: "纪录片",
+	ShortDrama:  "短剧",
+	Other:       "其他",
+}
+
+// Genre 子类描述
+type Genre struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// TypeInfo 大类描述
+type TypeInfo struct {
+	ID     string  `json:"id"`
+	Label  string  `json:"label"`
+	Genres []Genre `json:"genres,omitempty"`
+}
+
+// Types 返回统一分类体系
+func Types() []TypeInfo {
+	order := []string{Movie, Series, Anime, Variety, Documentary, ShortDrama, Other}
+	types := make([]TypeInfo, 0, len(order))
+	for _, typ := range order {
+		info := TypeInfo{ID: typ, Label: typeLabels[typ]}
+		for _, genre := range genreKeywords[typ] {
+			info.Genres = append(info.Genres, Genre{ID: genre.id, Label: genre.label})
+		}
+		types = append(types, info)
+	}
+	return types
+}
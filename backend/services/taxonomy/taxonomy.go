@@ -0,0 +1,159 @@
+package taxonomy
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 统一大类
+const (
+	Movie       = "movie"
+	Series      = "series"
+	Anime       = "anime"
+	Variety     = "variety"
+	Documentary = "documentary"
+	ShortDrama  = "short_drama"
+	Other       = "other"
+)
+
+// Result 分类结果
+type Result struct {
+	Type  string `json:"type"`
+	Genre string `json:"genre,omitempty"`
+}
+
+// String 以 "type/genre" 形式表示
+func (r Result) String() string {
+	if r.Genre == "" {
+		return r.Type
+	}
+	return r.Type + "/" + r.Genre
+}
+
+// Matches 判断是否满足筛选条件，filter 可以是 "series" 或 "series/kr"
+func (r Result) Matches(filter string) bool {
+	if filter == "" {
+		return true
+	}
+	f := ParseResult(filter)
+	if f.Type != r.Type {
+		return false
+	}
+	return f.Genre == "" || f.Genre == r.Genre
+}
+
+// ParseResult 解析 "type/genre" 字符串
+func ParseResult(s string) Result {
+	typ, genre, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "/")
+	return Result{Type: typ, Genre: genre}
+}
+
+// KeywordRule 关键词分类规则
+type KeywordRule struct {
+	Keyword string `json:"keyword"`
+	Type    string `json:"type"` // "type" 或 "type/genre"
+}
+
+// Rules 可配置的映射规则
+type Rules struct {
+	// Sites 站点级映射: 站点标识 -> type_id -> "type/genre"
+	Sites map[string]map[string]string `json:"sites"`
+	// Names 分类名精确映射: type_name -> "type/genre"
+	Names map[string]string `json:"names"`
+	// Keywords 额外关键词规则，优先于内置规则
+	Keywords []KeywordRule `json:"keywords"`
+}
+
+var (
+	rules     Rules
+	rulesLock sync.RWMutex
+)
+
+// Load 从文件加载映射规则，文件不存在时仅使用内置关键词规则
+func Load(path string) error {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+
+	rules = Rules{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("分类映射文件 %s 不存在，仅使用内置规则", path)
+			return nil
+		}
+		return fmt.Errorf("读取分类映射文件失败: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("解析分类映射文件失败: %w", err)
+	}
+	log.Printf("成功加载分类映射: %d 个站点规则, %d 个名称规则, %d 个关键词规则",
+		len(rules.Sites), len(rules.Names), len(rules.Keywords))
+	return nil
+}
+
+// Classify 将站点分类映射为统一分类
+// 优先级: 站点 type_id 规则 > 分类名精确规则 > 自定义关键词 > 内置关键词
+// hints 为辅助信息(如 vod_class)，仅在分类名无法确定子类时用于推断子类
+func Classify(siteKey, typeID, typeName string, hints ...string) Result {
+	typeName = strings.TrimSpace(typeName)
+
+	rulesLock.RLock()
+	if mapped, ok := rules.Sites[siteKey][typeID]; ok && typeID != "" {
+		rulesLock.RUnlock()
+		return ParseResult(mapped)
+	}
+	if mapped, ok := rules.Names[typeName]; ok {
+		rulesLock.RUnlock()
+		return ParseResult(mapped)
+	}
+	for _, rule := range rules.Keywords {
+		if rule.Keyword != "" && strings.Contains(typeName, rule.Keyword) {
+			rulesLock.RUnlock()
+			return ParseResult(rule.Type)
+		}
+	}
+	rulesLock.RUnlock()
+
+	result := classifyByKeyword(typeName)
+	if result.Genre == "" && result.Type != Other {
+		for _, hint := range hints {
+			if genre := detectGenre(result.Type, hint); genre != "" {
+				result.Genre = genre
+				break
+			}
+		}
+	}
+	return result
+}
+
+// classifyByKeyword 使用内置关键词规则分类
+func classifyByKeyword(name string) Result {
+	if name == "" {
+		return Result{Type: Other}
+	}
+	for _, rule := range typeKeywords {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(name, keyword) {
+				return Result{Type: rule.typ, Genre: detectGenre(rule.typ, name)}
+			}
+		}
+	}
+	return Result{Type: Other}
+}
+
+// detectGenre 根据名称推断子类
+func detectGenre(typ, name string) string {
+	for _, genre := range genreKeywords[typ] {
+		for _, keyword := range genre.keywords {
+			if strings.Contains(name, keyword) {
+				return genre.id
+			}
+		}
+	}
+	return ""
+}
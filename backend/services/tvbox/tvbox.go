@@ -0,0 +1,131 @@
+package tvbox
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"ReelNest/config"
+	"ReelNest/services/health"
+	"ReelNest/services/maccms"
+)
+
+// 站点类型
+const (
+	SiteTypeXML  = 0
+	SiteTypeJSON = 1
+)
+
+// 解析类型
+const (
+	ParseTypeSniff = 0
+	ParseTypeJSON  = 1
+)
+
+// Site TVBox 站点
+type Site struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Type        int    `json:"type"`
+	API         string `json:"api"`
+	Searchable  int    `json:"searchable"`
+	QuickSearch int    `json:"quickSearch"`
+	Filterable  int    `json:"filterable"`
+}
+
+// Parse TVBox 解析接口
+type Parse struct {
+	Name string `json:"name"`
+	Type int    `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Live TVBox 直播源
+type Live struct {
+	Name string `json:"name"`
+	Type int    `json:"type"`
+	URL  string `json:"url"`
+	EPG  string `json:"epg,omitempty"`
+}
+
+// Config TVBox 配置
+type Config struct {
+	Spider string  `json:"spider"`
+	Sites  []Site  `json:"sites"`
+	Parses []Parse `json:"parses"`
+	Lives  []Live  `json:"lives"`
+}
+
+// Options 生成配置的选项
+type Options struct {
+	BaseURL      string // 本服务对外地址，用于聚合源与解析接口
+	IncludeAdult bool
+	IncludeDown  bool // 是否保留当前不可用的站点
+}
+
+// Build 根据站点配置生成 TVBox 配置
+// 第一个站点为本服务的聚合虚拟源，其后为各个已启用的原始站点
+func Build(tracker *health.Tracker, opts Options) Config {
+	base := strings.TrimRight(opts.BaseURL, "/")
+	cfg := Config{
+		Sites: []Site{{
+			Key:         "reelnest",
+			Name:        "ReelNest 聚合",
+			Type:        SiteTypeJSON,
+			API:         base + "/" + maccms.APIPath,
+			Searchable:  1,
+			QuickSearch: 1,
+			Filterable:  1,
+		}},
+		Parses: []Parse{{
+			Name: "ReelNest 解析",
+			Type: ParseTypeJSON,
+			URL:  base + "/api/resolve?format=tvbox&url=",
+		}},
+		Lives: []Live{},
+	}
+
+	// 直播源统一经本服务输出为 M3U，TXT 列表与本地文件同样可用
+	lives := config.GetLiveSites(opts.IncludeAdult)
+	for _, key := range sortedKeys(lives) {
+		cfg.Lives = append(cfg.Lives, Live{
+			Name: lives[key].Name,
+			URL:  base + "/api/live/m3u?source=" + url.QueryEscape(key),
+		})
+	}
+
+	sites := config.GetEnabledSites(opts.IncludeAdult)
+	for _, key := range sortedKeys(sites) {
+		if !opts.IncludeDown && !tracker.Available(key) {
+			continue
+		}
+		site := sites[key]
+		// 网盘等非 MacCMS 站点只能经聚合源访问
+		if !site.IsMacCMS() {
+			continue
+		}
+		api := site.Api
+		if !strings.HasSuffix(api, "/") {
+			api += "/"
+		}
+		cfg.Sites = append(cfg.Sites, Site{
+			Key:         key,
+			Name:        site.Name,
+			Type:        SiteTypeJSON,
+			API:         api + maccms.APIPath,
+			Searchable:  1,
+			QuickSearch: 1,
+			Filterable:  1,
+		})
+	}
+	return cfg
+}
+
+func sortedKeys(sites map[string]config.Site) []string {
+	keys := make([]string, 0, len(sites))
+	for key := range sites {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,345 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"ReelNest/models"
+)
+
+const (
+	// TokenPrefix 接口令牌前缀，便于识别泄露的令牌
+	TokenPrefix = "rn_"
+	// DefaultUser 匿名请求共用的用户名，不能用于创建用户
+	DefaultUser = "default"
+	// maxTokensPerUser 每个用户最多的有效令牌数
+	maxTokensPerUser = 20
+	// lastUsedInterval 令牌最近使用时间的更新间隔，避免每个请求都写入数据库
+	lastUsedInterval = time.Minute
+)
+
+// 存储桶名称
+var (
+	bucketUsers  = []byte("users")  // 键为用户名
+	bucketTokens = []byte("tokens") // 键为令牌的 SHA-256 摘要
+)
+
+// nameRegex 用户名：小写字母、数字、下划线与连字符
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+var (
+	// ErrNotFound 用户不存在
+	ErrNotFound = errors.New("用户不存在")
+	// ErrExists 用户已存在
+	ErrExists = errors.New("用户已存在")
+	// ErrInvalidName 用户名格式错误
+	ErrInvalidName = errors.New("用户名需为 1-32 位小写字母、数字、下划线或连字符")
+	// ErrReservedName 用户名为保留名称
+	ErrReservedName = errors.New("该用户名为保留名称")
+	// ErrInvalidToken 令牌无效或已撤销
+	ErrInvalidToken = errors.New("令牌无效或已撤销")
+	// ErrTooManyTokens 令牌数量超过上限
+	ErrTooManyTokens = errors.New("令牌数量超过上限，请先撤销不用的令牌")
+)
+
+// Store 基于 bbolt 的用户、个人设置与接口令牌
+type Store struct {
+	db    *bolt.DB
+	count atomic.Int64 // 用户数，认证中间件每个请求都会读取
+}
+
+// Open 打开(或创建)用户数据库
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketUsers, bucketTokens} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		s.count.Store(int64(tx.Bucket(bucketUsers).Stats().KeyN))
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Count 用户数
+func (s *Store) Count() int {
+	return int(s.count.Load())
+}
+
+// Create 创建用户
+func (s *Store) Create(name string) (*models.UserProfile, error) {
+	name = strings.TrimSpace(name)
+	if !nameRegex.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+	if name == DefaultUser {
+		return nil, ErrReservedName
+	}
+	p := &models.UserProfile{Name: name, CreatedAt: time.Now().UnixMilli()}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers)
+		if b.Get([]byte(name)) != nil {
+			return ErrExists
+		}
+		return putJSON(b, []byte(name), p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.count.Add(1)
+	return p, nil
+}
+
+// Get 获取用户
+func (s *Store) Get(name string) (*models.UserProfile, error) {
+	var p *models.UserProfile
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		p, err = getUser(tx, name)
+		return err
+	})
+	return p, err
+}
+
+// List 列出所有用户
+func (s *Store) List() ([]models.UserProfile, error) {
+	list := []models.UserProfile{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketUsers).ForEach(func(k, v []byte) error {
+			var p models.UserProfile
+			if json.Unmarshal(v, &p) == nil {
+				list = append(list, p)
+			}
+			return nil
+		})
+	})
+	return list, err
+}
+
+// Delete 删除用户并撤销其全部令牌
+func (s *Store) Delete(name string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketUsers).Get([]byte(name)) == nil {
+			return ErrNotFound
+		}
+		if err := tx.Bucket(bucketUsers).Delete([]byte(name)); err != nil {
+			return err
+		}
+		return deleteTokens(tx, func(t models.APIToken) bool { return t.User == name })
+	})
+	if err == nil {
+		s.count.Add(-1)
+	}
+	return err
+}
+
+// UpdateSettings 更新个人设置
+func (s *Store) UpdateSettings(name string, settings models.ProfileSettings) (*models.UserProfile, error) {
+	var p *models.UserProfile
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		if p, err = getUser(tx, name); err != nil {
+			return err
+		}
+		p.Settings = settings
+		return putJSON(tx.Bucket(bucketUsers), []byte(name), p)
+	})
+	return p, err
+}
+
+// IssueToken 为用户签发接口令牌，返回的 Token 字段为明文令牌，之后无法再次查看
+func (s *Store) IssueToken(user, name string) (*models.APIToken, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	token := TokenPrefix + hex.EncodeToString(secret)
+	t := models.APIToken{
+		ID:        hex.EncodeToString(id),
+		User:      user,
+		Name:      strings.TrimSpace(name),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := getUser(tx, user); err != nil {
+			return err
+		}
+		if n := len(userTokens(tx, user)); n >= maxTokensPerUser {
+			return ErrTooManyTokens
+		}
+		return putJSON(tx.Bucket(bucketTokens), digest(token), t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.Token = token
+	return &t, nil
+}
+
+// Tokens 列出用户的令牌(不含明文)
+func (s *Store) Tokens(user string) ([]models.APIToken, error) {
+	var list []models.APIToken
+	err := s.db.View(func(tx *bolt.Tx) error {
+		list = userTokens(tx, user)
+		return nil
+	})
+	return list, err
+}
+
+// RevokeToken 撤销用户的令牌
+func (s *Store) RevokeToken(user, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		found := false
+		err := deleteTokens(tx, func(t models.APIToken) bool {
+			if t.User == user && t.ID == id {
+				found = true
+				return true
+			}
+			return false
+		})
+		if err == nil && !found {
+			return ErrInvalidToken
+		}
+		return err
+	})
+}
+
+// Authenticate 校验令牌并返回对应的用户
+func (s *Store) Authenticate(token string) (*models.UserProfile, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	key := digest(token)
+
+	var (
+		t models.APIToken
+		p *models.UserProfile
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketTokens).Get(key)
+		if data == nil {
+			return ErrInvalidToken
+		}
+		if err := json.Unmarshal(data, &t); err != nil {
+			return err
+		}
+		var err error
+		p, err = getUser(tx, t.User)
+		if errors.Is(err, ErrNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Sub(time.UnixMilli(t.LastUsedAt)) >= lastUsedInterval {
+		t.LastUsedAt = now.UnixMilli()
+		s.db.Update(func(tx *bolt.Tx) error {
+			if tx.Bucket(bucketTokens).Get(key) == nil {
+				return nil
+			}
+			return putJSON(tx.Bucket(bucketTokens), key, t)
+		})
+	}
+	return p, nil
+}
+
+func getUser(tx *bolt.Tx, name string) (*models.UserProfile, error) {
+	data := tx.Bucket(bucketUsers).Get([]byte(name))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	var p models.UserProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// userTokens 列出用户的令牌，按创建时间排序
+func userTokens(tx *bolt.Tx, user string) []models.APIToken {
+	list := []models.APIToken{}
+	tx.Bucket(bucketTokens).ForEach(func(k, v []byte) error {
+		var t models.APIToken
+		if json.Unmarshal(v, &t) == nil && t.User == user {
+			list = append(list, t)
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt < list[j].CreatedAt })
+	return list
+}
+
+// deleteTokens 删除满足条件的令牌
+func deleteTokens(tx *bolt.Tx, match func(models.APIToken) bool) error {
+	b := tx.Bucket(bucketTokens)
+	var keys [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		var t models.APIToken
+		if json.Unmarshal(v, &t) == nil && match(t) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putJSON(b *bolt.Bucket, k []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(k, data)
+}
+
+// digest 令牌的 SHA-256 摘要，数据库中不保存明文令牌
+func digest(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
@@ -0,0 +1,58 @@
+package vodsource
+
+import (
+	"strconv"
+
+	"ReelNest/services/maccms"
+	"ReelNest/services/taxonomy"
+)
+
+// classTable 统一分类与虚拟源分类 ID 的对应关系
+// 大类 ID 为 1..n，子类 ID 为 大类ID*100+序号
+type classTable struct {
+	classes  []maccms.Class
+	filters  map[string]string // type_id -> 统一分类筛选条件
+	typeIDs  map[string]string // 统一分类 -> type_id
+	typeName map[string]string // type_id -> 名称
+}
+
+// newClassTable 根据统一分类体系生成虚拟源分类
+func newClassTable() *classTable {
+	t := &classTable{
+		filters:  make(map[string]string),
+		typeIDs:  make(map[string]string),
+		typeName: make(map[string]string),
+	}
+	for i, typ := range taxonomy.Types() {
+		parentID := strconv.Itoa(i + 1)
+		t.add(parentID, "0", typ.Label, typ.ID)
+		for j, genre := range typ.Genres {
+			id := strconv.Itoa((i+1)*100 + j + 1)
+			t.add(id, parentID, genre.Label, taxonomy.Result{Type: typ.ID, Genre: genre.ID}.String())
+		}
+	}
+	return t
+}
+
+func (t *classTable) add(id, pid, name, filter string) {
+	t.classes = append(t.classes, maccms.Class{
+		TypeID:   maccms.FlexString(id),
+		TypePID:  maccms.FlexString(pid),
+		TypeName: name,
+	})
+	t.filters[id] = filter
+	t.typeIDs[filter] = id
+	t.typeName[id] = name
+}
+
+// lookup 返回统一分类对应的 type_id 与名称，优先匹配子类
+func (t *classTable) lookup(r taxonomy.Result) (string, string) {
+	id, ok := t.typeIDs[r.String()]
+	if !ok {
+		id, ok = t.typeIDs[r.Type]
+	}
+	if !ok {
+		id = t.typeIDs[taxonomy.Other]
+	}
+	return id, t.typeName[id]
+}
@@ -0,0 +1,356 @@
+package vodsource
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"ReelNest/config"
+	"ReelNest/models"
+	"ReelNest/services/browse"
+	"ReelNest/services/catalog"
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+	"ReelNest/services/search"
+	"ReelNest/services/taxonomy"
+)
+
+const (
+	// PageSize 每页条数，与 MacCMS 默认一致
+	PageSize = 20
+	// MaxDetailIDs 单次详情请求最多的视频数
+	MaxDetailIDs = 20
+	// defaultListHours 未指定 h 时列表覆盖的时间范围
+	defaultListHours = 720
+	// detailConcurrency 完整列表同时请求的详情数
+	detailConcurrency = 8
+)
+
+// VideoID 生成虚拟视频 ID: 站点标识_原视频ID
+func VideoID(siteKey, id string) string {
+	return siteKey + config.KeySep + id
+}
+
+// ParseVideoID 解析虚拟视频 ID
+func ParseVideoID(s string) (siteKey, id string, ok bool) {
+	siteKey, id, ok = strings.Cut(strings.TrimSpace(s), config.KeySep)
+	return siteKey, id, ok && siteKey != "" && id != ""
+}
+
+// Source 将所有站点聚合为一个 MacCMS 兼容的虚拟源
+// 同一作品的多个站点合并为一条，各站点的播放组作为不同线路
+type Source struct {
+	agg     *search.Aggregator
+	browser *browse.Browser
+	mac     *maccms.Client
+	store   *catalog.Store
+	classes *classTable
+}
+
+// NewSource 创建虚拟源
+func NewSource(agg *search.Aggregator, browser *browse.Browser, mac *maccms.Client, store *catalog.Store) *Source {
+	return &Source{
+		agg:     agg,
+		browser: browser,
+		mac:     mac,
+		store:   store,
+		classes: newClassTable(),
+	}
+}
+
+// Classes 虚拟源分类列表，对应统一分类体系
+func (s *Source) Classes() []maccms.Class {
+	return s.classes.classes
+}
+
+// sites 参与聚合的站点：已启用且当前未被判定为不可用
+func (s *Source) sites(includeAdult bool) map[string]config.Site {
+	sites := config.GetEnabledSites(includeAdult)
+	for key := range sites {
+		if !s.agg.Tracker().Available(key) {
+			delete(sites, key)
+		}
+	}
+	return sites
+}
+
+// Search 聚合搜索并按作品合并，结果包含全部线路的播放地址
+func (s *Source) Search(ctx context.Context, keyword string, page int, includeAdult bool) *maccms.Response {
+	hits := search.Hits(s.agg.Search(ctx, keyword, s.sites(includeAdult)))
+	works, byID := groupHits(hits)
+	works = search.Rank([]string{keyword}, works, s.agg.Tracker())
+
+	list, pageCount := search.Paginate(works, page, PageSize)
+	resp := newResponse(page, pageCount, len(works))
+	for _, w := range list {
+		resp.List = append(resp.List, s.merge(w, byID))
+	}
+	return resp
+}
+
+// Detail 获取虚拟视频详情，并合并其他站点中的同一作品
+func (s *Source) Detail(ctx context.Context, ids []string, includeAdult bool) *maccms.Response {
+	if len(ids) > MaxDetailIDs {
+		ids = ids[:MaxDetailIDs]
+	}
+
+	resp := newResponse(1, 1, 0)
+	sites := s.sites(includeAdult)
+	// 只返回已启用的站点，未开启成人内容时不返回成人站点
+	enabled := config.GetEnabledSites(includeAdult)
+	for _, raw := range ids {
+		siteKey, id, ok := ParseVideoID(raw)
+		if !ok {
+			continue
+		}
+		site, ok := enabled[siteKey]
+		if !ok {
+			continue
+		}
+
+		video, err := s.video(ctx, siteKey, site, id)
+		if err != nil {
+			log.Printf("虚拟源获取 %s 详情失败: %v", raw, err)
+			continue
+		}
+
+		// 请求的条目排在首位，保证合并后的作品以它为准
+		hits := append([]search.Hit{{SiteKey: siteKey, Site: site, Video: *video}},
+			search.Hits(s.agg.Search(ctx, video.VodName, sites))...)
+		works, byID := groupHits(hits)
+		for _, w := range works {
+			if containsSource(w, siteKey, id) {
+				resp.List = append(resp.List, s.merge(promote(w, siteKey, id), byID))
+				break
+			}
+		}
+	}
+	resp.Total = maccms.FlexInt(len(resp.List))
+	return resp
+}
+
+// List 按虚拟分类列出最近更新的作品
+// full 为 true 时(ac=videolist)与 MacCMS 一致返回含播放地址的完整条目，否则不含播放地址
+func (s *Source) List(ctx context.Context, typeID string, page, hours int, includeAdult, full bool) *maccms.Response {
+	canonical := ""
+	if typeID != "" {
+		filter, ok := s.classes.filters[typeID]
+		if !ok {
+			return newResponse(page, 0, 0)
+		}
+		canonical = filter
+	}
+	if hours <= 0 {
+		hours = defaultListHours
+	}
+
+	result := s.browser.Latest(ctx, s.sites(includeAdult), hours, page, canonical)
+	items := make([]matcher.Item, 0, len(result.List))
+	for _, info := range result.List {
+		items = append(items, matcher.Item{Info: info})
+	}
+
+	resp := newResponse(result.Page, result.PageCount, result.Total)
+	works := matcher.Group(items)
+	var byID map[string]search.Hit
+	if full {
+		byID = s.details(ctx, works)
+	}
+	for _, w := range works {
+		// 所有来源的详情都获取失败时退回不含播放地址的条目
+		if full {
+			if video := s.merge(w, byID); video.VodID != "" {
+				resp.List = append(resp.List, video)
+				continue
+			}
+		}
+		video := fromInfo(w.Info)
+		video.VodID = maccms.FlexString(VideoID(w.Sources[0].SourceCode, w.Sources[0].VideoID))
+		s.setClass(&video, w.Info)
+		resp.List = append(resp.List, video)
+	}
+	return resp
+}
+
+// video 获取完整视频条目，优先读取本地目录
+func (s *Source) video(ctx context.Context, siteKey string, site config.Site, id string) (*maccms.Video, error) {
+	video, err := s.store.Get(siteKey, id)
+	if err != nil {
+		video, err = s.mac.Detail(ctx, site, id)
+	}
+	return video, err
+}
+
+// details 并发获取作品各来源的完整条目，按 站点+视频ID 建立索引
+func (s *Source) details(ctx context.Context, works []models.Work) map[string]search.Hit {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		byID = make(map[string]search.Hit)
+		sem  = make(chan struct{}, detailConcurrency)
+	)
+	for _, w := range works {
+		for _, src := range w.Sources {
+			site, ok := config.GetSite(src.SourceCode)
+			if !ok || site.IsLive() {
+				continue
+			}
+			wg.Add(1)
+			go func(key, id string, site config.Site) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				video, err := s.video(ctx, key, site, id)
+				if err != nil {
+					log.Printf("虚拟源获取 %s 详情失败: %v", VideoID(key, id), err)
+					return
+				}
+				mu.Lock()
+				byID[hitKey(key, id)] = search.Hit{SiteKey: key, Site: site, Video: *video}
+				mu.Unlock()
+			}(src.SourceCode, src.VideoID, site)
+		}
+	}
+	wg.Wait()
+	return byID
+}
+
+// merge 将作品的各站点条目合并为一个视频，线路名为 站点名-原线路名
+func (s *Source) merge(w models.Work, byID map[string]search.Hit) maccms.Video {
+	var (
+		video maccms.Video
+		froms []string
+		urls  []string
+	)
+	for _, src := range w.Sources {
+		hit, ok := byID[hitKey(src.SourceCode, src.VideoID)]
+		if !ok {
+			continue
+		}
+		if video.VodID == "" {
+			video = hit.Video
+			video.VodID = maccms.FlexString(VideoID(src.SourceCode, src.VideoID))
+		}
+		for _, group := range hit.Video.PlayGroups() {
+			if len(group.Episodes) == 0 {
+				continue
+			}
+			froms = append(froms, lineName(src.SourceName, group.From))
+			urls = append(urls, joinEpisodes(group.Episodes))
+		}
+	}
+
+	video.VodPlayFrom = strings.Join(froms, "$$$")
+	video.VodPlayURL = strings.Join(urls, "$$$")
+	if video.VodPic == "" {
+		video.VodPic = w.Info.CoverUrl
+	}
+	if video.VodContent == "" {
+		video.VodContent = w.Info.Desc
+	}
+	s.setClass(&video, w.Info)
+	return video
+}
+
+// setClass 将视频分类替换为虚拟源分类
+func (s *Source) setClass(video *maccms.Video, info models.VideoInfo) {
+	typeID, name := s.classes.lookup(taxonomy.Result{Type: info.CanonicalType, Genre: info.CanonicalGenre})
+	video.TypeID = maccms.FlexString(typeID)
+	video.TypeName = name
+}
+
+// groupHits 将命中结果按作品分组，并按 站点+视频ID 建立索引
+func groupHits(hits []search.Hit) ([]models.Work, map[string]search.Hit) {
+	items := make([]matcher.Item, 0, len(hits))
+	byID := make(map[string]search.Hit, len(hits))
+	for _, hit := range hits {
+		key := hitKey(hit.SiteKey, string(hit.Video.VodID))
+		if _, ok := byID[key]; ok {
+			continue
+		}
+		byID[key] = hit
+		items = append(items, hit.Item())
+	}
+	return matcher.Group(items), byID
+}
+
+func hitKey(siteKey, id string) string {
+	return siteKey + "\x00" + id
+}
+
+// containsSource 作品是否包含指定站点的视频
+func containsSource(w models.Work, siteKey, id string) bool {
+	for _, src := range w.Sources {
+		if src.SourceCode == siteKey && src.VideoID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// promote 将指定来源移到首位
+func promote(w models.Work, siteKey, id string) models.Work {
+	sources := make([]models.SourceHit, 0, len(w.Sources))
+	for _, src := range w.Sources {
+		if src.SourceCode == siteKey && src.VideoID == id {
+			sources = append([]models.SourceHit{src}, sources...)
+		} else {
+			sources = append(sources, src)
+		}
+	}
+	w.Sources = sources
+	return w
+}
+
+// lineName 线路名称，去掉 MacCMS 格式中的分隔符
+func lineName(siteName, from string) string {
+	name := siteName
+	if from != "" {
+		name += "-" + from
+	}
+	return strings.NewReplacer("$", "", "#", "").Replace(name)
+}
+
+// joinEpisodes 生成 "第1集$url#第2集$url" 格式的剧集列表
+func joinEpisodes(episodes []models.EpisodeInfo) string {
+	parts := make([]string, 0, len(episodes))
+	for _, ep := range episodes {
+		title := strings.NewReplacer("$", "", "#", "").Replace(ep.Title)
+		parts = append(parts, title+"$"+ep.Url)
+	}
+	return strings.Join(parts, "#")
+}
+
+// fromInfo 由统一视频信息还原 MacCMS 条目(不含播放地址)
+func fromInfo(info models.VideoInfo) maccms.Video {
+	return maccms.Video{
+		VodName:     info.Title,
+		VodSub:      info.SubTitle,
+		VodPic:      info.CoverUrl,
+		VodYear:     maccms.FlexString(info.Year),
+		VodArea:     info.Area,
+		VodDirector: strings.Join(info.Directors, ","),
+		VodActor:    strings.Join(info.Actors, ","),
+		VodClass:    strings.Join(info.Categories, ","),
+		VodContent:  info.Desc,
+		VodRemarks:  info.Remarks,
+		VodScore:    maccms.FlexString(info.Score),
+		VodDuration: info.Duration,
+		VodTime:     info.UpdatedAt,
+	}
+}
+
+// newResponse 创建 MacCMS 格式的响应
+func newResponse(page, pageCount, total int) *maccms.Response {
+	return &maccms.Response{
+		Code:      1,
+		Msg:       "数据列表",
+		Page:      maccms.FlexInt(max(page, 1)),
+		PageCount: maccms.FlexInt(pageCount),
+		Limit:     PageSize,
+		Total:     maccms.FlexInt(total),
+		List:      make([]maccms.Video, 0),
+	}
+}
@@ -0,0 +1,410 @@
+package watchlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+
+	"ReelNest/models"
+)
+
+const (
+	// MaxLists 每个用户最多的收藏夹数
+	MaxLists = 100
+	// MaxItems 每个收藏夹最多的视频数
+	MaxItems = 2000
+	// maxNameLength 收藏夹名称的最大字符数
+	maxNameLength = 50
+	// DefaultID 默认收藏夹，首次收藏时自动创建
+	DefaultID = "default"
+	// defaultName 默认收藏夹名称
+	defaultName = "我的收藏"
+)
+
+// bucketUsers 每个用户一个子存储桶，键为收藏夹 ID
+var bucketUsers = []byte("users")
+
+var (
+	// ErrNotFound 收藏夹或视频不存在
+	ErrNotFound = errors.New("收藏夹不存在")
+	// ErrItemNotFound 收藏夹中没有该视频
+	ErrItemNotFound = errors.New("收藏夹中没有该视频")
+	// ErrInvalidName 收藏夹名称为空或过长
+	ErrInvalidName = errors.New("收藏夹名称需为 1-50 个字符")
+	// ErrTooMany 收藏夹或视频数量超过上限
+	ErrTooMany = errors.New("收藏夹或视频数量超过上限")
+)
+
+// ItemKey 收藏夹中视频的标识
+type ItemKey struct {
+	Source  string `json:"source"`
+	VideoID string `json:"id"`
+}
+
+// Store 基于 bbolt 的收藏夹
+type Store struct {
+	db *bolt.DB
+}
+
+// Open 打开(或创建)收藏夹数据库
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketUsers)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Lists 列出用户的收藏夹(不含视频)，默认收藏夹在前，其余按创建时间排序
+func (s *Store) Lists(user string) ([]models.Watchlist, error) {
+	lists, err := s.all(user)
+	for i := range lists {
+		lists[i].Items = nil
+	}
+	return lists, err
+}
+
+// Export 导出用户的全部收藏夹(含视频)
+func (s *Store) Export(user string) ([]models.Watchlist, error) {
+	return s.all(user)
+}
+
+// Get 获取收藏夹及其中的视频
+func (s *Store) Get(user, id string) (*models.Watchlist, error) {
+	var wl *models.Watchlist
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil {
+			return ErrNotFound
+		}
+		var err error
+		wl, err = get(b, id)
+		return err
+	})
+	return wl, err
+}
+
+// Create 创建收藏夹
+func (s *Store) Create(user, name string) (*models.Watchlist, error) {
+	name, err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	wl := &models.Watchlist{ID: newID(), Name: name, CreatedAt: now, UpdatedAt: now}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b, err := userBucket(tx, user)
+		if err != nil {
+			return err
+		}
+		if b.Stats().KeyN >= MaxLists {
+			return ErrTooMany
+		}
+		return put(b, wl)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wl, nil
+}
+
+// Rename 重命名收藏夹
+func (s *Store) Rename(user, id, name string) (*models.Watchlist, error) {
+	name, err := checkName(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(user, id, false, func(wl *models.Watchlist) error {
+		wl.Name = name
+		return nil
+	})
+}
+
+// Delete 删除收藏夹
+func (s *Store) Delete(user, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil || b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// Add 将视频加入收藏夹末尾，已收藏时只更新详情快照；收藏到默认收藏夹时自动创建
+func (s *Store) Add(user, id string, item models.WatchlistItem) (*models.Watchlist, error) {
+	return s.update(user, id, id == DefaultID, func(wl *models.Watchlist) error {
+		for i := range wl.Items {
+			if wl.Items[i].Source == item.Source && wl.Items[i].VideoID == item.VideoID {
+				wl.Items[i].Info = item.Info
+				return nil
+			}
+		}
+		if len(wl.Items) >= MaxItems {
+			return ErrTooMany
+		}
+		item.AddedAt = time.Now().UnixMilli()
+		wl.Items = append(wl.Items, item)
+		return nil
+	})
+}
+
+// Remove 从收藏夹移除视频
+func (s *Store) Remove(user, id string, key ItemKey) (*models.Watchlist, error) {
+	return s.update(user, id, false, func(wl *models.Watchlist) error {
+		for i := range wl.Items {
+			if wl.Items[i].Source == key.Source && wl.Items[i].VideoID == key.VideoID {
+				wl.Items = append(wl.Items[:i], wl.Items[i+1:]...)
+				return nil
+			}
+		}
+		return ErrItemNotFound
+	})
+}
+
+// Reorder 按给定顺序排列收藏夹中的视频，未列出的视频保持原有顺序排在后面
+func (s *Store) Reorder(user, id string, order []ItemKey) (*models.Watchlist, error) {
+	return s.update(user, id, false, func(wl *models.Watchlist) error {
+		rank := make(map[ItemKey]int, len(order))
+		for i, k := range order {
+			if _, ok := rank[k]; !ok {
+				rank[k] = i
+			}
+		}
+		sort.SliceStable(wl.Items, func(i, j int) bool {
+			ri, oki := rank[ItemKey{wl.Items[i].Source, wl.Items[i].VideoID}]
+			rj, okj := rank[ItemKey{wl.Items[j].Source, wl.Items[j].VideoID}]
+			switch {
+			case oki && okj:
+				return ri < rj
+			default:
+				return oki && !okj
+			}
+		})
+		return nil
+	})
+}
+
+// Import 导入收藏夹：replace 时先清空用户的收藏夹，否则与同名收藏夹合并
+// 返回导入后的收藏夹数与新增的视频数
+func (s *Store) Import(user string, lists []models.Watchlist, replace bool) (int, int, error) {
+	added := 0
+	total := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		users := tx.Bucket(bucketUsers)
+		if replace && users.Bucket([]byte(user)) != nil {
+			if err := users.DeleteBucket([]byte(user)); err != nil {
+				return err
+			}
+		}
+		b, err := userBucket(tx, user)
+		if err != nil {
+			return err
+		}
+		existing, err := decodeAll(b)
+		if err != nil {
+			return err
+		}
+		byName := make(map[string]*models.Watchlist, len(existing))
+		for i := range existing {
+			byName[existing[i].Name] = &existing[i]
+		}
+
+		now := time.Now().UnixMilli()
+		for _, in := range lists {
+			name, err := checkName(in.Name)
+			if err != nil {
+				return err
+			}
+			wl, ok := byName[name]
+			if !ok {
+				if len(byName) >= MaxLists {
+					return ErrTooMany
+				}
+				id := in.ID
+				if id != DefaultID || b.Get([]byte(DefaultID)) != nil {
+					id = newID()
+				}
+				wl = &models.Watchlist{ID: id, Name: name, CreatedAt: now}
+				if in.CreatedAt > 0 {
+					wl.CreatedAt = in.CreatedAt
+				}
+				byName[name] = wl
+			}
+
+			seen := make(map[ItemKey]bool, len(wl.Items))
+			for _, item := range wl.Items {
+				seen[ItemKey{item.Source, item.VideoID}] = true
+			}
+			for _, item := range in.Items {
+				k := ItemKey{item.Source, item.VideoID}
+				if k.Source == "" || k.VideoID == "" || seen[k] {
+					continue
+				}
+				if len(wl.Items) >= MaxItems {
+					return ErrTooMany
+				}
+				if item.AddedAt <= 0 {
+					item.AddedAt = now
+				}
+				wl.Items = append(wl.Items, item)
+				seen[k] = true
+				added++
+			}
+			wl.UpdatedAt = now
+			if err := put(b, wl); err != nil {
+				return err
+			}
+		}
+		total = len(byName)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return total, added, nil
+}
+
+// Purge 删除用户的全部收藏夹，用于删除用户
+func (s *Store) Purge(user string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(bucketUsers).DeleteBucket([]byte(user))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// update 读取、修改并保存收藏夹，create 为 true 时收藏夹不存在则创建默认收藏夹
+func (s *Store) update(user, id string, create bool, fn func(*models.Watchlist) error) (*models.Watchlist, error) {
+	var wl *models.Watchlist
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := userBucket(tx, user)
+		if err != nil {
+			return err
+		}
+		wl, err = get(b, id)
+		if errors.Is(err, ErrNotFound) && create {
+			if b.Stats().KeyN >= MaxLists {
+				return ErrTooMany
+			}
+			now := time.Now().UnixMilli()
+			wl, err = &models.Watchlist{ID: id, Name: defaultName, CreatedAt: now}, nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(wl); err != nil {
+			return err
+		}
+		wl.UpdatedAt = time.Now().UnixMilli()
+		return put(b, wl)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wl, nil
+}
+
+// all 读取用户的全部收藏夹
+func (s *Store) all(user string) ([]models.Watchlist, error) {
+	lists := []models.Watchlist{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUsers).Bucket([]byte(user))
+		if b == nil {
+			return nil
+		}
+		var err error
+		lists, err = decodeAll(b)
+		return err
+	})
+	return lists, err
+}
+
+func decodeAll(b *bolt.Bucket) ([]models.Watchlist, error) {
+	lists := []models.Watchlist{}
+	err := b.ForEach(func(k, v []byte) error {
+		var wl models.Watchlist
+		if err := json.Unmarshal(v, &wl); err != nil {
+			return nil
+		}
+		wl.Count = len(wl.Items)
+		lists = append(lists, wl)
+		return nil
+	})
+	sort.SliceStable(lists, func(i, j int) bool {
+		if (lists[i].ID == DefaultID) != (lists[j].ID == DefaultID) {
+			return lists[i].ID == DefaultID
+		}
+		return lists[i].CreatedAt < lists[j].CreatedAt
+	})
+	return lists, err
+}
+
+func get(b *bolt.Bucket, id string) (*models.Watchlist, error) {
+	data := b.Get([]byte(id))
+	if data == nil {
+		return nil, ErrNotFound
+	}
+	var wl models.Watchlist
+	if err := json.Unmarshal(data, &wl); err != nil {
+		return nil, err
+	}
+	wl.Count = len(wl.Items)
+	return &wl, nil
+}
+
+func put(b *bolt.Bucket, wl *models.Watchlist) error {
+	wl.Count = len(wl.Items)
+	data, err := json.Marshal(wl)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(wl.ID), data)
+}
+
+func userBucket(tx *bolt.Tx, user string) (*bolt.Bucket, error) {
+	return tx.Bucket(bucketUsers).CreateBucketIfNotExists([]byte(user))
+}
+
+// checkName 去掉名称首尾空白并检查长度
+func checkName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+// newID 生成随机的收藏夹 ID
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
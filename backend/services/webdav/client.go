@@ -0,0 +1,206 @@
+package webdav
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/config"
+	"ReelNest/utils"
+)
+
+const (
+	// listTimeout 单个目录 PROPFIND 请求的超时
+	listTimeout = 20 * time.Second
+	// maxListBytes 单个目录响应大小上限
+	maxListBytes = 8 << 20
+)
+
+// propfindBody 只请求浏览目录需要的属性
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:displayname/><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// Entry 目录中的文件或子目录
+type Entry struct {
+	Path    string // 相对根目录的路径，以 "/" 开头
+	Name    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// multistatus PROPFIND 响应
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Prop   struct {
+				DisplayName   string `xml:"displayname"`
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// Client WebDAV 客户端，凭据取自站点配置
+type Client struct {
+	hc *client.Client
+}
+
+// NewClient 创建 WebDAV 客户端
+func NewClient(hc *client.Client) *Client {
+	return &Client{hc: hc}
+}
+
+// List 列出目录的直接子项(PROPFIND Depth: 1)，dir 为相对根目录的路径
+func (c *Client) List(ctx context.Context, site config.Site, dir string) ([]Entry, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(FileURL(site, dir) + "/")
+	req.SetMethod("PROPFIND")
+	req.Header.Set("Depth", "1")
+	req.Header.SetContentTypeBytes([]byte("application/xml; charset=utf-8"))
+	req.SetBodyString(propfindBody)
+	SetAuth(req, site)
+
+	if err := c.hc.Do(reqCtx, req, resp); err != nil {
+		return nil, err
+	}
+	defer resp.CloseBodyStream()
+	if resp.StatusCode() != http.StatusMultiStatus {
+		return nil, fmt.Errorf("列出目录 %s 失败: HTTP %d", dir, resp.StatusCode())
+	}
+
+	body, err := readBody(resp, maxListBytes)
+	if err != nil {
+		return nil, err
+	}
+	var ms multistatus
+	if err := xml.Unmarshal(body, &ms); err != nil {
+		return nil, fmt.Errorf("解析目录 %s 失败: %w", dir, err)
+	}
+
+	rootPath := rootPath(site)
+	self := path.Clean("/" + dir)
+	entries := make([]Entry, 0, len(ms.Responses))
+	for _, r := range ms.Responses {
+		href, err := url.PathUnescape(hrefPath(r.Href))
+		if err != nil {
+			continue
+		}
+		rel, ok := relativePath(path.Clean(href), rootPath)
+		if !ok || rel == self {
+			continue
+		}
+
+		e := Entry{Path: rel, Name: path.Base(rel)}
+		for _, ps := range r.Propstat {
+			if ps.Status != "" && !strings.Contains(ps.Status, " 200") {
+				continue
+			}
+			p := ps.Prop
+			if p.ResourceType.Collection != nil {
+				e.IsDir = true
+			}
+			if p.DisplayName != "" {
+				e.Name = p.DisplayName
+			}
+			if n, err := strconv.ParseInt(strings.TrimSpace(p.ContentLength), 10, 64); err == nil {
+				e.Size = n
+			}
+			if t, err := http.ParseTime(strings.TrimSpace(p.LastModified)); err == nil {
+				e.ModTime = t
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// FileURL 拼接文件在网盘上的完整地址，p 为相对根目录的路径
+func FileURL(site config.Site, p string) string {
+	base := strings.TrimRight(site.Api, "/")
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return base
+	}
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return base + "/" + strings.Join(segments, "/")
+}
+
+// SetAuth 设置 Basic 认证头，站点未配置用户名时不设置
+func SetAuth(req *protocol.Request, site config.Site) {
+	if site.Username == "" {
+		return
+	}
+	token := base64.StdEncoding.EncodeToString([]byte(site.Username + ":" + site.Password))
+	req.Header.Set("Authorization", "Basic "+token)
+}
+
+// rootPath 站点根目录在服务器上的路径(未转义)
+func rootPath(site config.Site) string {
+	u, err := url.Parse(site.Api)
+	if err != nil {
+		return "/"
+	}
+	return path.Clean("/" + u.Path)
+}
+
+// relativePath 将服务器路径转换为相对根目录的路径，不在根目录下时返回 false
+func relativePath(p, root string) (string, bool) {
+	if root == "/" {
+		return p, true
+	}
+	if p != root && !strings.HasPrefix(p, root+"/") {
+		return "", false
+	}
+	return path.Clean("/" + strings.TrimPrefix(p, root)), true
+}
+
+// readBody 读取响应体，超过 maxBytes 时返回错误
+func readBody(resp *protocol.Response, maxBytes int64) ([]byte, error) {
+	if !resp.IsBodyStream() {
+		return resp.Body(), nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.BodyStream(), maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, utils.ErrBodyTooLarge
+	}
+	return body, nil
+}
+
+// hrefPath 响应中的 href 可能是完整地址，只取路径部分
+func hrefPath(href string) string {
+	href = strings.TrimSpace(href)
+	if u, err := url.Parse(href); err == nil && u.Scheme != "" {
+		return u.EscapedPath()
+	}
+	return href
+}
@@ -0,0 +1,298 @@
+package webdav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ReelNest/config"
+	"ReelNest/services/maccms"
+	"ReelNest/services/matcher"
+	"ReelNest/utils"
+)
+
+const (
+	// maxDepth 分类目录以下最多遍历的层数
+	maxDepth = 5
+	// maxDirs 单次扫描最多列出的目录数
+	maxDirs = 5000
+	// playFrom 网盘视频的播放线路名称
+	playFrom = "webdav"
+	// otherClass 根目录下零散视频所属的分类名称
+	otherClass = "其他"
+)
+
+// StreamPath 网盘视频的播放地址前缀，由本服务携带凭据转发
+const StreamPath = "/api/drive/stream"
+
+var (
+	// seasonDirRegex 季目录名称，如 "Season 2"、"S02"、"第二季"
+	seasonDirRegex = regexp.MustCompile(`(?i)^(?:season\s*\d+|s\d{1,2}|第\s*[0-9零一二三四五六七八九十两]+\s*季)$`)
+	// seasonNumRegex 英文季目录中的季数
+	seasonNumRegex = regexp.MustCompile(`(?i)^(?:season\s*|s)(\d+)$`)
+	// titleYearRegex 目录名称末尾的年份，如 "流浪地球 (2019)"
+	titleYearRegex = regexp.MustCompile(`^(.+?)[\s._-]*[\(\[（【]((?:19|20)\d{2})[\)\]）】]`)
+	// fileYearRegex 文件名中的年份，如 "Movie.2019.1080p.mkv"
+	fileYearRegex = regexp.MustCompile(`^(.+?)[\s._-]+((?:19|20)\d{2})(?:[\s._-]|$)`)
+)
+
+// errTooManyDirs 目录数量超过扫描上限
+var errTooManyDirs = errors.New("目录过多，停止扫描")
+
+// library 一次扫描得到的网盘片库
+type library struct {
+	classes []maccms.Class
+	videos  []maccms.Video
+}
+
+// scanner 扫描单个网盘站点，顶层目录作为分类，含视频的目录作为作品
+type scanner struct {
+	client *Client
+	site   config.Site
+	key    string
+	dirs   int
+	lib    library
+}
+
+// scan 扫描网盘目录生成片库
+func scan(ctx context.Context, c *Client, key string, site config.Site) (*library, error) {
+	s := &scanner{client: c, site: site, key: key}
+	root, err := s.list(ctx, "/")
+	if err != nil {
+		return nil, err
+	}
+
+	var loose []Entry
+	for _, e := range root {
+		if !e.IsDir {
+			if utils.IsVideoFile(e.Name) {
+				loose = append(loose, e)
+			}
+			continue
+		}
+		typeID := strconv.Itoa(len(s.lib.classes) + 1)
+		s.lib.classes = append(s.lib.classes, maccms.Class{
+			TypeID:   maccms.FlexString(typeID),
+			TypeName: e.Name,
+		})
+		if err := s.walkClass(ctx, e, typeID); err != nil {
+			return nil, err
+		}
+	}
+	if len(loose) > 0 {
+		typeID := strconv.Itoa(len(s.lib.classes) + 1)
+		s.lib.classes = append(s.lib.classes, maccms.Class{
+			TypeID:   maccms.FlexString(typeID),
+			TypeName: otherClass,
+		})
+		for _, e := range loose {
+			s.addMovie(e, typeID, otherClass)
+		}
+	}
+
+	sort.SliceStable(s.lib.videos, func(i, j int) bool {
+		return s.lib.videos[i].VodTime > s.lib.videos[j].VodTime
+	})
+	return &s.lib, nil
+}
+
+// walkClass 遍历分类目录：零散视频各自作为电影，子目录作为作品或合集
+func (s *scanner) walkClass(ctx context.Context, dir Entry, typeID string) error {
+	entries, err := s.list(ctx, dir.Path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		switch {
+		case e.IsDir:
+			if err := s.walkWork(ctx, e, typeID, dir.Name, 1); err != nil {
+				return err
+			}
+		case utils.IsVideoFile(e.Name):
+			s.addMovie(e, typeID, dir.Name)
+		}
+	}
+	return nil
+}
+
+// walkWork 处理作品目录：直接包含的视频为一部作品，季目录各为一部作品，其它子目录继续向下查找
+func (s *scanner) walkWork(ctx context.Context, dir Entry, typeID, typeName string, depth int) error {
+	entries, err := s.list(ctx, dir.Path)
+	if err != nil {
+		return err
+	}
+
+	var files []Entry
+	for _, e := range entries {
+		if !e.IsDir {
+			if utils.IsVideoFile(e.Name) {
+				files = append(files, e)
+			}
+			continue
+		}
+		if seasonDirRegex.MatchString(strings.TrimSpace(e.Name)) {
+			seasonFiles, err := s.videoFiles(ctx, e.Path)
+			if err != nil {
+				return err
+			}
+			if len(seasonFiles) > 0 {
+				s.addWork(dir, e.Name, seasonFiles, typeID, typeName)
+			}
+			continue
+		}
+		if depth < maxDepth {
+			if err := s.walkWork(ctx, e, typeID, typeName, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	if len(files) > 0 {
+		s.addWork(dir, "", files, typeID, typeName)
+	}
+	return nil
+}
+
+// videoFiles 列出目录中的视频文件
+func (s *scanner) videoFiles(ctx context.Context, dir string) ([]Entry, error) {
+	entries, err := s.list(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []Entry
+	for _, e := range entries {
+		if !e.IsDir && utils.IsVideoFile(e.Name) {
+			files = append(files, e)
+		}
+	}
+	return files, nil
+}
+
+func (s *scanner) list(ctx context.Context, dir string) ([]Entry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.dirs++; s.dirs > maxDirs {
+		return nil, errTooManyDirs
+	}
+	return s.client.List(ctx, s.site, dir)
+}
+
+// addWork 添加作品，season 非空时标题附加季名称
+func (s *scanner) addWork(dir Entry, season string, files []Entry, typeID, typeName string) {
+	name, year := splitTitle(dir.Name, titleYearRegex)
+	id := dir.Path
+	if season != "" {
+		name += " " + seasonTitle(season)
+		id += "/" + season
+	}
+	sortEpisodes(files)
+
+	links := make([]string, 0, len(files))
+	var latest time.Time
+	for _, f := range files {
+		links = append(links, episodeTitle(f.Name)+"$"+s.streamURL(f.Path))
+		if f.ModTime.After(latest) {
+			latest = f.ModTime
+		}
+	}
+	s.lib.videos = append(s.lib.videos, maccms.Video{
+		VodID:       maccms.FlexString(videoID(id)),
+		VodName:     name,
+		VodYear:     maccms.FlexString(year),
+		VodContent:  dir.Path,
+		VodRemarks:  fmt.Sprintf("共%d集", len(files)),
+		VodTime:     formatTime(latest),
+		VodPlayFrom: playFrom,
+		VodPlayURL:  strings.Join(links, "#"),
+		TypeID:      maccms.FlexString(typeID),
+		TypeName:    typeName,
+	})
+}
+
+// addMovie 将单个视频文件作为一部电影
+func (s *scanner) addMovie(f Entry, typeID, typeName string) {
+	stem := strings.TrimSuffix(f.Name, path.Ext(f.Name))
+	name, year := splitTitle(stem, titleYearRegex)
+	if year == "" {
+		name, year = splitTitle(stem, fileYearRegex)
+		name = strings.TrimSpace(strings.NewReplacer(".", " ", "_", " ").Replace(name))
+	}
+	s.lib.videos = append(s.lib.videos, maccms.Video{
+		VodID:       maccms.FlexString(videoID(f.Path)),
+		VodName:     name,
+		VodYear:     maccms.FlexString(year),
+		VodContent:  f.Path,
+		VodRemarks:  "完结",
+		VodTime:     formatTime(f.ModTime),
+		VodPlayFrom: playFrom,
+		VodPlayURL:  "正片$" + s.streamURL(f.Path),
+		TypeID:      maccms.FlexString(typeID),
+		TypeName:    typeName,
+	})
+}
+
+// streamURL 生成经本服务转发的播放地址(相对地址)，成人站点带上 adult=1 以通过成人内容校验
+func (s *scanner) streamURL(p string) string {
+	q := url.Values{"site": {s.key}, "path": {p}}
+	if s.site.Adult {
+		q.Set("adult", "1")
+	}
+	return StreamPath + "?" + q.Encode()
+}
+
+// splitTitle 拆分名称与年份
+func splitTitle(name string, re *regexp.Regexp) (string, string) {
+	if m := re.FindStringSubmatch(name); m != nil {
+		return strings.TrimSpace(m[1]), m[2]
+	}
+	return strings.TrimSpace(name), ""
+}
+
+// seasonTitle 将 "Season 2"、"S02" 统一为 "第2季"，中文季名保持不变
+func seasonTitle(name string) string {
+	name = strings.TrimSpace(name)
+	if m := seasonNumRegex.FindStringSubmatch(name); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return fmt.Sprintf("第%d季", n)
+	}
+	return name
+}
+
+// episodeTitle 剧集标题取文件名，去掉播放列表中的分隔符
+func episodeTitle(name string) string {
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	return strings.NewReplacer("#", " ", "$", " ").Replace(stem)
+}
+
+// sortEpisodes 按集数排序，无法识别集数时按名称排序
+func sortEpisodes(files []Entry) {
+	sort.SliceStable(files, func(i, j int) bool {
+		a, b := matcher.EpisodeNumber(episodeTitle(files[i].Name)), matcher.EpisodeNumber(episodeTitle(files[j].Name))
+		if a > 0 && b > 0 && a != b {
+			return a < b
+		}
+		return files[i].Name < files[j].Name
+	})
+}
+
+// videoID 由路径生成稳定的视频 ID
+func videoID(p string) string {
+	h := fnv.New64a()
+	h.Write([]byte(p))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(maccms.TimeLayout)
+}
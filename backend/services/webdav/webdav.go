@@ -0,0 +1,133 @@
+package webdav
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/client"
+	"github.com/cloudwego/hertz/pkg/protocol"
+
+	"ReelNest/config"
+	"ReelNest/services/maccms"
+)
+
+// pageSize 列表每页条数，与常见 MacCMS 站点一致
+const pageSize = 20
+
+// entry 已扫描的站点片库
+type entry struct {
+	lib       *library
+	scannedAt time.Time
+}
+
+// Drive 网盘站点数据来源：扫描 WebDAV 目录生成片库，按 MacCMS 接口参数查询
+type Drive struct {
+	client *Client
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+	// scanning 每个站点同时只进行一次扫描
+	scanning map[string]*sync.Mutex
+}
+
+// NewDrive 创建网盘数据来源，ttl 为重新扫描目录的间隔
+func NewDrive(hc *client.Client, ttl time.Duration) *Drive {
+	return &Drive{
+		client:   NewClient(hc),
+		ttl:      ttl,
+		entries:  make(map[string]entry),
+		scanning: make(map[string]*sync.Mutex),
+	}
+}
+
+// Fetch 实现 maccms.Backend，支持 ac=list 与 ac=videolist/detail 的 ids、wd、t、h、pg 参数
+func (d *Drive) Fetch(ctx context.Context, site config.Site, params url.Values) (*maccms.Response, error) {
+	key, ok := siteKey(site)
+	if !ok {
+		return nil, maccms.ErrNotFound
+	}
+	lib, err := d.library(ctx, key, site)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Get("ac") == "list" {
+		return &maccms.Response{Code: 1, Msg: "数据列表", Page: 1, Limit: pageSize, Class: lib.classes}, nil
+	}
+	return maccms.Query(lib.videos, params, pageSize), nil
+}
+
+// Stream 携带站点凭据请求网盘文件，method 为 GET 或 HEAD，转发 Range 等请求头
+// 响应体以流的形式返回，调用方读取完毕后需调用 CloseBodyStream 并释放响应
+func (d *Drive) Stream(ctx context.Context, site config.Site, p, method string, header map[string]string) (*protocol.Response, error) {
+	req := protocol.AcquireRequest()
+	defer protocol.ReleaseRequest(req)
+
+	req.SetRequestURI(FileURL(site, p))
+	req.SetMethod(method)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	SetAuth(req, site)
+
+	resp := protocol.AcquireResponse()
+	if err := d.client.hc.Do(ctx, req, resp); err != nil {
+		protocol.ReleaseResponse(resp)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// library 获取站点片库，过期时重新扫描，扫描失败时继续使用旧片库
+func (d *Drive) library(ctx context.Context, key string, site config.Site) (*library, error) {
+	d.mu.Lock()
+	cached, ok := d.entries[key]
+	lock := d.scanning[key]
+	if lock == nil {
+		lock = &sync.Mutex{}
+		d.scanning[key] = lock
+	}
+	d.mu.Unlock()
+	if ok && time.Since(cached.scannedAt) < d.ttl {
+		return cached.lib, nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// 等待期间其它请求可能已完成扫描
+	d.mu.Lock()
+	cached, ok = d.entries[key]
+	d.mu.Unlock()
+	if ok && time.Since(cached.scannedAt) < d.ttl {
+		return cached.lib, nil
+	}
+
+	lib, err := scan(ctx, d.client, key, site)
+	if err != nil {
+		if ok {
+			log.Printf("扫描网盘 %s 失败，继续使用旧目录: %v", key, err)
+			return cached.lib, nil
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.entries[key] = entry{lib: lib, scannedAt: time.Now()}
+	d.mu.Unlock()
+	return lib, nil
+}
+
+// siteKey 查找网盘站点的标识，用于生成播放地址
+func siteKey(site config.Site) (string, bool) {
+	for key, s := range config.GetAllSites() {
+		if s.Type == config.SiteTypeWebDAV && s.Api == site.Api {
+			return key, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,52 @@
+package zhconv
+
+import (
+	"log"
+	"sync"
+
+	"github.com/longbridgeapp/opencc"
+)
+
+var (
+	t2s, s2t *opencc.OpenCC
+	initOnce sync.Once
+)
+
+// load 延迟加载词典，首次转换时才初始化
+func load() {
+	initOnce.Do(func() {
+		var err error
+		if t2s, err = opencc.New("t2s"); err != nil {
+			log.Printf("加载繁转简词典失败: %v", err)
+		}
+		if s2t, err = opencc.New("s2t"); err != nil {
+			log.Printf("加载简转繁词典失败: %v", err)
+		}
+	})
+}
+
+// ToSimplified 繁体转简体，转换失败时原样返回
+func ToSimplified(s string) string {
+	load()
+	if t2s == nil {
+		return s
+	}
+	out, err := t2s.Convert(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+// ToTraditional 简体转繁体，转换失败时原样返回
+func ToTraditional(s string) string {
+	load()
+	if s2t == nil {
+		return s
+	}
+	out, err := s2t.Convert(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
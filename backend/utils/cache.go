@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// TTLCache 带过期时间和容量上限的内存缓存
+type TTLCache[V any] struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]ttlEntry[V]
+}
+
+// NewTTLCache 创建缓存，maxEntries<=0 表示不限制条目数
+func NewTTLCache[V any](ttl time.Duration, maxEntries int) *TTLCache[V] {
+	return &TTLCache[V]{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]ttlEntry[V]),
+	}
+}
+
+// Get 读取未过期的缓存值
+func (c *TTLCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set 写入缓存，条目过多时先清理过期数据，仍然已满则放弃写入
+func (c *TTLCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if _, exists := c.entries[key]; !exists && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			return
+		}
+	}
+	c.entries[key] = ttlEntry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+// Delete 删除缓存
+func (c *TTLCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
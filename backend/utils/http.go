@@ -4,23 +4,29 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"net"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
 	"unicode/utf8"
 
+	"github.com/cloudwego/hertz/pkg/app/client"
 	"github.com/cloudwego/hertz/pkg/protocol"
 )
 
 // 预编译正则表达式提高性能
 var (
-	redirectRegex   = regexp.MustCompile(`window\.location\.href\s*=\s*["']([^"']+)["']`)
-	htmlTagsRegex   = regexp.MustCompile(`<[^>]+>`)
-	multiSpaceRegex = regexp.MustCompile(`\s+`)
+	redirectRegex    = regexp.MustCompile(`(?:window\.|document\.|top\.|self\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']|location\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)`)
+	metaRefreshRegex = regexp.MustCompile(`(?i)<meta[^>]+http-equiv=["']?refresh["']?[^>]+content=["']?\d+\s*;\s*url=([^"'>\s]+)`)
+	htmlTagsRegex    = regexp.MustCompile(`<[^>]+>`)
+	multiSpaceRegex  = regexp.MustCompile(`\s+`)
 )
 
 var userAgents = []string{
@@ -32,6 +38,11 @@ var userAgents = []string{
 
 // DecompressBody 解压响应体
 func DecompressBody(body []byte, contentEncoding string) ([]byte, error) {
+	return DecompressBodyLimit(body, contentEncoding, 0)
+}
+
+// DecompressBodyLimit 解压响应体，解压后超过 maxBytes 时返回 ErrBodyTooLarge，maxBytes<=0 表示不限制
+func DecompressBodyLimit(body []byte, contentEncoding string, maxBytes int64) ([]byte, error) {
 	if contentEncoding == "" {
 		return body, nil
 	}
@@ -52,7 +63,18 @@ func DecompressBody(body []byte, contentEncoding string) ([]byte, error) {
 	}
 
 	defer reader.Close()
-	return io.ReadAll(reader)
+	if maxBytes <= 0 {
+		return io.ReadAll(reader)
+	}
+	// 压缩率极高的内容解压后可能远超原始大小，同样按上限截断
+	data, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrBodyTooLarge
+	}
+	return data, nil
 }
 
 // CleanHTML 清除HTML标签
@@ -149,6 +171,21 @@ func CleanJSONString(jsonStr string) (string, error) {
 	return string(cleanedJSON), nil
 }
 
+// ExtractRedirect 从网页中提取 JavaScript 跳转或 meta refresh 的目标地址
+func ExtractRedirect(html string) (string, bool) {
+	if m := redirectRegex.FindStringSubmatch(html); m != nil {
+		for _, target := range m[1:] {
+			if target != "" {
+				return target, true
+			}
+		}
+	}
+	if m := metaRefreshRegex.FindStringSubmatch(html); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
 // SplitToArray 将逗号分隔的字符串转换为字符串数组
 func SplitToArray(input string) []string {
 	if input == "" {
@@ -200,6 +237,177 @@ func AddBrowserHeaders(req *protocol.Request, referer string) {
 	}
 }
 
+// ErrBodyTooLarge 响应体超过读取上限
+var ErrBodyTooLarge = errors.New("响应体超过大小限制")
+
+// MaxRedirects 跟随 3xx 重定向的最大次数
+const MaxRedirects = 5
+
+// ErrTooManyRedirects 重定向次数超过 MaxRedirects
+var ErrTooManyRedirects = errors.New("重定向次数过多")
+
+// URLCheck 请求前校验目标地址，返回错误时放弃请求
+type URLCheck func(ctx context.Context, u *url.URL) error
+
+// ErrPrivateAddress 目标地址解析到本机、内网或链路本地地址
+var ErrPrivateAddress = errors.New("不允许访问内网地址")
+
+// CheckPublicURL 解析主机名并拒绝本机、内网、链路本地等非公网地址，用作 URLCheck
+// 防止代理类接口被用来访问服务器所在内网(如云主机元数据地址 169.254.169.254)
+func CheckPublicURL(ctx context.Context, u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("不支持的协议: %s", u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("缺少主机名")
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+		}
+	}
+	return nil
+}
+
+// cgnatRange 运营商级 NAT 地址段 100.64.0.0/10
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublicIP 是否为公网单播地址
+func isPublicIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnatRange.Contains(ip))
+}
+
+// FetchResult 上游请求结果
+type FetchResult struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	// URL 跟随重定向后的最终地址，相对地址应以此为基准解析
+	URL string
+}
+
+// Do 发送请求并跟随 3xx 重定向(最多 MaxRedirects 次)，返回最终请求的地址
+// check 非 nil 时在每一跳请求前校验目标地址；返回时 resp 为最终响应
+func Do(ctx context.Context, c *client.Client, req *protocol.Request, resp *protocol.Response, check URLCheck) (string, error) {
+	current := req.URI().String()
+	for hop := 0; ; hop++ {
+		if check != nil {
+			u, err := url.Parse(current)
+			if err != nil {
+				return current, err
+			}
+			if err := check(ctx, u); err != nil {
+				return current, err
+			}
+		}
+		if err := c.Do(ctx, req, resp); err != nil {
+			return current, err
+		}
+
+		location := string(resp.Header.Peek("Location"))
+		if !IsRedirect(resp.StatusCode()) || location == "" {
+			return current, nil
+		}
+		if hop >= MaxRedirects {
+			resp.CloseBodyStream()
+			return current, ErrTooManyRedirects
+		}
+		base, err := url.Parse(current)
+		if err != nil {
+			return current, err
+		}
+		next, err := base.Parse(location)
+		if err != nil || (next.Scheme != "http" && next.Scheme != "https") {
+			resp.CloseBodyStream()
+			return current, fmt.Errorf("无效的重定向地址: %s", location)
+		}
+
+		resp.CloseBodyStream()
+		resp.Reset()
+		current = next.String()
+		req.SetRequestURI(current)
+	}
+}
+
+// IsRedirect 是否为带 Location 的重定向状态码
+func IsRedirect(code int) bool {
+	switch code {
+	case 301, 302, 303, 307, 308:
+		return true
+	}
+	return false
+}
+
+// Fetch 以浏览器请求头发起GET请求并读取响应体，跟随重定向，maxBytes<=0 表示不限制大小
+func Fetch(ctx context.Context, c *client.Client, targetURL, referer string, maxBytes int64) (*FetchResult, error) {
+	return FetchChecked(ctx, c, targetURL, referer, maxBytes, nil)
+}
+
+// FetchChecked 与 Fetch 相同，每一跳请求前先用 check 校验目标地址
+// maxBytes 同时限制压缩与解压后的大小
+func FetchChecked(ctx context.Context, c *client.Client, targetURL, referer string, maxBytes int64, check URLCheck) (*FetchResult, error) {
+	req, resp := protocol.AcquireRequest(), protocol.AcquireResponse()
+	defer protocol.ReleaseRequest(req)
+	defer protocol.ReleaseResponse(resp)
+
+	req.SetRequestURI(targetURL)
+	req.SetMethod("GET")
+	AddBrowserHeaders(req, referer)
+
+	finalURL, err := Do(ctx, c, req, resp, check)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.CloseBodyStream()
+
+	// 声明长度超限时直接放弃，避免无意义的读取
+	if maxBytes > 0 && int64(resp.Header.ContentLength()) > maxBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	var reader io.Reader = bytes.NewReader(resp.Body())
+	if resp.IsBodyStream() {
+		reader = resp.BodyStream()
+	}
+	if maxBytes > 0 {
+		reader = io.LimitReader(reader, maxBytes+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if maxBytes > 0 && int64(len(body)) > maxBytes {
+		return nil, ErrBodyTooLarge
+	}
+
+	// 处理压缩内容
+	if encoding := string(resp.Header.Peek("Content-Encoding")); encoding != "" {
+		decompressed, err := DecompressBodyLimit(body, encoding, maxBytes)
+		if errors.Is(err, ErrBodyTooLarge) {
+			return nil, err
+		}
+		if err == nil {
+			body = decompressed
+		}
+	}
+
+	return &FetchResult{
+		StatusCode:  resp.StatusCode(),
+		ContentType: string(resp.Header.ContentType()),
+		Body:        body,
+		URL:         finalURL,
+	}, nil
+}
+
 // LogResponse 记录响应内容(有长度限制)
 // func LogResponse(body []byte) {
 // 	maxLogLength := 500 // 最大日志长度
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestCheckPublicURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"http://127.0.0.1/a.jpg", false},
+		{"http://localhost:8080/a.jpg", false},
+		{"http://10.0.0.1/a.jpg", false},
+		{"http://192.168.1.2/a.jpg", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/a.jpg", false},
+		{"http://0.0.0.0/a.jpg", false},
+		{"http://[::1]/a.jpg", false},
+		{"http://[::ffff:127.0.0.1]/a.jpg", false},
+		{"http://[fe80::1]/a.jpg", false},
+		{"http://8.8.8.8/a.jpg", true},
+		{"http://[2001:4860:4860::8888]/a.jpg", true},
+		{"ftp://8.8.8.8/a.jpg", false},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckPublicURL(context.Background(), u); (err == nil) != tt.ok {
+			t.Errorf("CheckPublicURL(%s) = %v, want ok %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestDecompressBodyLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(make([]byte, 1<<20))
+	zw.Close()
+
+	if _, err := DecompressBodyLimit(buf.Bytes(), "gzip", 1<<10); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("gzip bomb err = %v, want ErrBodyTooLarge", err)
+	}
+	body, err := DecompressBodyLimit(buf.Bytes(), "gzip", 1<<20)
+	if err != nil || len(body) != 1<<20 {
+		t.Errorf("within limit = %d bytes, %v", len(body), err)
+	}
+}
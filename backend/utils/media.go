@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// videoExts 识别为视频的文件扩展名
+var videoExts = map[string]bool{
+	".mp4": true, ".mkv": true, ".avi": true, ".mov": true, ".wmv": true,
+	".flv": true, ".ts": true, ".m2ts": true, ".webm": true, ".rmvb": true,
+	".m4v": true, ".mpg": true, ".mpeg": true, ".3gp": true,
+}
+
+// imageExts 识别为图片的文件扩展名
+var imageExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".webp": true,
+}
+
+// IsVideoFile 根据扩展名判断是否为视频文件
+func IsVideoFile(name string) bool {
+	return videoExts[strings.ToLower(path.Ext(name))]
+}
+
+// IsImageFile 根据扩展名判断是否为图片文件
+func IsImageFile(name string) bool {
+	return imageExts[strings.ToLower(path.Ext(name))]
+}